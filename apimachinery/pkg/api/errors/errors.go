@@ -175,6 +175,17 @@ func NewGone(message string) *StatusError {
 	}}
 }
 
+// NewResourceExpired creates an error that indicates that the requested resource content has expired from
+// the server (usually due to a resourceVersion that is too old).
+func NewResourceExpired(message string) *StatusError {
+	return &StatusError{metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusGone,
+		Reason:  metav1.StatusReasonExpired,
+		Message: message,
+	}}
+}
+
 // NewInvalid returns an error indicating the item is invalid and cannot be processed.
 func NewInvalid(qualifiedKind schema.GroupKind, name string, errs field.ErrorList) *StatusError {
 	causes := make([]metav1.StatusCause, 0, len(errs))
@@ -220,6 +231,31 @@ func NewServiceUnavailable(reason string) *StatusError {
 	}}
 }
 
+// NewRequestEntityTooLargeError returns an error indicating that the request body exceeded the
+// size the server is willing to read.
+func NewRequestEntityTooLargeError(message string) *StatusError {
+	return &StatusError{metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusRequestEntityTooLarge,
+		Reason:  metav1.StatusReasonRequestEntityTooLarge,
+		Message: fmt.Sprintf("Request entity too large: %s", message),
+	}}
+}
+
+// NewTooManyRequests returns an error indicating that the server turned the request away because
+// it is busy, and that the client should retry after the given number of seconds.
+func NewTooManyRequests(message string, retryAfterSeconds int) *StatusError {
+	return &StatusError{metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    StatusTooManyRequests,
+		Reason:  metav1.StatusReasonTooManyRequests,
+		Message: message,
+		Details: &metav1.StatusDetails{
+			RetryAfterSeconds: int32(retryAfterSeconds),
+		},
+	}}
+}
+
 // NewMethodNotSupported returns an error indicating the requested action is not supported on this kind.
 func NewMethodNotSupported(qualifiedResource schema.GroupResource, action string) *StatusError {
 	return &StatusError{metav1.Status{
@@ -312,6 +348,9 @@ func NewGenericServerResponse(code int, verb string, qualifiedResource schema.Gr
 	case http.StatusMethodNotAllowed:
 		reason = metav1.StatusReasonMethodNotAllowed
 		message = "the server does not allow this method on the requested resource"
+	case http.StatusRequestEntityTooLarge:
+		reason = metav1.StatusReasonRequestEntityTooLarge
+		message = "the server has rejected the request because its body is too large"
 	case StatusUnprocessableEntity:
 		reason = metav1.StatusReasonInvalid
 		message = "the server rejected our request due to an error in our request"
@@ -380,6 +419,12 @@ func IsConflict(err error) bool {
 	return reasonForError(err) == metav1.StatusReasonConflict
 }
 
+// IsResourceExpired is true if the error indicates the resource has expired and the current action is
+// no longer possible.
+func IsResourceExpired(err error) bool {
+	return reasonForError(err) == metav1.StatusReasonExpired
+}
+
 // IsInvalid determines if the err is an error which indicates the provided resource is not valid.
 func IsInvalid(err error) bool {
 	return reasonForError(err) == metav1.StatusReasonInvalid
@@ -425,10 +470,19 @@ func IsInternalError(err error) bool {
 	return reasonForError(err) == metav1.StatusReasonInternalError
 }
 
+// IsRequestEntityTooLargeError determines if err is an error which indicates the request
+// body was too large for the server to read.
+func IsRequestEntityTooLargeError(err error) bool {
+	return reasonForError(err) == metav1.StatusReasonRequestEntityTooLarge
+}
+
 // IsTooManyRequests determines if err is an error which indicates that there are too many requests
 // that the server cannot handle.
 // TODO: update IsTooManyRequests() when the TooManyRequests(429) error returned from the API server has a non-empty Reason field
 func IsTooManyRequests(err error) bool {
+	if reasonForError(err) == metav1.StatusReasonTooManyRequests {
+		return true
+	}
 	switch t := err.(type) {
 	case APIStatus:
 		return t.Status().Code == StatusTooManyRequests
@@ -465,7 +519,7 @@ func SuggestsClientDelay(err error) (int, bool) {
 	case APIStatus:
 		if t.Status().Details != nil {
 			switch t.Status().Reason {
-			case metav1.StatusReasonServerTimeout, metav1.StatusReasonTimeout:
+			case metav1.StatusReasonServerTimeout, metav1.StatusReasonTimeout, metav1.StatusReasonTooManyRequests:
 				return int(t.Status().Details.RetryAfterSeconds), true
 			}
 		}
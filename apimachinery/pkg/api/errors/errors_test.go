@@ -73,6 +73,19 @@ func TestErrorNew(t *testing.T) {
 		t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonServiceUnavailable, string(reasonForError(err)))
 	}
 
+	err = NewRequestEntityTooLargeError("limit is 3145728")
+	if !IsRequestEntityTooLargeError(err) {
+		t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonRequestEntityTooLarge, string(reasonForError(err)))
+	}
+
+	err = NewTooManyRequests("too many requests", 1)
+	if !IsTooManyRequests(err) {
+		t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonTooManyRequests, string(reasonForError(err)))
+	}
+	if delay, ok := SuggestsClientDelay(err); !ok || delay != 1 {
+		t.Errorf("Expected \"err\" to suggest a client delay of 1 second, got %d", delay)
+	}
+
 	err = NewMethodNotSupported(resource("errors"), "create")
 	if !IsMethodNotSupported(err) {
 		t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonMethodNotAllowed, string(reasonForError(err)))
@@ -190,6 +203,7 @@ var verbCodeMap = verbCodeType{
 		http.StatusUnauthorized,
 		http.StatusForbidden,
 		http.StatusMethodNotAllowed,
+		http.StatusRequestEntityTooLarge,
 		StatusUnprocessableEntity,
 		StatusServerTimeout,
 		StatusTooManyRequests,
@@ -202,6 +216,7 @@ var verbCodeMap = verbCodeType{
 		http.StatusUnauthorized,
 		http.StatusForbidden,
 		http.StatusMethodNotAllowed,
+		http.StatusRequestEntityTooLarge,
 		StatusUnprocessableEntity,
 		StatusServerTimeout,
 		StatusTooManyRequests,
@@ -245,6 +260,10 @@ func TestNewGenericServerResponse(t *testing.T) {
 				if !IsMethodNotSupported(err) {
 					t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonMethodNotAllowed, string(reasonForError(err)))
 				}
+			case http.StatusRequestEntityTooLarge:
+				if !IsRequestEntityTooLargeError(err) {
+					t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonRequestEntityTooLarge, string(reasonForError(err)))
+				}
 			case StatusUnprocessableEntity:
 				if !IsInvalid(err) {
 					t.Errorf("Expected \"err\" to be %s, but received %s", metav1.StatusReasonInvalid, string(reasonForError(err)))
@@ -61,6 +61,27 @@ func ListAccessor(obj interface{}) (List, error) {
 	}
 }
 
+// ListInterfaceAccessor returns the paging capable list metadata of obj, or an error
+// if obj is not a list.
+func ListInterfaceAccessor(obj interface{}) (v1meta.ListInterface, error) {
+	switch t := obj.(type) {
+	case v1meta.ListInterface:
+		return t, nil
+	case ListMetaAccessor:
+		if m, ok := t.GetListMeta().(v1meta.ListInterface); ok {
+			return m, nil
+		}
+		return nil, errNotList
+	case v1meta.ListMetaAccessor:
+		if m, ok := t.GetListMeta().(v1meta.ListInterface); ok {
+			return m, nil
+		}
+		return nil, errNotList
+	default:
+		return nil, errNotList
+	}
+}
+
 // errNotObject is returned when an object implements the List style interfaces but not the Object style
 // interfaces.
 var errNotObject = fmt.Errorf("object does not implement the Object interfaces")
@@ -84,6 +105,33 @@ func Accessor(obj interface{}) (v1meta.Object, error) {
 	}
 }
 
+// AsPartialObjectMetadata takes the metav1 interface and returns a partial object.
+func AsPartialObjectMetadata(m v1meta.Object) *v1meta.PartialObjectMetadata {
+	switch t := m.(type) {
+	case *v1meta.ObjectMeta:
+		return &v1meta.PartialObjectMetadata{ObjectMeta: *t}
+	default:
+		return &v1meta.PartialObjectMetadata{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:				m.GetName(),
+				GenerateName:			m.GetGenerateName(),
+				Namespace:			m.GetNamespace(),
+				SelfLink:			m.GetSelfLink(),
+				UID:				m.GetUID(),
+				ResourceVersion:		m.GetResourceVersion(),
+				Generation:			m.GetGeneration(),
+				CreationTimestamp:		m.GetCreationTimestamp(),
+				DeletionTimestamp:		m.GetDeletionTimestamp(),
+				DeletionGracePeriodSeconds:	m.GetDeletionGracePeriodSeconds(),
+				Labels:				m.GetLabels(),
+				Annotations:			m.GetAnnotations(),
+				ClusterName:			m.GetClusterName(),
+				ManagedFields:			m.GetManagedFields(),
+			},
+		}
+	}
+}
+
 type objectAccessor struct {
 	runtime.Object
 }
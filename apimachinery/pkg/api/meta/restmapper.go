@@ -49,7 +49,7 @@ var RESTScopeNamespace = &restScope{
 	name:	     RESTScopeNameNamespace,
 	paramName:	"namespaces",
 	argumentName:     "namespace",
-	paramDescription: "object name and auth scope, such as for teams and projects",
+	paramDescription: "object name and auth scope, such as for the account owning users, projects and credentials",
 }
 
 var RESTScopeRoot = &restScope{
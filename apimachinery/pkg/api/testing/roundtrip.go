@@ -0,0 +1,138 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+// Package testing holds helpers for testing the API types.
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ProtobufMessage is implemented by the types with a protobuf encoding.
+type ProtobufMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+	Size() int
+}
+
+// maxFillDepth bounds the nesting of the structs Fill populates.
+const maxFillDepth = 8
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fill sets every field of the struct obj points to that has a protobuf tag to a distinct,
+// non-zero value, recursively. Slices and maps get two elements. Times are whole seconds, as
+// they are encoded.
+func Fill(obj interface{}) {
+	f := &filler{}
+	f.fill(reflect.ValueOf(obj).Elem(), 0)
+}
+
+type filler struct {
+	n int
+}
+
+func (f *filler) next() int {
+	f.n++
+	return f.n
+}
+
+func (f *filler) fill(v reflect.Value, depth int) {
+	if depth > maxFillDepth {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("s%d", f.next()))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(f.next()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(f.next()))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(f.next()) + 0.5)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		f.fill(p.Elem(), depth+1)
+		v.Set(p)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(fmt.Sprintf(`{"b":%d}`, f.next())))
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), 2, 2)
+		for i := 0; i < s.Len(); i++ {
+			f.fill(s.Index(i), depth+1)
+		}
+		v.Set(s)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < 2; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			f.fill(key, depth+1)
+			value := reflect.New(v.Type().Elem()).Elem()
+			f.fill(value, depth+1)
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(time.Unix(int64(1500000000+f.next()), 0).Local()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if len(field.PkgPath) != 0 {
+				continue
+			}
+			// Fields without a protobuf tag, e.g. TypeMeta, are not encoded. metav1.Time
+			// embeds its time.Time untagged.
+			if _, ok := field.Tag.Lookup("protobuf"); !ok && field.Type != timeType {
+				continue
+			}
+			f.fill(v.Field(i), depth+1)
+		}
+	}
+}
+
+// ProtobufRoundTrip fails t unless obj reads back the same after it is marshalled, and its
+// Size matches its encoding.
+func ProtobufRoundTrip(t *testing.T, obj ProtobufMessage) {
+	data, err := obj.Marshal()
+	if err != nil {
+		t.Errorf("%T: unable to marshal: %v", obj, err)
+		return
+	}
+	if len(data) != obj.Size() {
+		t.Errorf("%T: Size() is %d, the encoding is %d bytes", obj, obj.Size(), len(data))
+	}
+	decoded := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(ProtobufMessage)
+	if err := decoded.Unmarshal(data); err != nil {
+		t.Errorf("%T: unable to unmarshal: %v", obj, err)
+		return
+	}
+	if !reflect.DeepEqual(obj, decoded) {
+		t.Errorf("%T: expected\n%#v\ngot\n%#v", obj, obj, decoded)
+		return
+	}
+	again, err := decoded.Marshal()
+	if err != nil || !bytes.Equal(data, again) {
+		t.Errorf("%T: expected the decoded object to encode the same, got error %v", obj, err)
+	}
+}
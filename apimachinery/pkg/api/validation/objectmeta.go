@@ -108,3 +108,21 @@ func ValidateObjectMeta(objMeta *metav1.ObjectMeta, requiresNamespace bool, name
         }
         return ValidateObjectMetaAccessor(metadata, requiresNamespace, nameFn, fldPath)
 }
+
+// ValidateObjectMetaAccessorUpdate validates an object's metadata when updated. Fields owned by
+// the server (uid, creationTimestamp, generation) are expected to have been copied from the old
+// object already; this only guards the fields a client could otherwise change.
+func ValidateObjectMetaAccessorUpdate(newMeta, oldMeta metav1.Object, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if newMeta.GetName() != oldMeta.GetName() {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), newMeta.GetName(), "field is immutable"))
+	}
+	if newMeta.GetNamespace() != oldMeta.GetNamespace() {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), newMeta.GetNamespace(), "field is immutable"))
+	}
+	allErrs = append(allErrs, v1validation.ValidateLabels(newMeta.GetLabels(), fldPath.Child("labels"))...)
+	allErrs = append(allErrs, ValidateAnnotations(newMeta.GetAnnotations(), fldPath.Child("annotations"))...)
+
+	return allErrs
+}
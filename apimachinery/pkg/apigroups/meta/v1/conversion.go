@@ -0,0 +1,68 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package v1
+
+import (
+	"github.com/rantuttl/cloudops/apimachinery/pkg/conversion"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/fields"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/labels"
+)
+
+// NOTE (rantuttl): Only the selector and query parameter conversions are carried over for now. See
+// ./staging/src/k8s.io/apimachinery/pkg/apis/meta/v1/conversion.go for the full set.
+
+func Convert_string_To_labels_Selector(in *string, out *labels.Selector, s conversion.Scope) error {
+	selector, err := labels.Parse(*in)
+	if err != nil {
+		return err
+	}
+	*out = selector
+	return nil
+}
+
+func Convert_string_To_fields_Selector(in *string, out *fields.Selector, s conversion.Scope) error {
+	selector, err := fields.ParseSelector(*in)
+	if err != nil {
+		return err
+	}
+	*out = selector
+	return nil
+}
+
+func Convert_labels_Selector_To_string(in *labels.Selector, out *string, s conversion.Scope) error {
+	if *in == nil {
+		return nil
+	}
+	*out = (*in).String()
+	return nil
+}
+
+func Convert_fields_Selector_To_string(in *fields.Selector, out *string, s conversion.Scope) error {
+	if *in == nil {
+		return nil
+	}
+	*out = (*in).String()
+	return nil
+}
+
+// Convert_Slice_string_To_v1_IncludeObjectPolicy allows the includeObject query parameter to be
+// decoded into TableOptions.
+func Convert_Slice_string_To_v1_IncludeObjectPolicy(input *[]string, out *IncludeObjectPolicy, s conversion.Scope) error {
+	if len(*input) > 0 {
+		*out = IncludeObjectPolicy((*input)[0])
+	}
+	return nil
+}
@@ -63,6 +63,8 @@ type Object interface {
 	//SetOwnerReferences([]OwnerReference)
 	GetClusterName() string
 	SetClusterName(clusterName string)
+	GetManagedFields() []ManagedFieldsEntry
+	SetManagedFields(managedFields []ManagedFieldsEntry)
 }
 
 // ListMetaAccessor retrieves the list interface from an object
@@ -81,6 +83,14 @@ type List interface {
 	SetSelfLink(selfLink string)
 }
 
+// ListInterface extends List with the continue token of a paged list. Only list
+// metadata implements it; objects never carry a continue token.
+type ListInterface interface {
+	List
+	GetContinue() string
+	SetContinue(c string)
+}
+
 // Type exposes the type and APIVersion of versioned or internal API objects.
 // TODO: move this, and TypeMeta and ListMeta, to a different package
 type Type interface {
@@ -94,6 +104,8 @@ func (meta *ListMeta) GetResourceVersion() string        { return meta.ResourceV
 func (meta *ListMeta) SetResourceVersion(version string) { meta.ResourceVersion = version }
 func (meta *ListMeta) GetSelfLink() string               { return meta.SelfLink }
 func (meta *ListMeta) SetSelfLink(selfLink string)       { meta.SelfLink = selfLink }
+func (meta *ListMeta) GetContinue() string                { return meta.Continue }
+func (meta *ListMeta) SetContinue(c string)               { meta.Continue = c }
 
 func (obj *TypeMeta) GetObjectKind() schema.ObjectKind { return obj }
 
@@ -202,3 +214,9 @@ func (meta *ObjectMeta) GetClusterName() string {
 func (meta *ObjectMeta) SetClusterName(clusterName string) {
 	meta.ClusterName = clusterName
 }
+func (meta *ObjectMeta) GetManagedFields() []ManagedFieldsEntry {
+	return meta.ManagedFields
+}
+func (meta *ObjectMeta) SetManagedFields(managedFields []ManagedFieldsEntry) {
+	meta.ManagedFields = managedFields
+}
@@ -0,0 +1,65 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package v1
+
+import (
+	"bytes"
+	"testing"
+
+	apitesting "github.com/rantuttl/cloudops/apimachinery/pkg/api/testing"
+)
+
+func TestProtobufRoundTrip(t *testing.T) {
+	for _, obj := range []apitesting.ProtobufMessage{
+		&APIGroup{},
+		&APIGroupList{},
+		&APIResource{},
+		&APIResourceList{},
+		&BulkApplyResult{},
+		&BulkApplyResultItem{},
+		&DeleteOptions{},
+		&FieldsV1{},
+		&GroupVersionForDiscovery{},
+		&ListMeta{},
+		&ManagedFieldsEntry{},
+		&ObjectMeta{},
+		&PartialObjectMetadata{},
+		&PartialObjectMetadataList{},
+		&Preconditions{},
+		&ServerAddressByClientCIDR{},
+		&Status{},
+		&StatusCause{},
+		&StatusDetails{},
+		&Time{},
+		&Timestamp{},
+		&WatchEvent{},
+	} {
+		apitesting.Fill(obj)
+		apitesting.ProtobufRoundTrip(t, obj)
+	}
+}
+
+// TestProtobufEncoding pins the wire encoding to the messages of generated.proto.
+func TestProtobufEncoding(t *testing.T) {
+	obj := &GroupVersionForDiscovery{GroupVersion: "core/v1", Version: "v1"}
+	expected := append(append([]byte{0x0a, 0x07}, "core/v1"...), append([]byte{0x12, 0x02}, "v1"...)...)
+	data, err := obj.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("expected %x, got %x", expected, data)
+	}
+}
@@ -20,29 +20,57 @@ import (
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 )
 
+// GroupName is the group name for the common meta types shared by all API groups.
+const GroupName = "meta"
+
+// SchemeGroupVersion is group version used to register these objects
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1"}
+
 // WatchEventKind is name reserved for serializing watch events.
 const WatchEventKind = "WatchEvent"
 
+// AddMetaToScheme registers the meta types that are always served as meta/v1, whatever the
+// group of the request, into the given scheme.
+func AddMetaToScheme(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&Table{},
+		&PartialObjectMetadata{},
+		&PartialObjectMetadataList{},
+		&BulkApplyResult{},
+		// The errors of the handlers served as meta/v1, e.g. bulk apply
+		&Status{},
+	)
+	return nil
+}
+
+// AddToGroupVersion registers common meta types into schemas.
 func AddToGroupVersion(scheme *runtime.Scheme, groupVersion schema.GroupVersion) {
-	// TODO (rantuttl): Handle watch events here in the future
-	//scheme.AddKnownTypeWithName(groupVersion.WithKind(WatchEventKind), &WatchEvent{})
-	//scheme.AddKnownTypeWithName(
-	//	schema.GroupVersion{Group: groupVersion.Group, Version: runtime.APIVersionInternal}.WithKind(WatchEventKind),
-	//	// FIXME (rantuttl): Add InternalEvent to apimachinery/pkg/apigroups/meta/v1/types.go
-	//	&InternalEvent{},
-	//)
+	scheme.AddKnownTypeWithName(groupVersion.WithKind(WatchEventKind), &WatchEvent{})
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersion{Group: groupVersion.Group, Version: runtime.APIVersionInternal}.WithKind(WatchEventKind),
+		&InternalEvent{},
+	)
 
 	scheme.AddKnownTypes(groupVersion,
-		// FIXME (rantuttl): Add ListOptions to apimachinery/pkg/apigroups/meta/v1/types.go
-		//&ListOptions{},
+		&ListOptions{},
 		// TODO (rantuttl): Consider moving 'Status" to here from the likes of /apiserver/pkg/api/core/v1/register.go
 		//&Status{},
 		&ExportOptions{},
 		&GetOptions{},
 		&DeleteOptions{},
+		&CreateOptions{},
+		&UpdateOptions{},
+		&PatchOptions{},
+		&BulkApplyOptions{},
+		&TableOptions{},
+	)
+	scheme.AddConversionFuncs(
+		Convert_versioned_Event_to_watch_Event,
+		Convert_versioned_InternalEvent_to_versioned_Event,
+		Convert_watch_Event_to_versioned_Event,
+		Convert_versioned_Event_to_versioned_InternalEvent,
 	)
 	// See ./staging/src/k8s.io/apimachinery/pkg/apis/meta/v1/register.go for generic conversion functions
-	// FUTURE FIXME (rantuttl): Add watcher conversion funcs as needed.
 
 	scheme.AddGeneratedDeepCopyFuncs(GetGeneratedDeepCopyFuncs()...)
 	//AddConversionFuncs(scheme)
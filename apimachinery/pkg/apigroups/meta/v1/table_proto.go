@@ -0,0 +1,46 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+)
+
+// errTableNotMarshalable is returned for any attempt to encode or decode a Table as protobuf.
+var errTableNotMarshalable = fmt.Errorf("object *v1.Table does not implement the protobuf marshalling interface and cannot be encoded to a protobuf message")
+
+// Table cells hold arbitrary values and have no protobuf form. The methods below shadow the
+// ones promoted from the embedded ListMeta, which would otherwise encode only the list metadata.
+
+// Size implements the protobuf marshalling interface.
+func (m *Table) Size() (n int) {
+	return 0
+}
+
+// Marshal implements the protobuf marshalling interface.
+func (m *Table) Marshal() (data []byte, err error) {
+	return nil, errTableNotMarshalable
+}
+
+// MarshalTo implements the protobuf marshalling interface.
+func (m *Table) MarshalTo(data []byte) (int, error) {
+	return 0, errTableNotMarshalable
+}
+
+// Unmarshal implements the protobuf marshalling interface.
+func (m *Table) Unmarshal(data []byte) error {
+	return errTableNotMarshalable
+}
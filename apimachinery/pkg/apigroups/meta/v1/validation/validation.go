@@ -17,6 +17,7 @@ package validation
 
 import (
 	"fmt"
+	"unicode"
 
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/util/validation"
@@ -87,3 +88,36 @@ func ValidateDeleteOptions(options *metav1.DeleteOptions) field.ErrorList {
 	}
 	return allErrs
 }
+
+var allowedDryRunValues = []string{metav1.DryRunAll}
+
+// ValidateDryRun validates that a dryRun query param only contains allowed values.
+func ValidateDryRun(fldPath *field.Path, dryRun []string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, value := range dryRun {
+		if value != metav1.DryRunAll {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Index(i), value, allowedDryRunValues))
+		}
+	}
+	return allErrs
+}
+
+// FieldManagerMaxLength is the maximum length of a field manager name.
+const FieldManagerMaxLength = 128
+
+// ValidateFieldManager validates that the fieldManager name is at most 128 characters long
+// and only contains printable characters. An empty name is valid, the caller decides whether
+// it is required.
+func ValidateFieldManager(fieldManager string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(fieldManager) > FieldManagerMaxLength {
+		allErrs = append(allErrs, field.TooLong(fldPath, fieldManager, FieldManagerMaxLength))
+	}
+	for _, r := range fieldManager {
+		if !unicode.IsPrint(r) {
+			allErrs = append(allErrs, field.Invalid(fldPath, fieldManager, "must only contain printable characters"))
+			break
+		}
+	}
+	return allErrs
+}
@@ -24,7 +24,7 @@ import (
 	"reflect"
 
 	"github.com/rantuttl/cloudops/apimachinery/pkg/conversion"
-	//"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
 )
 
@@ -32,46 +32,59 @@ import (
 func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 	return []conversion.GeneratedDeepCopyFunc{
 		// FIXME (rantuttl): Of the commented types, which do we need
-		//{Fn: DeepCopy_v1_APIGroup, InType: reflect.TypeOf(&APIGroup{})},
-		//{Fn: DeepCopy_v1_APIGroupList, InType: reflect.TypeOf(&APIGroupList{})},
+		{Fn: DeepCopy_v1_APIGroup, InType: reflect.TypeOf(&APIGroup{})},
+		{Fn: DeepCopy_v1_APIGroupList, InType: reflect.TypeOf(&APIGroupList{})},
 		{Fn: DeepCopy_v1_APIResource, InType: reflect.TypeOf(&APIResource{})},
 		{Fn: DeepCopy_v1_APIResourceList, InType: reflect.TypeOf(&APIResourceList{})},
 		//{Fn: DeepCopy_v1_APIVersions, InType: reflect.TypeOf(&APIVersions{})},
+		{Fn: DeepCopy_v1_BulkApplyOptions, InType: reflect.TypeOf(&BulkApplyOptions{})},
+		{Fn: DeepCopy_v1_BulkApplyResult, InType: reflect.TypeOf(&BulkApplyResult{})},
+		{Fn: DeepCopy_v1_BulkApplyResultItem, InType: reflect.TypeOf(&BulkApplyResultItem{})},
+		{Fn: DeepCopy_v1_CreateOptions, InType: reflect.TypeOf(&CreateOptions{})},
 		{Fn: DeepCopy_v1_DeleteOptions, InType: reflect.TypeOf(&DeleteOptions{})},
 		//{Fn: DeepCopy_v1_Duration, InType: reflect.TypeOf(&Duration{})},
 		{Fn: DeepCopy_v1_ExportOptions, InType: reflect.TypeOf(&ExportOptions{})},
+		{Fn: DeepCopy_v1_FieldsV1, InType: reflect.TypeOf(&FieldsV1{})},
 		{Fn: DeepCopy_v1_GetOptions, InType: reflect.TypeOf(&GetOptions{})},
 		{Fn: DeepCopy_v1_GroupKind, InType: reflect.TypeOf(&GroupKind{})},
 		{Fn: DeepCopy_v1_GroupResource, InType: reflect.TypeOf(&GroupResource{})},
 		{Fn: DeepCopy_v1_GroupVersion, InType: reflect.TypeOf(&GroupVersion{})},
-		//{Fn: DeepCopy_v1_GroupVersionForDiscovery, InType: reflect.TypeOf(&GroupVersionForDiscovery{})},
+		{Fn: DeepCopy_v1_GroupVersionForDiscovery, InType: reflect.TypeOf(&GroupVersionForDiscovery{})},
 		{Fn: DeepCopy_v1_GroupVersionKind, InType: reflect.TypeOf(&GroupVersionKind{})},
 		{Fn: DeepCopy_v1_GroupVersionResource, InType: reflect.TypeOf(&GroupVersionResource{})},
 		//{Fn: DeepCopy_v1_Initializer, InType: reflect.TypeOf(&Initializer{})},
 		//{Fn: DeepCopy_v1_Initializers, InType: reflect.TypeOf(&Initializers{})},
-		//{Fn: DeepCopy_v1_InternalEvent, InType: reflect.TypeOf(&InternalEvent{})},
+		{Fn: DeepCopy_v1_InternalEvent, InType: reflect.TypeOf(&InternalEvent{})},
 		{Fn: DeepCopy_v1_LabelSelector, InType: reflect.TypeOf(&LabelSelector{})},
 		{Fn: DeepCopy_v1_LabelSelectorRequirement, InType: reflect.TypeOf(&LabelSelectorRequirement{})},
 		{Fn: DeepCopy_v1_ListMeta, InType: reflect.TypeOf(&ListMeta{})},
-		//{Fn: DeepCopy_v1_ListOptions, InType: reflect.TypeOf(&ListOptions{})},
+		{Fn: DeepCopy_v1_ListOptions, InType: reflect.TypeOf(&ListOptions{})},
+		{Fn: DeepCopy_v1_ManagedFieldsEntry, InType: reflect.TypeOf(&ManagedFieldsEntry{})},
 		//{Fn: DeepCopy_v1_MicroTime, InType: reflect.TypeOf(&MicroTime{})},
 		{Fn: DeepCopy_v1_ObjectMeta, InType: reflect.TypeOf(&ObjectMeta{})},
 		//{Fn: DeepCopy_v1_OwnerReference, InType: reflect.TypeOf(&OwnerReference{})},
+		{Fn: DeepCopy_v1_PartialObjectMetadata, InType: reflect.TypeOf(&PartialObjectMetadata{})},
+		{Fn: DeepCopy_v1_PartialObjectMetadataList, InType: reflect.TypeOf(&PartialObjectMetadataList{})},
 		//{Fn: DeepCopy_v1_Patch, InType: reflect.TypeOf(&Patch{})},
+		{Fn: DeepCopy_v1_PatchOptions, InType: reflect.TypeOf(&PatchOptions{})},
 		{Fn: DeepCopy_v1_Preconditions, InType: reflect.TypeOf(&Preconditions{})},
 		//{Fn: DeepCopy_v1_RootPaths, InType: reflect.TypeOf(&RootPaths{})},
-		//{Fn: DeepCopy_v1_ServerAddressByClientCIDR, InType: reflect.TypeOf(&ServerAddressByClientCIDR{})},
+		{Fn: DeepCopy_v1_ServerAddressByClientCIDR, InType: reflect.TypeOf(&ServerAddressByClientCIDR{})},
 		{Fn: DeepCopy_v1_Status, InType: reflect.TypeOf(&Status{})},
 		{Fn: DeepCopy_v1_StatusCause, InType: reflect.TypeOf(&StatusCause{})},
 		{Fn: DeepCopy_v1_StatusDetails, InType: reflect.TypeOf(&StatusDetails{})},
+		{Fn: DeepCopy_v1_Table, InType: reflect.TypeOf(&Table{})},
+		{Fn: DeepCopy_v1_TableColumnDefinition, InType: reflect.TypeOf(&TableColumnDefinition{})},
+		{Fn: DeepCopy_v1_TableOptions, InType: reflect.TypeOf(&TableOptions{})},
+		{Fn: DeepCopy_v1_TableRow, InType: reflect.TypeOf(&TableRow{})},
 		{Fn: DeepCopy_v1_Time, InType: reflect.TypeOf(&Time{})},
 		//{Fn: DeepCopy_v1_Timestamp, InType: reflect.TypeOf(&Timestamp{})},
 		{Fn: DeepCopy_v1_TypeMeta, InType: reflect.TypeOf(&TypeMeta{})},
-		//{Fn: DeepCopy_v1_WatchEvent, InType: reflect.TypeOf(&WatchEvent{})},
+		{Fn: DeepCopy_v1_UpdateOptions, InType: reflect.TypeOf(&UpdateOptions{})},
+		{Fn: DeepCopy_v1_WatchEvent, InType: reflect.TypeOf(&WatchEvent{})},
 	}
 }
 
-/* FIXME (rantuttl)
 // DeepCopy_v1_APIGroup is an autogenerated deepcopy function.
 func DeepCopy_v1_APIGroup(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -112,7 +125,6 @@ func DeepCopy_v1_APIGroupList(in interface{}, out interface{}, c *conversion.Clo
 		return nil
 	}
 }
-*/ // FIXME (rantuttl)
 
 // DeepCopy_v1_APIResource is an autogenerated deepcopy function.
 func DeepCopy_v1_APIResource(in interface{}, out interface{}, c *conversion.Cloner) error {
@@ -182,6 +194,77 @@ func DeepCopy_v1_APIVersions(in interface{}, out interface{}, c *conversion.Clon
 }
 */ // FIXME (rantuttl)
 
+// DeepCopy_v1_BulkApplyOptions is an autogenerated deepcopy function.
+func DeepCopy_v1_BulkApplyOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*BulkApplyOptions)
+		out := out.(*BulkApplyOptions)
+		*out = *in
+		if in.DryRun != nil {
+			in, out := &in.DryRun, &out.DryRun
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+		if in.Force != nil {
+			in, out := &in.Force, &out.Force
+			*out = new(bool)
+			**out = **in
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_BulkApplyResult is an autogenerated deepcopy function.
+func DeepCopy_v1_BulkApplyResult(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*BulkApplyResult)
+		out := out.(*BulkApplyResult)
+		*out = *in
+		if in.Items != nil {
+			in, out := &in.Items, &out.Items
+			*out = make([]BulkApplyResultItem, len(*in))
+			for i := range *in {
+				if err := DeepCopy_v1_BulkApplyResultItem(&(*in)[i], &(*out)[i], c); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_BulkApplyResultItem is an autogenerated deepcopy function.
+func DeepCopy_v1_BulkApplyResultItem(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*BulkApplyResultItem)
+		out := out.(*BulkApplyResultItem)
+		*out = *in
+		if in.Status != nil {
+			in, out := &in.Status, &out.Status
+			*out = new(Status)
+			if err := DeepCopy_v1_Status(*in, *out, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_CreateOptions is an autogenerated deepcopy function.
+func DeepCopy_v1_CreateOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*CreateOptions)
+		out := out.(*CreateOptions)
+		*out = *in
+		if in.DryRun != nil {
+			in, out := &in.DryRun, &out.DryRun
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_DeleteOptions is an autogenerated deepcopy function.
 func DeepCopy_v1_DeleteOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -211,6 +294,11 @@ func DeepCopy_v1_DeleteOptions(in interface{}, out interface{}, c *conversion.Cl
 			*out = new(DeletionPropagation)
 			**out = **in
 		}
+		if in.DryRun != nil {
+			in, out := &in.DryRun, &out.DryRun
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
 		return nil
 	}
 }
@@ -237,6 +325,21 @@ func DeepCopy_v1_ExportOptions(in interface{}, out interface{}, c *conversion.Cl
 	}
 }
 
+// DeepCopy_v1_FieldsV1 is an autogenerated deepcopy function.
+func DeepCopy_v1_FieldsV1(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*FieldsV1)
+		out := out.(*FieldsV1)
+		*out = *in
+		if in.Raw != nil {
+			in, out := &in.Raw, &out.Raw
+			*out = make([]byte, len(*in))
+			copy(*out, *in)
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_GetOptions is an autogenerated deepcopy function.
 func DeepCopy_v1_GetOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -277,7 +380,6 @@ func DeepCopy_v1_GroupVersion(in interface{}, out interface{}, c *conversion.Clo
 	}
 }
 
-/* FIXME (rantuttl)
 // DeepCopy_v1_GroupVersionForDiscovery is an autogenerated deepcopy function.
 func DeepCopy_v1_GroupVersionForDiscovery(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -287,7 +389,6 @@ func DeepCopy_v1_GroupVersionForDiscovery(in interface{}, out interface{}, c *co
 		return nil
 	}
 }
-*/ // FIXME (rantuttl)
 
 // DeepCopy_v1_GroupVersionKind is an autogenerated deepcopy function.
 func DeepCopy_v1_GroupVersionKind(in interface{}, out interface{}, c *conversion.Cloner) error {
@@ -343,6 +444,8 @@ func DeepCopy_v1_Initializers(in interface{}, out interface{}, c *conversion.Clo
 	}
 }
 
+*/ // FIXME (rantuttl)
+
 // DeepCopy_v1_InternalEvent is an autogenerated deepcopy function.
 func DeepCopy_v1_InternalEvent(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -360,7 +463,6 @@ func DeepCopy_v1_InternalEvent(in interface{}, out interface{}, c *conversion.Cl
 		return nil
 	}
 }
-*/ // FIXME (rantuttl)
 
 // DeepCopy_v1_LabelSelector is an autogenerated deepcopy function.
 func DeepCopy_v1_LabelSelector(in interface{}, out interface{}, c *conversion.Cloner) error {
@@ -415,7 +517,6 @@ func DeepCopy_v1_ListMeta(in interface{}, out interface{}, c *conversion.Cloner)
 	}
 }
 
-/* FIXME (rantuttl)
 // DeepCopy_v1_ListOptions is an autogenerated deepcopy function.
 func DeepCopy_v1_ListOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -431,6 +532,7 @@ func DeepCopy_v1_ListOptions(in interface{}, out interface{}, c *conversion.Clon
 	}
 }
 
+/* FIXME (rantuttl)
 // DeepCopy_v1_MicroTime is an autogenerated deepcopy function.
 func DeepCopy_v1_MicroTime(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -442,6 +544,28 @@ func DeepCopy_v1_MicroTime(in interface{}, out interface{}, c *conversion.Cloner
 }
 */ // FIXME (rantuttl)
 
+// DeepCopy_v1_ManagedFieldsEntry is an autogenerated deepcopy function.
+func DeepCopy_v1_ManagedFieldsEntry(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*ManagedFieldsEntry)
+		out := out.(*ManagedFieldsEntry)
+		*out = *in
+		if in.Time != nil {
+			in, out := &in.Time, &out.Time
+			*out = new(Time)
+			**out = (*in).DeepCopy()
+		}
+		if in.FieldsV1 != nil {
+			in, out := &in.FieldsV1, &out.FieldsV1
+			*out = new(FieldsV1)
+			if err := DeepCopy_v1_FieldsV1(*in, *out, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_ObjectMeta is an autogenerated deepcopy function.
 func DeepCopy_v1_ObjectMeta(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -473,6 +597,15 @@ func DeepCopy_v1_ObjectMeta(in interface{}, out interface{}, c *conversion.Clone
 				(*out)[key] = val
 			}
 		}
+		if in.ManagedFields != nil {
+			in, out := &in.ManagedFields, &out.ManagedFields
+			*out = make([]ManagedFieldsEntry, len(*in))
+			for i := range *in {
+				if err := DeepCopy_v1_ManagedFieldsEntry(&(*in)[i], &(*out)[i], c); err != nil {
+					return err
+				}
+			}
+		}
 /* FIXME (rantuttl)
 		if in.OwnerReferences != nil {
 			in, out := &in.OwnerReferences, &out.OwnerReferences
@@ -535,6 +668,58 @@ func DeepCopy_v1_Patch(in interface{}, out interface{}, c *conversion.Cloner) er
 }
 */ // FIXME (rantuttl)
 
+// DeepCopy_v1_PartialObjectMetadata is an autogenerated deepcopy function.
+func DeepCopy_v1_PartialObjectMetadata(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*PartialObjectMetadata)
+		out := out.(*PartialObjectMetadata)
+		*out = *in
+		if err := DeepCopy_v1_ObjectMeta(&in.ObjectMeta, &out.ObjectMeta, c); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_PartialObjectMetadataList is an autogenerated deepcopy function.
+func DeepCopy_v1_PartialObjectMetadataList(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*PartialObjectMetadataList)
+		out := out.(*PartialObjectMetadataList)
+		*out = *in
+		if in.Items != nil {
+			in, out := &in.Items, &out.Items
+			*out = make([]PartialObjectMetadata, len(*in))
+			for i := range *in {
+				if err := DeepCopy_v1_PartialObjectMetadata(&(*in)[i], &(*out)[i], c); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_PatchOptions is an autogenerated deepcopy function.
+func DeepCopy_v1_PatchOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*PatchOptions)
+		out := out.(*PatchOptions)
+		*out = *in
+		if in.DryRun != nil {
+			in, out := &in.DryRun, &out.DryRun
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+		if in.Force != nil {
+			in, out := &in.Force, &out.Force
+			*out = new(bool)
+			**out = **in
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_Preconditions is an autogenerated deepcopy function.
 func DeepCopy_v1_Preconditions(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -565,6 +750,7 @@ func DeepCopy_v1_RootPaths(in interface{}, out interface{}, c *conversion.Cloner
 		return nil
 	}
 }
+*/ // FIXME (rantuttl)
 
 // DeepCopy_v1_ServerAddressByClientCIDR is an autogenerated deepcopy function.
 func DeepCopy_v1_ServerAddressByClientCIDR(in interface{}, out interface{}, c *conversion.Cloner) error {
@@ -575,7 +761,6 @@ func DeepCopy_v1_ServerAddressByClientCIDR(in interface{}, out interface{}, c *c
 		return nil
 	}
 }
-*/ // FIXME (rantuttl)
 
 // DeepCopy_v1_Status is an autogenerated deepcopy function.
 func DeepCopy_v1_Status(in interface{}, out interface{}, c *conversion.Cloner) error {
@@ -620,6 +805,72 @@ func DeepCopy_v1_StatusDetails(in interface{}, out interface{}, c *conversion.Cl
 	}
 }
 
+// DeepCopy_v1_Table is an autogenerated deepcopy function.
+func DeepCopy_v1_Table(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*Table)
+		out := out.(*Table)
+		*out = *in
+		if in.ColumnDefinitions != nil {
+			in, out := &in.ColumnDefinitions, &out.ColumnDefinitions
+			*out = make([]TableColumnDefinition, len(*in))
+			copy(*out, *in)
+		}
+		if in.Rows != nil {
+			in, out := &in.Rows, &out.Rows
+			*out = make([]TableRow, len(*in))
+			for i := range *in {
+				if err := DeepCopy_v1_TableRow(&(*in)[i], &(*out)[i], c); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DeepCopy_v1_TableColumnDefinition is an autogenerated deepcopy function.
+func DeepCopy_v1_TableColumnDefinition(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*TableColumnDefinition)
+		out := out.(*TableColumnDefinition)
+		*out = *in
+		return nil
+	}
+}
+
+// DeepCopy_v1_TableOptions is an autogenerated deepcopy function.
+func DeepCopy_v1_TableOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*TableOptions)
+		out := out.(*TableOptions)
+		*out = *in
+		return nil
+	}
+}
+
+// DeepCopy_v1_TableRow is an autogenerated deepcopy function.
+func DeepCopy_v1_TableRow(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*TableRow)
+		out := out.(*TableRow)
+		*out = *in
+		if in.Cells != nil {
+			if newVal, err := c.DeepCopy(&in.Cells); err != nil {
+				return err
+			} else {
+				out.Cells = *newVal.(*[]interface{})
+			}
+		}
+		if newVal, err := c.DeepCopy(&in.Object); err != nil {
+			return err
+		} else {
+			out.Object = *newVal.(*runtime.RawExtension)
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_Time is an autogenerated deepcopy function.
 func DeepCopy_v1_Time(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -652,7 +903,21 @@ func DeepCopy_v1_TypeMeta(in interface{}, out interface{}, c *conversion.Cloner)
 	}
 }
 
-/* FIXME (rantuttl)
+// DeepCopy_v1_UpdateOptions is an autogenerated deepcopy function.
+func DeepCopy_v1_UpdateOptions(in interface{}, out interface{}, c *conversion.Cloner) error {
+	{
+		in := in.(*UpdateOptions)
+		out := out.(*UpdateOptions)
+		*out = *in
+		if in.DryRun != nil {
+			in, out := &in.DryRun, &out.DryRun
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+		return nil
+	}
+}
+
 // DeepCopy_v1_WatchEvent is an autogenerated deepcopy function.
 func DeepCopy_v1_WatchEvent(in interface{}, out interface{}, c *conversion.Cloner) error {
 	{
@@ -667,4 +932,3 @@ func DeepCopy_v1_WatchEvent(in interface{}, out interface{}, c *conversion.Clone
 		return nil
 	}
 }
-*/ // FIXME (rantuttl)
@@ -0,0 +1,32 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package runtime
+
+import (
+	"testing"
+
+	apitesting "github.com/rantuttl/cloudops/apimachinery/pkg/api/testing"
+)
+
+func TestProtobufRoundTrip(t *testing.T) {
+	for _, obj := range []apitesting.ProtobufMessage{
+		&RawExtension{},
+		&TypeMeta{},
+		&Unknown{},
+	} {
+		apitesting.Fill(obj)
+		apitesting.ProtobufRoundTrip(t, obj)
+	}
+}
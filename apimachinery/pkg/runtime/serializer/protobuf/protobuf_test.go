@@ -0,0 +1,184 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package protobuf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+)
+
+var testGroupVersion = schema.GroupVersion{Group: "test", Version: "v1"}
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(testGroupVersion, &metav1.Status{}, &metav1.PartialObjectMetadata{}, &metav1.PartialObjectMetadataList{}, &metav1.Table{})
+	return scheme
+}
+
+func newTestObject() *metav1.PartialObjectMetadata {
+	created := metav1.NewTime(time.Unix(1500000000, 0))
+	grace := int64(30)
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: testGroupVersion.String(), Kind: "PartialObjectMetadata"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:                       "foo",
+			Namespace:                  "acme",
+			UID:                        types.UID("6c5b8a2e"),
+			ResourceVersion:            "12",
+			Generation:                 3,
+			CreationTimestamp:          created,
+			DeletionTimestamp:          &created,
+			DeletionGracePeriodSeconds: &grace,
+			Labels:                     map[string]string{"team": "gitops", "env": "prod"},
+			Annotations:                map[string]string{"note": ""},
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    "ci",
+				Operation:  metav1.ManagedFieldsOperationApply,
+				APIVersion: testGroupVersion.String(),
+				Time:       &created,
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{}}`)},
+			}},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(scheme, scheme, runtime.ContentTypeProtobuf)
+
+	status := &metav1.Status{
+		TypeMeta: metav1.TypeMeta{APIVersion: testGroupVersion.String(), Kind: "Status"},
+		Status:   metav1.StatusFailure,
+		Message:  "conflict",
+		Reason:   metav1.StatusReasonConflict,
+		Code:     409,
+		Details: &metav1.StatusDetails{
+			Name:              "foo",
+			Kind:              "accounts",
+			RetryAfterSeconds: 5,
+			Causes:            []metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict, Message: "conflict with ci", Field: ".metadata.labels.team"}},
+		},
+	}
+	list := &metav1.PartialObjectMetadataList{
+		TypeMeta: metav1.TypeMeta{APIVersion: testGroupVersion.String(), Kind: "PartialObjectMetadataList"},
+		ListMeta: metav1.ListMeta{ResourceVersion: "12", Continue: "token"},
+		Items:    []metav1.PartialObjectMetadata{{ObjectMeta: newTestObject().ObjectMeta}, {}},
+	}
+
+	for _, obj := range []runtime.Object{newTestObject(), status, list} {
+		buf := &bytes.Buffer{}
+		if err := s.Encode(obj, buf); err != nil {
+			t.Fatalf("unexpected error encoding %T: %v", obj, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), protoEncodingPrefix) {
+			t.Fatalf("expected %T to be written with the protobuf prefix, got %q", obj, buf.Bytes())
+		}
+		if ok, _, _ := s.RecognizesData(bytes.NewReader(buf.Bytes())); !ok {
+			t.Fatalf("expected the serializer to recognize its own output")
+		}
+
+		out, gvk, err := s.Decode(buf.Bytes(), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %T: %v", obj, err)
+		}
+		if *gvk != obj.GetObjectKind().GroupVersionKind() {
+			t.Errorf("unexpected kind %v for %T", gvk, obj)
+		}
+		// type information travels in the envelope, not in the message
+		out.GetObjectKind().SetGroupVersionKind(*gvk)
+		if !reflect.DeepEqual(obj, out) {
+			t.Errorf("round trip mismatch:\nexpected: %#v\n     got: %#v", obj, out)
+		}
+	}
+}
+
+func TestDecodeIntoUnknown(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(scheme, scheme, runtime.ContentTypeProtobuf)
+
+	buf := &bytes.Buffer{}
+	if err := s.Encode(newTestObject(), buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unk := &runtime.Unknown{}
+	if _, _, err := s.Decode(buf.Bytes(), nil, unk); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unk.Kind != "PartialObjectMetadata" || unk.APIVersion != testGroupVersion.String() || len(unk.Raw) == 0 {
+		t.Fatalf("unexpected unknown: %#v", unk)
+	}
+
+	if _, _, err := s.Decode([]byte(`{"kind":"Status"}`), nil, nil); err == nil {
+		t.Fatalf("expected data without the protobuf prefix to be rejected")
+	}
+}
+
+func TestNotMarshalable(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(scheme, scheme, runtime.ContentTypeProtobuf)
+
+	table := &metav1.Table{TypeMeta: metav1.TypeMeta{APIVersion: testGroupVersion.String(), Kind: "Table"}}
+	// Table must not fall back to the methods promoted from its embedded ListMeta
+	if err := s.Encode(table, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected an error encoding a Table")
+	}
+}
+
+func TestLengthDelimitedFraming(t *testing.T) {
+	scheme := newTestScheme()
+	raw := NewRawSerializer(scheme, scheme, runtime.ContentTypeProtobuf)
+
+	buf := &bytes.Buffer{}
+	w := LengthDelimitedFramer.NewFrameWriter(buf)
+	events := []*metav1.WatchEvent{
+		{Type: "ADDED", Object: runtime.RawExtension{Raw: []byte("first")}},
+		{Type: "DELETED", Object: runtime.RawExtension{Raw: []byte("second")}},
+	}
+	for _, event := range events {
+		data, err := event.Marshal()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	r := LengthDelimitedFramer.NewFrameReader(ioutil.NopCloser(buf))
+	for _, expected := range events {
+		frame := make([]byte, 1024)
+		n, err := r.Read(frame)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		event := &metav1.WatchEvent{}
+		if _, _, err := raw.Decode(frame[:n], &schema.GroupVersionKind{Group: "test", Version: "v1", Kind: "WatchEvent"}, event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(expected, event) {
+			t.Errorf("expected %#v, got %#v", expected, event)
+		}
+	}
+}
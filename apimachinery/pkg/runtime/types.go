@@ -41,6 +41,7 @@ type TypeMeta struct {
 
 const (
 	ContentTypeJSON string = "application/json"
+	ContentTypeProtobuf string = "application/vnd.cloudops.protobuf"
 )
 
 // RawExtension is used to hold extensions in external versions.
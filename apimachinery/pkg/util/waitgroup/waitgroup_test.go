@@ -0,0 +1,52 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package waitgroup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitGroup(t *testing.T) {
+	wg := &SafeWaitGroup{}
+	if err := wg.Add(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waited)
+	}()
+	// Let Wait start.
+	time.Sleep(100 * time.Millisecond)
+
+	if err := wg.Add(1); err == nil {
+		t.Errorf("expected Add after Wait to fail")
+	}
+	wg.Done()
+	select {
+	case <-waited:
+		t.Fatalf("expected Wait to block until the counter is zero")
+	default:
+	}
+	wg.Done()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Wait to return once the counter is zero")
+	}
+}
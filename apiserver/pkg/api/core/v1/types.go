@@ -20,7 +20,7 @@ import (
 )
 
 type AccountStatus struct {
-	Phase AccountPhase
+	Phase AccountPhase `protobuf:"bytes,1,opt,name=phase,casttype=AccountPhase"`
 }
 
 type AccountPhase string
@@ -33,9 +33,9 @@ const (
 
 type Account struct {
 	v1meta.TypeMeta `json:",inline"`
-	v1meta.ObjectMeta `json:"metadata,omitempty"`
-	Spec AccountSpec `json:"spec,omitempty"`
-	Status AccountStatus `json:"status,omitempty"`
+	v1meta.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Spec AccountSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	Status AccountStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
 }
 
 type AccountSpec struct {
@@ -48,27 +48,27 @@ type AccountSpec struct {
 
 type AccountList struct {
 	v1meta.TypeMeta `json:",inline"`
-	v1meta.ListMeta `json:"metadata,omitempty"`
-	Items []Account `json:"items"`
+	v1meta.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Items []Account `json:"items" protobuf:"bytes,2,rep,name=items"`
 }
 
 // TODO (rantuttl): Add other "core" group types below
 type User struct {
 	v1meta.TypeMeta `json:",inline"`
-	v1meta.ObjectMeta `json:"metadata,omitempty"`
-	Spec UserSpec `json:"spec,omitempty"`
+	v1meta.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Spec UserSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
 }
 
 type UserSpec struct {
 	// TODO (rantuttl): add additional info as needed about user specifics
 	// First/Last name, username, email, password(salt:sha256-hash), account
-	FirstName string `json:"firstname,omitempty"`
-	LastName string `json:"lastname,omitempty"`
-	Username string `json:"username"`
+	FirstName string `json:"firstname,omitempty" protobuf:"bytes,1,opt,name=firstname"`
+	LastName string `json:"lastname,omitempty" protobuf:"bytes,2,opt,name=lastname"`
+	Username string `json:"username" protobuf:"bytes,3,opt,name=username"`
 }
 
 type UserList struct {
 	v1meta.TypeMeta `json:",inline"`
-	v1meta.ListMeta `json:"metadata,omitempty"`
-	Items []User `json:"items"`
+	v1meta.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Items []User `json:"items" protobuf:"bytes,2,rep,name=items"`
 }
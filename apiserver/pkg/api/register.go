@@ -19,6 +19,7 @@ import (
 	"os"
 
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/apimachinery/registered"
@@ -46,6 +47,22 @@ const GroupName = ""
 // SchemeGroupVersion is group version used to register these objects
 var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: runtime.APIVersionInternal}
 
+// Unversioned is group version for unversioned API objects
+var Unversioned = schema.GroupVersion{Group: "", Version: "v1"}
+
+func init() {
+	// Register Unversioned types under their own special group
+	Scheme.AddUnversionedTypes(Unversioned,
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+	// Register the meta types served as meta/v1 for every group, e.g. tables
+	if err := metav1.AddMetaToScheme(Scheme); err != nil {
+		panic(err)
+	}
+}
+
 // Resource takes an unqualified resource and returns a Group qualified GroupResource
 func Resource(resource string) schema.GroupResource {
 	return SchemeGroupVersion.WithResource(resource).GroupResource()
@@ -34,6 +34,15 @@ func ValidateAccount(account *core.Account) field.ErrorList {
 	return allErrs
 }
 
+// ValidateAccountUpdate tests to make sure an account update can be applied. The account's
+// status is owned by the server and has already been reset by the strategy.
+func ValidateAccountUpdate(newAccount *core.Account, oldAccount *core.Account) field.ErrorList {
+	allErrs := ValidateAccount(newAccount)
+	// TODO (rantuttl): Add any spec immutability checks here once AccountSpec has content
+
+	return allErrs
+}
+
 // ValidateObjectMeta validates an object's metadata on creation. It expects that name generation has already
 // been performed.
 // It doesn't return an error for rootscoped resources with namespace, because namespace should already be cleared before.
@@ -39,7 +39,8 @@ func Install(groupFactoryRegistry announced.APIGroupFactoryRegistry, registry *r
 			// TODO (rantuttl): Consider creating a polyill of all API group types to apiserver/pkg/api/types.go
 			// This way, the import path and the ImportPrefx below will be apiserver/pkg/api
 			ImportPrefix:			"apiserver/pkg/api/core/v1",
-			// the list of kinds that are scoped at the root of the api hierarchy; otherwise it's namespace scoped
+			// the list of kinds that are scoped at the root of the api hierarchy; otherwise it's namespace scoped.
+			// Resources owned by an account (users, projects, credentials) are namespaced, one namespace per account.
 			RootScopedKinds:		sets.NewString("Account", "AccountList"),
 			AddInternalObjectsToScheme:	core.AddToScheme,
 		},
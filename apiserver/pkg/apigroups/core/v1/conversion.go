@@ -16,10 +16,25 @@
 package v1
 
 import (
+	"fmt"
+
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 )
 
 func addConversionFuncs(scheme *runtime.Scheme) error {
-	// TODO (rantuttl): Good place for label conversion functions. See ./pkg/api/v1/conversion.go
+	// Field label conversions for the fields each kind can be selected on. See ./pkg/api/v1/conversion.go
+	if err := scheme.AddFieldLabelConversionFunc(SchemeGroupVersion.String(), "Account",
+		func(label, value string) (string, string, error) {
+			switch label {
+			case "metadata.name",
+				"status.phase":
+				return label, value, nil
+			default:
+				return "", "", fmt.Errorf("field label not supported: %s", label)
+			}
+		},
+	); err != nil {
+		return err
+	}
 	return nil
 }
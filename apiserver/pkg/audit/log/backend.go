@@ -0,0 +1,71 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package log writes audit events as JSON lines, to a file rotated by size or to any writer.
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+)
+
+type backend struct {
+	lock sync.Mutex
+	out  io.Writer
+}
+
+// NewBackend returns an audit backend writing each event to out as a line of JSON.
+func NewBackend(out io.Writer) audit.Backend {
+	return &backend{out: out}
+}
+
+func (b *backend) ProcessEvents(events ...*audit.Event) {
+	for _, ev := range events {
+		b.logEvent(ev)
+	}
+}
+
+func (b *backend) logEvent(ev *audit.Event) {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		glog.Errorf("Unable to encode audit event %s: %v", ev.AuditID, err)
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, err := b.out.Write(append(line, '\n')); err != nil {
+		glog.Errorf("Unable to write audit event %s: %v", ev.AuditID, err)
+	}
+}
+
+func (b *backend) Run(stopCh <-chan struct{}) error {
+	return nil
+}
+
+func (b *backend) Shutdown() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	// Only close the files we rotate, not e.g. standard out.
+	if f, ok := b.out.(*RotatingFile); ok {
+		if err := f.Close(); err != nil {
+			glog.Errorf("Unable to close the audit log: %v", err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp inserted in the name of rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a file that is rotated once it grows over a size. Rotated files are kept
+// next to it, named after it with the time of the rotation, up to a number of files and an
+// age.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens, or creates, the file at path. It is rotated once it grows over
+// maxSizeMB megabytes, keeping at most maxBackups rotated files no older than maxAgeDays days.
+// Zero means no limit.
+func NewRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write writes p to the file, rotating it first if p would grow it over its maximum size.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return 0, fmt.Errorf("%s is closed", f.path)
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if err := os.Rename(f.path, f.backupName(time.Now())); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.removeOldBackups()
+	return nil
+}
+
+// backupName returns the name of the file rotated at t, e.g. audit-2017-06-01T10-00-00.000.log
+// for audit.log.
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), t.Format(backupTimeFormat), ext)
+}
+
+// removeOldBackups removes the rotated files over maxBackups or older than maxAge. Errors are
+// ignored, the files are retried at the next rotation.
+func (f *RotatingFile) removeOldBackups() {
+	if f.maxBackups == 0 && f.maxAge == 0 {
+		return
+	}
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := ioutil.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(filepath.Dir(f.path), name), t})
+	}
+	sort.Sort(newestFirst(backups))
+
+	for i, b := range backups {
+		if (f.maxBackups > 0 && i >= f.maxBackups) || (f.maxAge > 0 && time.Since(b.time) > f.maxAge) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// backup is a rotated file and the time of its rotation.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// newestFirst orders backups by rotation time, newest first.
+type newestFirst []backup
+
+func (b newestFirst) Len() int           { return len(b) }
+func (b newestFirst) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b newestFirst) Less(i, j int) bool { return b[i].time.After(b[j].time) }
@@ -0,0 +1,66 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	f, err := NewRotatingFile(path, 1, 2, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	// Rotate every two lines.
+	f.maxSize = 22
+	line := []byte("0123456789\n")
+
+	for i := 0; i < 8; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Tell the rotated files apart.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != strings.Repeat(string(line), 2) {
+		t.Errorf("expected the current file to hold the last two lines, got %q", data)
+	}
+	backups, err := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("expected 2 rotated files to be kept, got %v", backups)
+	}
+}
@@ -0,0 +1,181 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package policy evaluates the audit policy against requests.
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+)
+
+// Checker tells how a request is audited.
+type Checker interface {
+	// LevelAndStages returns the level the request is audited at, and the stages that are not
+	// recorded.
+	LevelAndStages(attribs authorizer.Attributes) (audit.Level, []audit.Stage)
+}
+
+// LoadPolicyFromFile reads and validates the audit policy in file, which may be either YAML
+// or JSON.
+func LoadPolicyFromFile(file string) (*audit.Policy, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy := &audit.Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("unable to parse audit policy %q: %v", file, err)
+	}
+	if err := validate(policy); err != nil {
+		return nil, fmt.Errorf("invalid audit policy %q: %v", file, err)
+	}
+	return policy, nil
+}
+
+func validate(policy *audit.Policy) error {
+	if err := validateStages(policy.OmitStages); err != nil {
+		return err
+	}
+	for i, rule := range policy.Rules {
+		if !rule.Level.Valid() {
+			return fmt.Errorf("rules[%d]: unknown level %q", i, rule.Level)
+		}
+		if len(rule.NonResourceURLs) > 0 && (len(rule.Resources) > 0 || len(rule.Namespaces) > 0) {
+			return fmt.Errorf("rules[%d]: nonResourceURLs cannot be combined with resources or namespaces", i)
+		}
+		if err := validateStages(rule.OmitStages); err != nil {
+			return fmt.Errorf("rules[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func validateStages(stages []audit.Stage) error {
+	for _, stage := range stages {
+		switch stage {
+		case audit.StageRequestReceived, audit.StageResponseStarted, audit.StageResponseComplete, audit.StagePanic:
+		default:
+			return fmt.Errorf("unknown stage %q", stage)
+		}
+	}
+	return nil
+}
+
+// NewChecker returns a Checker evaluating policy.
+func NewChecker(policy *audit.Policy) Checker {
+	return &policyChecker{*policy}
+}
+
+type policyChecker struct {
+	audit.Policy
+}
+
+func (p *policyChecker) LevelAndStages(attribs authorizer.Attributes) (audit.Level, []audit.Stage) {
+	for _, rule := range p.Rules {
+		if ruleMatches(&rule, attribs) {
+			return rule.Level, append(append([]audit.Stage{}, p.OmitStages...), rule.OmitStages...)
+		}
+	}
+	return audit.LevelNone, nil
+}
+
+func ruleMatches(r *audit.PolicyRule, attribs authorizer.Attributes) bool {
+	if len(r.Users) > 0 || len(r.UserGroups) > 0 {
+		u := attribs.GetUser()
+		if u == nil || !(hasString(r.Users, u.GetName()) || hasAnyString(r.UserGroups, u.GetGroups())) {
+			return false
+		}
+	}
+	if len(r.Verbs) > 0 && !hasString(r.Verbs, attribs.GetVerb()) {
+		return false
+	}
+
+	if attribs.IsResourceRequest() {
+		if len(r.NonResourceURLs) > 0 {
+			return false
+		}
+		if len(r.Namespaces) > 0 && !hasString(r.Namespaces, attribs.GetNamespace()) {
+			return false
+		}
+		return len(r.Resources) == 0 || resourceMatches(r.Resources, attribs)
+	}
+
+	if len(r.Resources) > 0 || len(r.Namespaces) > 0 {
+		return false
+	}
+	if len(r.NonResourceURLs) == 0 {
+		return true
+	}
+	path := attribs.GetPath()
+	for _, url := range r.NonResourceURLs {
+		if url == "*" || url == path || (strings.HasSuffix(url, "*") && strings.HasPrefix(path, strings.TrimSuffix(url, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceMatches(resources []audit.GroupResources, attribs authorizer.Attributes) bool {
+	resource := attribs.GetResource()
+	combined := resource
+	subresource := attribs.GetSubresource()
+	if len(subresource) > 0 {
+		combined = resource + "/" + subresource
+	}
+	name := attribs.GetName()
+
+	for _, gr := range resources {
+		if gr.Group != attribs.GetAPIGroup() {
+			continue
+		}
+		if len(gr.ResourceNames) > 0 && !hasString(gr.ResourceNames, name) {
+			continue
+		}
+		if len(gr.Resources) == 0 {
+			return true
+		}
+		for _, r := range gr.Resources {
+			if r == combined || (len(subresource) > 0 && r == "*/"+subresource) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasString(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyString(slice []string, values []string) bool {
+	for _, v := range values {
+		if hasString(slice, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+)
+
+const testPolicy = `
+omitStages: ["RequestReceived"]
+rules:
+- level: None
+  users: ["system:healthcheck"]
+- level: RequestResponse
+  verbs: ["create", "delete"]
+  resources:
+  - group: "core"
+    resources: ["accounts"]
+- level: Request
+  resources:
+  - group: "core"
+    resources: ["*/status"]
+- level: None
+  nonResourceURLs: ["/healthz*", "/version"]
+- level: Metadata
+  userGroups: ["system:masters"]
+  omitStages: ["ResponseStarted"]
+`
+
+func writePolicy(t *testing.T, policy string) (string, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "policy.yaml")
+	if err := ioutil.WriteFile(file, []byte(policy), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestLevelAndStages(t *testing.T) {
+	file, cleanup := writePolicy(t, testPolicy)
+	defer cleanup()
+	p, err := LoadPolicyFromFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checker := NewChecker(p)
+
+	alice := &user.DefaultInfo{Name: "alice"}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+	resource := func(u user.Info, verb, resource, subresource string) authorizer.Attributes {
+		return authorizer.AttributesRecord{User: u, Verb: verb, APIGroup: "core", APIVersion: "v1", Resource: resource, Subresource: subresource, ResourceRequest: true}
+	}
+	nonResource := func(u user.Info, path string) authorizer.Attributes {
+		return authorizer.AttributesRecord{User: u, Verb: "get", Path: path}
+	}
+
+	testCases := []struct {
+		name    string
+		attribs authorizer.Attributes
+		level   audit.Level
+		omit    []audit.Stage
+	}{
+		{"account create", resource(alice, "create", "accounts", ""), audit.LevelRequestResponse, []audit.Stage{audit.StageRequestReceived}},
+		{"account delete", resource(alice, "delete", "accounts", ""), audit.LevelRequestResponse, []audit.Stage{audit.StageRequestReceived}},
+		{"account list", resource(alice, "list", "accounts", ""), audit.LevelNone, nil},
+		{"account status", resource(alice, "update", "accounts", "status"), audit.LevelRequest, []audit.Stage{audit.StageRequestReceived}},
+		{"excluded user", resource(&user.DefaultInfo{Name: "system:healthcheck"}, "create", "accounts", ""), audit.LevelNone, nil},
+		{"healthz", nonResource(admin, "/healthz/ping"), audit.LevelNone, nil},
+		{"admin list", resource(admin, "list", "accounts", ""), audit.LevelMetadata, []audit.Stage{audit.StageRequestReceived, audit.StageResponseStarted}},
+		{"admin non-resource", nonResource(admin, "/apis"), audit.LevelMetadata, []audit.Stage{audit.StageRequestReceived, audit.StageResponseStarted}},
+	}
+	for _, tc := range testCases {
+		level, omit := checker.LevelAndStages(tc.attribs)
+		if level != tc.level {
+			t.Errorf("%s: expected level %q, got %q", tc.name, tc.level, level)
+		}
+		if level != audit.LevelNone && !reflect.DeepEqual(omit, tc.omit) {
+			t.Errorf("%s: expected omitted stages %v, got %v", tc.name, tc.omit, omit)
+		}
+	}
+}
+
+func TestLoadPolicyFromFileErrors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown level": "rules:\n- level: Everything\n",
+		"unknown stage": "omitStages: [\"Done\"]\nrules:\n- level: Metadata\n",
+		"mixed urls":    "rules:\n- level: Metadata\n  nonResourceURLs: [\"/version\"]\n  namespaces: [\"\"]\n",
+		"not yaml":      "rules: [",
+	}
+	for name, policy := range testCases {
+		file, cleanup := writePolicy(t, policy)
+		if _, err := LoadPolicyFromFile(file); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		cleanup()
+	}
+}
@@ -0,0 +1,137 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	utilnet "github.com/rantuttl/cloudops/apimachinery/pkg/util/net"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/uuid"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+)
+
+// NewEventFromRequest returns the event of req, at the RequestReceived stage.
+func NewEventFromRequest(req *http.Request, level Level, attribs authorizer.Attributes) *Event {
+	now := time.Now()
+	ev := &Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: APIVersion,
+		},
+		Level:                    level,
+		AuditID:                  uuid.NewUUID(),
+		Stage:                    StageRequestReceived,
+		RequestURI:               req.URL.RequestURI(),
+		Verb:                     attribs.GetVerb(),
+		UserAgent:                req.UserAgent(),
+		RequestReceivedTimestamp: now,
+		StageTimestamp:           now,
+	}
+
+	for _, ip := range utilnet.SourceIPs(req) {
+		ev.SourceIPs = append(ev.SourceIPs, ip.String())
+	}
+
+	if u := attribs.GetUser(); u != nil {
+		ev.User = UserInfo{
+			Username: u.GetName(),
+			UID:      u.GetUID(),
+			Groups:   u.GetGroups(),
+			Extra:    u.GetExtra(),
+		}
+	}
+
+	if attribs.IsResourceRequest() {
+		ev.ObjectRef = &ObjectReference{
+			Resource:    attribs.GetResource(),
+			Namespace:   attribs.GetNamespace(),
+			Name:        attribs.GetName(),
+			APIGroup:    attribs.GetAPIGroup(),
+			APIVersion:  attribs.GetAPIVersion(),
+			Subresource: attribs.GetSubresource(),
+		}
+	}
+	return ev
+}
+
+// SetStage moves ev to stage, at the current time.
+func SetStage(ev *Event, stage Stage) {
+	ev.Stage = stage
+	ev.StageTimestamp = time.Now()
+	if stage != StageRequestReceived {
+		ev.Latency = ev.StageTimestamp.Sub(ev.RequestReceivedTimestamp).String()
+	}
+}
+
+// SetResponseCode records the response code of the request.
+func SetResponseCode(ev *Event, code int) {
+	status := metav1.StatusSuccess
+	if code >= http.StatusBadRequest {
+		status = metav1.StatusFailure
+	}
+	ev.ResponseStatus = &metav1.Status{Status: status, Code: int32(code)}
+}
+
+// SetPanic records the request handling panicked with r.
+func SetPanic(ev *Event, r interface{}) {
+	ev.ResponseStatus = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusInternalServerError,
+		Reason:  metav1.StatusReasonInternalError,
+		Message: fmt.Sprintf("APIServer panic'd: %v", r),
+	}
+}
+
+// LogObjectRef completes the object reference of ev from obj, e.g. with the name of an object
+// created by a request whose URL does not name it. ev may be nil when the request is not
+// audited.
+func LogObjectRef(ev *Event, obj runtime.Object) {
+	if ev == nil || ev.ObjectRef == nil || obj == nil {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if len(ev.ObjectRef.Name) == 0 {
+		ev.ObjectRef.Name = accessor.GetName()
+	}
+	if len(ev.ObjectRef.Namespace) == 0 {
+		ev.ObjectRef.Namespace = accessor.GetNamespace()
+	}
+	if len(ev.ObjectRef.UID) == 0 {
+		ev.ObjectRef.UID = accessor.GetUID()
+	}
+}
+
+// Copy returns a copy of ev that later changes to ev do not affect.
+func Copy(ev *Event) *Event {
+	c := *ev
+	if ev.ObjectRef != nil {
+		ref := *ev.ObjectRef
+		c.ObjectRef = &ref
+	}
+	if ev.ResponseStatus != nil {
+		status := *ev.ResponseStatus
+		c.ResponseStatus = &status
+	}
+	return &c
+}
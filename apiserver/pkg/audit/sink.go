@@ -0,0 +1,71 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package audit
+
+import (
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/errors"
+)
+
+// Sink receives audit events.
+type Sink interface {
+	// ProcessEvents handles events. It must not block the request, nor keep the events
+	// passed, which the caller may reuse.
+	ProcessEvents(events ...*Event)
+}
+
+// Backend is a Sink that runs alongside the server.
+type Backend interface {
+	Sink
+
+	// Run starts the backend. It returns right away; the backend runs until stopCh is closed.
+	Run(stopCh <-chan struct{}) error
+
+	// Shutdown waits for the events already processed to be written out. It is called
+	// after stopCh passed to Run is closed.
+	Shutdown()
+}
+
+// Union returns a Backend sending events to every one of backends.
+func Union(backends ...Backend) Backend {
+	if len(backends) == 1 {
+		return backends[0]
+	}
+	return union(backends)
+}
+
+type union []Backend
+
+func (u union) ProcessEvents(events ...*Event) {
+	for _, backend := range u {
+		backend.ProcessEvents(events...)
+	}
+}
+
+func (u union) Run(stopCh <-chan struct{}) error {
+	var errs []error
+	for _, backend := range u {
+		if err := backend.Run(stopCh); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (u union) Shutdown() {
+	for _, backend := range u {
+		backend.Shutdown()
+	}
+}
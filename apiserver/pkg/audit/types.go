@@ -0,0 +1,178 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package audit holds the audit events the server records for every request, the policy
+// selecting how much of a request is recorded, and the sinks events are sent to.
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+)
+
+// APIVersion is the version of the audit events and policy.
+const APIVersion = "audit.cloudops.io/v1alpha1"
+
+// Level defines the amount of information recorded for a request.
+type Level string
+
+const (
+	// LevelNone disables auditing.
+	LevelNone Level = "None"
+	// LevelMetadata records the request's metadata: user, verb, resource, response code and
+	// timestamps. Neither the request nor the response body is recorded.
+	LevelMetadata Level = "Metadata"
+	// LevelRequest records the metadata and the request body.
+	LevelRequest Level = "Request"
+	// LevelRequestResponse records the metadata, the request body and the response body.
+	LevelRequestResponse Level = "RequestResponse"
+)
+
+var levelOrder = map[Level]int{
+	LevelNone:            0,
+	LevelMetadata:        1,
+	LevelRequest:         2,
+	LevelRequestResponse: 3,
+}
+
+// Less returns true if l records less than o.
+func (l Level) Less(o Level) bool {
+	return levelOrder[l] < levelOrder[o]
+}
+
+// Valid returns true if l is a known level.
+func (l Level) Valid() bool {
+	_, ok := levelOrder[l]
+	return ok
+}
+
+// Stage is a stage of the request handling an event is recorded at.
+type Stage string
+
+const (
+	// StageRequestReceived is recorded as soon as the request is received, before it is
+	// handled.
+	StageRequestReceived Stage = "RequestReceived"
+	// StageResponseStarted is recorded once the response headers are sent, for long-running
+	// requests (e.g. watch) only.
+	StageResponseStarted Stage = "ResponseStarted"
+	// StageResponseComplete is recorded once the response is complete.
+	StageResponseComplete Stage = "ResponseComplete"
+	// StagePanic is recorded when the request handling panicked.
+	StagePanic Stage = "Panic"
+)
+
+// Event captures all the information that can be included in an API audit log.
+type Event struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Level the event was recorded at.
+	Level Level `json:"level"`
+	// AuditID is unique per request, the events of all stages of a request share it.
+	AuditID types.UID `json:"auditID"`
+	// RequestID is the X-Request-Id of the request, shared with the server's logs and the
+	// backend calls made for the request.
+	RequestID string `json:"requestID,omitempty"`
+	Stage     Stage  `json:"stage"`
+
+	RequestURI string `json:"requestURI"`
+	// Verb is the API verb of the request, or the lowercased HTTP method of a non-resource
+	// request.
+	Verb      string   `json:"verb"`
+	User      UserInfo `json:"user"`
+	SourceIPs []string `json:"sourceIPs,omitempty"`
+	UserAgent string   `json:"userAgent,omitempty"`
+	// ObjectRef is the object the request is for, nil for non-resource requests.
+	ObjectRef *ObjectReference `json:"objectRef,omitempty"`
+	// ResponseStatus holds the response code, set from the ResponseStarted stage on.
+	ResponseStatus *metav1.Status `json:"responseStatus,omitempty"`
+
+	// RequestObject is the JSON request body, recorded at the Request level and above.
+	RequestObject json.RawMessage `json:"requestObject,omitempty"`
+	// ResponseObject is the JSON response body, recorded at the RequestResponse level.
+	ResponseObject json.RawMessage `json:"responseObject,omitempty"`
+
+	RequestReceivedTimestamp time.Time `json:"requestReceivedTimestamp"`
+	StageTimestamp           time.Time `json:"stageTimestamp"`
+	// Latency is the time from receiving the request to this stage.
+	Latency string `json:"latency,omitempty"`
+}
+
+// EventList is a list of audit events, as sent to the webhook.
+type EventList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Items []Event `json:"items"`
+}
+
+// UserInfo is the user a request was made by.
+type UserInfo struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid,omitempty"`
+	Groups   []string            `json:"groups,omitempty"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+// ObjectReference identifies the object a request is for.
+type ObjectReference struct {
+	Resource    string    `json:"resource,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	UID         types.UID `json:"uid,omitempty"`
+	APIGroup    string    `json:"apiGroup,omitempty"`
+	APIVersion  string    `json:"apiVersion,omitempty"`
+	Subresource string    `json:"subresource,omitempty"`
+}
+
+// Policy selects the level requests are audited at. The first rule matching a request wins;
+// requests no rule matches are not audited.
+type Policy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Rules []PolicyRule `json:"rules"`
+	// OmitStages are never recorded, whatever the rule matching the request.
+	OmitStages []Stage `json:"omitStages,omitempty"`
+}
+
+// PolicyRule maps requests to a level. An empty list matches anything; a rule naming users or
+// groups matches requests by any of them.
+type PolicyRule struct {
+	Level Level `json:"level"`
+
+	Users      []string `json:"users,omitempty"`
+	UserGroups []string `json:"userGroups,omitempty"`
+	Verbs      []string `json:"verbs,omitempty"`
+
+	// Resources and Namespaces only match resource requests. The empty namespace matches
+	// cluster-scoped resources.
+	Resources  []GroupResources `json:"resources,omitempty"`
+	Namespaces []string         `json:"namespaces,omitempty"`
+	// NonResourceURLs only match non-resource requests. A trailing "*" matches any suffix.
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+
+	// OmitStages are not recorded for the requests matching the rule.
+	OmitStages []Stage `json:"omitStages,omitempty"`
+}
+
+// GroupResources names resources of an API group. An empty Resources list matches every
+// resource of the group; "resource/subresource" and "*/subresource" match subresources.
+type GroupResources struct {
+	Group         string   `json:"group,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+}
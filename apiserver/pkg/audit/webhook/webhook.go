@@ -0,0 +1,187 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package webhook sends audit events in batches to a remote HTTP endpoint.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/wait"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+)
+
+const (
+	// bufferSize is the number of events held while waiting to be sent. Events processed while
+	// the buffer is full are dropped, rather than blocking requests.
+	bufferSize = 10000
+
+	// DefaultBatchMaxSize is the default number of events sent in one request.
+	DefaultBatchMaxSize = 400
+	// DefaultBatchMaxWait is the default time an event waits for its batch to fill up.
+	DefaultBatchMaxWait = 30 * time.Second
+
+	requestTimeout = 10 * time.Second
+)
+
+// retryBackoff is how sending a batch is retried when the webhook fails.
+var retryBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Steps:    4,
+}
+
+type backend struct {
+	url          string
+	client       *http.Client
+	batchMaxSize int
+	batchMaxWait time.Duration
+
+	buffer chan *audit.Event
+	// done is closed once the last batch is sent after stopping.
+	done chan struct{}
+	// shutdown guards buffer against events processed after it is closed.
+	shutdown sync.RWMutex
+	stopped  bool
+}
+
+// NewBackend returns an audit backend POSTing batches of up to batchMaxSize events, as an
+// EventList, to url. A batch is sent once full, or batchMaxWait after its first event.
+func NewBackend(url string, batchMaxSize int, batchMaxWait time.Duration) (audit.Backend, error) {
+	if len(url) == 0 {
+		return nil, fmt.Errorf("an audit webhook url is required")
+	}
+	if batchMaxSize <= 0 || batchMaxWait <= 0 {
+		return nil, fmt.Errorf("audit webhook batch size and wait must be positive")
+	}
+	return &backend{
+		url:          url,
+		client:       &http.Client{Timeout: requestTimeout},
+		batchMaxSize: batchMaxSize,
+		batchMaxWait: batchMaxWait,
+		buffer:       make(chan *audit.Event, bufferSize),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+func (b *backend) ProcessEvents(events ...*audit.Event) {
+	b.shutdown.RLock()
+	defer b.shutdown.RUnlock()
+	if b.stopped {
+		return
+	}
+	for _, ev := range events {
+		select {
+		case b.buffer <- audit.Copy(ev):
+		default:
+			glog.Errorf("Audit webhook buffer is full, dropping audit event %s", ev.AuditID)
+		}
+	}
+}
+
+func (b *backend) Run(stopCh <-chan struct{}) error {
+	go func() {
+		<-stopCh
+		b.shutdown.Lock()
+		b.stopped = true
+		close(b.buffer)
+		b.shutdown.Unlock()
+	}()
+	go func() {
+		defer close(b.done)
+		for {
+			batch, more := b.collectBatch()
+			if len(batch) > 0 {
+				b.sendBatch(batch)
+			}
+			if !more {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// collectBatch waits for the next batch of events. It returns false once the buffer is closed
+// and drained.
+func (b *backend) collectBatch() ([]audit.Event, bool) {
+	ev, ok := <-b.buffer
+	if !ok {
+		return nil, false
+	}
+	batch := []audit.Event{*ev}
+	timer := time.NewTimer(b.batchMaxWait)
+	defer timer.Stop()
+	for len(batch) < b.batchMaxSize {
+		select {
+		case ev, ok := <-b.buffer:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, *ev)
+		case <-timer.C:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+func (b *backend) sendBatch(batch []audit.Event) {
+	list := audit.EventList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "EventList",
+			APIVersion: audit.APIVersion,
+		},
+		Items: batch,
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		glog.Errorf("Unable to encode %d audit events: %v", len(batch), err)
+		return
+	}
+
+	var lastErr error
+	err = wait.ExponentialBackoff(retryBackoff, func() (bool, error) {
+		lastErr = b.post(body)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		glog.Errorf("Unable to send %d audit events to %s, dropping them: %v", len(batch), b.url, lastErr)
+	}
+}
+
+func (b *backend) post(body []byte) error {
+	resp, err := b.client.Post(b.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *backend) Shutdown() {
+	<-b.done
+}
@@ -0,0 +1,81 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+)
+
+func TestBatching(t *testing.T) {
+	var lock sync.Mutex
+	var batches [][]audit.Event
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		// The first batch is retried.
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		list := audit.EventList{}
+		if err := json.NewDecoder(req.Body).Decode(&list); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		batches = append(batches, list.Items)
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(server.URL, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stopCh := make(chan struct{})
+	if err := backend.Run(stopCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range []types.UID{"1", "2", "3", "4", "5"} {
+		backend.ProcessEvents(&audit.Event{AuditID: id, Stage: audit.StageResponseComplete})
+	}
+	// Stopping sends the last, partial, batch.
+	close(stopCh)
+	backend.Shutdown()
+
+	lock.Lock()
+	defer lock.Unlock()
+	var ids []types.UID
+	for _, batch := range batches {
+		if len(batch) > 2 {
+			t.Errorf("expected batches of at most 2 events, got %d", len(batch))
+		}
+		for _, ev := range batch {
+			ids = append(ids, ev.AuditID)
+		}
+	}
+	if len(batches) != 3 || len(ids) != 5 {
+		t.Errorf("expected 5 events in 3 batches, got %v in %d batches", ids, len(batches))
+	}
+}
@@ -16,11 +16,22 @@
 package cal
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"golang.org/x/net/context"
 	"github.com/golang/glog"
 
 	"github.com/rantuttl/cloudops/apiserver/pkg/backend"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
+	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/conversion"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 )
 
@@ -58,12 +69,19 @@ type calHelper struct {
 	// group that can be used for CAL communications. Codec libraries for encoding / decoding
 	// requests / responses to CAL; things for managing cache (if used)
 	//client whatevertype
+	//
+	// TODO (rantuttl): There is no CAL HTTP client yet, so enforcing the request's deadline on
+	// CAL calls is deferred. The client must send each call with the ctx of the request, e.g.
+	// with http.NewRequest(...).WithContext(ctx), so that the call is abandoned once the
+	// request's deadline passes or its client goes away. Until then, the deadline only stops
+	// calls whose ctx is already done, see contextError. The client must also set
+	// requestHeaders(ctx) on each call.
 	codec		runtime.Codec
 	copier		runtime.ObjectCopier
 	transformer	backend.BackendTransformer
 }
 
-func (h *calHelper) Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64) error {
+func (h *calHelper) Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error {
 	if ctx == nil {
 		glog.Errorf("Context is nil")
 	}
@@ -75,9 +93,18 @@ func (h *calHelper) Create(ctx context.Context, key string, obj, out runtime.Obj
 	// 2. Transform object (if needed)
 	newBody, err := h.transformer.TransformToBackend(ctx, string(data))
 	glog.Infof("Transformed & string-a-fied obj:\n%s", newBody)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return h.dryRunResult(obj, out, newBody)
+	}
 	// 3. Set any TTL options for CAL request
 	// 4. TODO metrics for latency
-	// 5. Send request to client
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	// 5. Send request to client with ctx, see calHelper, and with requestHeaders(ctx)
 	// 6. If out != nil, copy CAL response body back to out
 	//	6a. Transform object (if needed)
 	//	6b. Decode object with calHelper known codecs
@@ -85,21 +112,276 @@ func (h *calHelper) Create(ctx context.Context, key string, obj, out runtime.Obj
 	return err
 }
 
+func (h *calHelper) Update(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error {
+	if ctx == nil {
+		glog.Errorf("Context is nil")
+	}
+	glog.Infof("Update key: %s", key)
+	// 1. Convert and Encode object with calHelper known codecs
+	data, err := runtime.Encode(h.codec, obj)
+	if err != nil {
+		return err
+	}
+	// 2. Transform object (if needed). The object's resourceVersion travels in the metadata
+	// variable, and CAL is expected to reject the mutation when it is stale.
+	newBody, err := h.transformer.TransformToBackend(ctx, string(data))
+	glog.Infof("Transformed & string-a-fied obj:\n%s", newBody)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return h.dryRunResult(obj, out, newBody)
+	}
+	// 3. Set any TTL options for CAL request
+	// 4. TODO metrics for latency
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	// 5. Send request to client with ctx, see calHelper, and with requestHeaders(ctx)
+	// 6. If out != nil, copy CAL response body back to out
+	//	6a. Transform object (if needed)
+	//	6b. Decode object with calHelper known codecs
+	//	6c. Map a CAL version mismatch to storage.NewResourceVersionConflictsError
+
+	return err
+}
+
+// dryRunResult fills out with obj as it would have been sent to CAL, and records the rendered
+// GraphQL document on it under the DryRunGraphQLAnnotation annotation.
+func (h *calHelper) dryRunResult(obj, out runtime.Object, rendered string) error {
+	if out == nil {
+		return nil
+	}
+	copied, err := h.copier.Copy(obj)
+	if err != nil {
+		return err
+	}
+	outVal, err := conversion.EnforcePtr(out)
+	if err != nil {
+		return err
+	}
+	copiedVal, err := conversion.EnforcePtr(copied)
+	if err != nil {
+		return err
+	}
+	if outVal.Type() != copiedVal.Type() {
+		return fmt.Errorf("unable to return dry run result: expected %v, got %v", outVal.Type(), copiedVal.Type())
+	}
+	outVal.Set(copiedVal)
+
+	accessor, err := meta.Accessor(out)
+	if err != nil {
+		return err
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[DryRunGraphQLAnnotation] = rendered
+	accessor.SetAnnotations(annotations)
+	return nil
+}
+
 func (h *calHelper) Get(ctx context.Context, key string, resourceVersion string, objPtr runtime.Object, ignoreNotFound bool) error {
 	if ctx == nil {
 		glog.Errorf("Context is nil")
 	}
 	glog.Infof("Get key: %s", key)
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	// TODO Send request to client with ctx, see calHelper, and with requestHeaders(ctx)
 
 	return nil
 }
 
-func (h *calHelper) Delete(ctx context.Context, key string, out runtime.Object, preconditions *metav1.Preconditions) error {
+func (h *calHelper) Delete(ctx context.Context, key string, out runtime.Object, preconditions *metav1.Preconditions, dryRun bool) error {
 	if ctx == nil {
 		glog.Errorf("Context is nil")
 	}
 	glog.Infof("Delete key: %s", key)
+	if dryRun {
+		return nil
+	}
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	// TODO Send request to client with ctx, see calHelper, and with requestHeaders(ctx)
 	// NOTE: preconditions.UID is the UID of the object
 
 	return nil
 }
+
+func (h *calHelper) List(ctx context.Context, key string, resourceVersion string, pred storage.SelectionPredicate, listObj runtime.Object) error {
+	if ctx == nil {
+		glog.Errorf("Context is nil")
+	}
+	glog.Infof("List key: %s", key)
+	keyPrefix := key
+	if !strings.HasSuffix(keyPrefix, "/") {
+		keyPrefix += "/"
+	}
+	// 1. Resume after the last key of the previous page, if continuing
+	vars := selectorVariables(pred)
+	if len(pred.Continue) > 0 {
+		if len(resourceVersion) > 0 && resourceVersion != "0" {
+			return apierrors.NewBadRequest("specifying resource version is not allowed when using continue")
+		}
+		fromKey, continueRV, err := storage.DecodeContinue(pred.Continue, keyPrefix)
+		if err != nil {
+			return err
+		}
+		// The GraphQL cursor is the key relative to the list prefix
+		vars[string(ARGAFTER)] = strings.TrimPrefix(fromKey, keyPrefix)
+		resourceVersion = continueRV
+	}
+	if pred.Limit > 0 {
+		// One more item than the limit tells whether there is a next page.
+		vars[string(ARGFIRST)] = pred.Limit + 1
+	}
+	// 2. Push the selectors and page bounds down to CAL as GraphQL arguments
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	// 3. Transform request (if needed)
+	newBody, err := h.transformer.TransformToBackend(ctx, string(data))
+	if err != nil {
+		return err
+	}
+	glog.V(5).Infof("Transformed list request:\n%s", newBody)
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	// 4. TODO Send request to client with ctx, see calHelper, and with requestHeaders(ctx)
+	// 5. Copy CAL response items back to listObj
+	//	5a. Transform object (if needed)
+	//	5b. Decode object with calHelper known codecs
+
+	return finishList(listObj, keyPrefix, resourceVersion, pred)
+}
+
+func (h *calHelper) Watch(ctx context.Context, key string, resourceVersion string, pred storage.SelectionPredicate) (watch.Interface, error) {
+	if ctx == nil {
+		glog.Errorf("Context is nil")
+	}
+	glog.Infof("Watch key: %s", key)
+	vars, err := json.Marshal(selectorVariables(pred))
+	if err != nil {
+		return nil, err
+	}
+	newBody, err := h.transformer.TransformToBackend(ctx, string(vars))
+	if err != nil {
+		return nil, err
+	}
+	glog.V(5).Infof("Transformed watch request:\n%s", newBody)
+
+	return newCalWatcher(ctx, pred), nil
+}
+
+// contextError returns the error of a request whose context is done, because its deadline has
+// passed or its client has gone away, so that it is not sent to CAL.
+func contextError(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return apierrors.NewTimeoutError("request did not complete within the allowed duration", 0)
+	default:
+		return apierrors.NewTimeoutError(fmt.Sprintf("request was abandoned: %v", ctx.Err()), 0)
+	}
+}
+
+// requestHeaders returns the headers of the CAL requests made on behalf of the request of ctx.
+// They forward its request ID, so that CAL's logs can be correlated with the API server's.
+// TODO (rantuttl): Forwarding the request ID to CAL is deferred until the CAL HTTP client
+// exists; nothing calls requestHeaders yet, the client must set them on each call.
+func requestHeaders(ctx context.Context) http.Header {
+	header := http.Header{}
+	if ctx == nil {
+		return header
+	}
+	if requestID, ok := request.RequestIDFrom(ctx); ok {
+		header.Set(request.RequestIDHeader, requestID)
+	}
+	return header
+}
+
+// selectorVariables returns the GraphQL variables carrying the predicate's selectors. Empty
+// selectors are left out so that CAL applies no filtering for them.
+func selectorVariables(pred storage.SelectionPredicate) map[string]interface{} {
+	vars := map[string]interface{}{}
+	if pred.Label != nil && !pred.Label.Empty() {
+		vars[string(ARGLABELSELECTOR)] = pred.Label.String()
+	}
+	if pred.Field != nil && !pred.Field.Empty() {
+		vars[string(ARGFIELDSELECTOR)] = pred.Field.String()
+	}
+	return vars
+}
+
+// finishList caps the decoded page at the predicate's limit and, when CAL returned more items
+// than that, issues the continue token for the next page before filtering out the items that
+// do not match the predicate.
+func finishList(listObj runtime.Object, keyPrefix, resourceVersion string, pred storage.SelectionPredicate) error {
+	listAccessor, err := meta.ListInterfaceAccessor(listObj)
+	if err != nil {
+		return err
+	}
+	if len(resourceVersion) == 0 {
+		resourceVersion = listAccessor.GetResourceVersion()
+	}
+	if pred.Limit > 0 {
+		items, err := meta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		// CAL is asked for one item over the limit, which is only there when a next page is;
+		// resume after the last item of this page.
+		if int64(len(items)) > pred.Limit {
+			items = items[:pred.Limit]
+			last, err := meta.Accessor(items[len(items)-1])
+			if err != nil {
+				return err
+			}
+			next, err := storage.EncodeContinue(keyPrefix+last.GetName(), keyPrefix, resourceVersion)
+			if err != nil {
+				return err
+			}
+			if err := meta.SetList(listObj, items); err != nil {
+				return err
+			}
+			listAccessor.SetContinue(next)
+		}
+	}
+	listAccessor.SetResourceVersion(resourceVersion)
+
+	// CAL may only honor part of the selectors (e.g. unsupported field selectors), so the
+	// predicate is always re-applied to whatever comes back.
+	return filterList(listObj, pred)
+}
+
+// filterList removes the items of listObj that do not match the predicate.
+func filterList(listObj runtime.Object, pred storage.SelectionPredicate) error {
+	if pred.Empty() {
+		return nil
+	}
+	items, err := meta.ExtractList(listObj)
+	if err != nil {
+		return err
+	}
+	filtered := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		matches, err := pred.Matches(item)
+		if err != nil {
+			return err
+		}
+		if matches {
+			filtered = append(filtered, item)
+		}
+	}
+	return meta.SetList(listObj, filtered)
+}
@@ -0,0 +1,100 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package cal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api/core/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	ctx := request.WithRequestID(request.NewContext(), "req-1")
+	if id := requestHeaders(ctx).Get(request.RequestIDHeader); id != "req-1" {
+		t.Errorf("expected the request ID to be forwarded, got %q", id)
+	}
+	if header := requestHeaders(request.NewContext()); len(header) != 0 {
+		t.Errorf("expected no headers without a request ID, got %v", header)
+	}
+}
+
+func TestContextError(t *testing.T) {
+	if err := contextError(request.NewContext()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expired, cancel := context.WithTimeout(request.NewContext(), -time.Second)
+	defer cancel()
+	canceled, cancel := context.WithCancel(request.NewContext())
+	cancel()
+	for _, ctx := range []context.Context{expired, canceled} {
+		if err := contextError(ctx); !errors.IsTimeout(err) {
+			t.Errorf("expected a timeout, got %v", err)
+		}
+		// The call is not made once the request is done.
+		if err := NewCalBackend(nil, nil, nil).Delete(ctx, "/accounts/acme", nil, nil, false); !errors.IsTimeout(err) {
+			t.Errorf("expected the delete to time out, got %v", err)
+		}
+	}
+}
+
+func TestFinishList(t *testing.T) {
+	// CAL is asked for limit+1 items, returned tells how many it had.
+	for _, test := range []struct {
+		limit, returned int
+		expectedItems   int
+		expectContinue  bool
+	}{
+		{limit: 0, returned: 3, expectedItems: 3},
+		{limit: 2, returned: 1, expectedItems: 1},
+		{limit: 2, returned: 2, expectedItems: 2},
+		{limit: 2, returned: 3, expectedItems: 2, expectContinue: true},
+	} {
+		list := &v1.AccountList{ListMeta: metav1.ListMeta{ResourceVersion: "7"}}
+		for i := 0; i < test.returned; i++ {
+			list.Items = append(list.Items, v1.Account{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("account-%d", i)}})
+		}
+		pred := storage.Everything
+		pred.Limit = int64(test.limit)
+		if err := finishList(list, "/accounts/", "", pred); err != nil {
+			t.Fatalf("limit %d, %d returned: unexpected error: %v", test.limit, test.returned, err)
+		}
+		if len(list.Items) != test.expectedItems {
+			t.Errorf("limit %d, %d returned: expected %d items, got %d", test.limit, test.returned, test.expectedItems, len(list.Items))
+		}
+		if !test.expectContinue {
+			if len(list.Continue) != 0 {
+				t.Errorf("limit %d, %d returned: expected no continue token, got %q", test.limit, test.returned, list.Continue)
+			}
+			continue
+		}
+		fromKey, rv, err := storage.DecodeContinue(list.Continue, "/accounts/")
+		if err != nil {
+			t.Fatalf("limit %d, %d returned: unexpected error: %v", test.limit, test.returned, err)
+		}
+		// The next page resumes after the last item of this one.
+		if fromKey != "/accounts/account-1" || rv != "7" {
+			t.Errorf("limit %d, %d returned: expected to resume after /accounts/account-1 at 7, got %s at %s", test.limit, test.returned, fromKey, rv)
+		}
+	}
+}
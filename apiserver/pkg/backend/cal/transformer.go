@@ -46,12 +46,20 @@ func (t *Transformer) NewGraphQLBody(verb Verb) (*GraphQLBody, error) {
 	switch verb {
 	case "create", "update", "delete", "patch":
 		gqlBody.OpKeyword = mutatonKeyword
+	case "watch":
+		gqlBody.OpKeyword = subscriptionKeyword
 	default:
 		gqlBody.OpKeyword = queryKeyword
 	}
-	gqlBody.FuncName = string(verb) + strings.Title(t.SingularResource)
+	// Collection verbs operate on the plural resource
+	objName := t.SingularResource
+	switch verb {
+	case LIST, WATCH:
+		objName = t.Resource
+	}
+	gqlBody.FuncName = string(verb) + strings.Title(objName)
 	gqlBody.Parameters = make(map[Variable]GqlParameter)
-	gqlBody.OpBody.ObjName = t.SingularResource
+	gqlBody.OpBody.ObjName = objName
 	gqlBody.OpBody.Arguments = make(map[Argument]Variable)
 	return gqlBody, nil
 }
@@ -157,7 +165,7 @@ func processObjectFields(fields []*Field, tabstops int) (obj string, errors []er
 			} else if s, ok := varg.(bool); ok {
 				value = strconv.FormatBool(s)
 			} else {
-				errors = append(errors, fmt.Errorf("Unable to convert field value to string. Unhandled type.", reflect.TypeOf(varg)))
+				errors = append(errors, fmt.Errorf("Unable to convert field value to string. Unhandled type: %v", reflect.TypeOf(varg)))
 				continue
 			}
 			args = fmt.Sprint(string(karg) + " : " + value + ", ")
@@ -22,11 +22,16 @@ type qraphqlQuery struct {
 	Vars	string	`json:"variables"`
 }
 
+// DryRunGraphQLAnnotation is set on the object returned by a dry-run request, and holds the
+// GraphQL document that would have been sent to CAL.
+const DryRunGraphQLAnnotation = "cal.cloudops.io/graphql"
+
 type opKeyword string
 
 const (
         queryKeyword    opKeyword = "query"
         mutatonKeyword  opKeyword = "mutation"
+        subscriptionKeyword opKeyword = "subscription"
 )
 
 type graphQLType string
@@ -38,6 +43,8 @@ const (
         GQLSPEC graphQLType = "Spec"
         GQLSTATUS graphQLType = "Status"
         GQLACCOUNT graphQLType = "Account"
+        GQLSTRING graphQLType = "String"
+        GQLINT graphQLType = "Int"
 )
 
 type Variable string
@@ -48,6 +55,10 @@ const (
         METADATA Variable = "$metadata"
         SPEC Variable = "$spec"
         STATUS Variable = "$status"
+        LABELSELECTOR Variable = "$labelSelector"
+        FIELDSELECTOR Variable = "$fieldSelector"
+        FIRST Variable = "$first"
+        AFTER Variable = "$after"
 )
 
 type graphqlEnum int64
@@ -86,6 +97,8 @@ const (
 	GET Verb = "get"
 	DELETE Verb = "delete"
 	UPDATE Verb = "update"
+	LIST Verb = "list"
+	WATCH Verb = "watch"
 )
 
 type Transformer struct {
@@ -121,6 +134,10 @@ const (
         ARGMETADATA Argument = "metadata"
         ARGSPEC Argument = "spec"
         ARGSTATUS Argument = "status"
+        ARGLABELSELECTOR Argument = "labelSelector"
+        ARGFIELDSELECTOR Argument = "fieldSelector"
+        ARGFIRST Argument = "first"
+        ARGAFTER Argument = "after"
 )
 
 type FragName string
@@ -0,0 +1,91 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package cal
+
+import (
+	"golang.org/x/net/context"
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
+)
+
+// calWatcher delivers the events of a CAL subscription, dropping those whose objects
+// do not match the predicate of the watch.
+type calWatcher struct {
+	pred     storage.SelectionPredicate
+	ctx      context.Context
+	cancel   context.CancelFunc
+	incoming chan watch.Event
+	result   chan watch.Event
+}
+
+func newCalWatcher(ctx context.Context, pred storage.SelectionPredicate) *calWatcher {
+	w := &calWatcher{
+		pred:     pred,
+		incoming: make(chan watch.Event),
+		result:   make(chan watch.Event),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	// TODO (rantuttl): Open the GraphQL subscription with the CAL client and feed its
+	// events into w.incoming. Until then the watch stays open and idle.
+	go w.run()
+	return w
+}
+
+// Stop implements watch.Interface
+func (w *calWatcher) Stop() {
+	w.cancel()
+}
+
+// ResultChan implements watch.Interface
+func (w *calWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *calWatcher) run() {
+	defer close(w.result)
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event, ok := <-w.incoming:
+			if !ok {
+				return
+			}
+			if !w.matches(event) {
+				continue
+			}
+			select {
+			case w.result <- event:
+			case <-w.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (w *calWatcher) matches(event watch.Event) bool {
+	if event.Type == watch.Error {
+		return true
+	}
+	matches, err := w.pred.Matches(event.Object)
+	if err != nil {
+		glog.Errorf("Unable to match watch event object: %v", err)
+		return false
+	}
+	return matches
+}
@@ -20,15 +20,35 @@ import (
 
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
 )
 
 type Interface interface {
-	// Create adds a new object at a key unless it already exists.
-	Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64) error
+	// Create adds a new object at a key unless it already exists. When dryRun is set, the
+	// request is prepared for the backend but never sent, and out reflects what would have
+	// been persisted.
+	Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error
 
 	Get(ctx context.Context, key string, resourceVersion string, objPtr runtime.Object, ignoreNotFound bool) error
 
-	Delete(ctx context.Context, key string, out runtime.Object, preconditions *metav1.Preconditions) error
+	// Delete removes the object at key. When dryRun is set, nothing is removed.
+	Delete(ctx context.Context, key string, out runtime.Object, preconditions *metav1.Preconditions, dryRun bool) error
+
+	// Update replaces the object at key with obj. If obj carries a resourceVersion, the backend
+	// must reject the update with a resource version conflict unless it matches the stored one.
+	// If out is not nil, it is filled with the object as persisted, including its new resourceVersion.
+	// When dryRun is set, the request is prepared for the backend but never sent.
+	Update(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error
+
+	// List unmarshals the objects found under the given key root into listObj. Only objects
+	// accepted by the predicate are returned; backends may push the predicate down to the
+	// data source, but must not return objects that do not match it.
+	List(ctx context.Context, key string, resourceVersion string, p storage.SelectionPredicate, listObj runtime.Object) error
+
+	// Watch begins watching the objects under the given key root. Only events for objects
+	// accepted by the predicate are delivered.
+	Watch(ctx context.Context, key string, resourceVersion string, p storage.SelectionPredicate) (watch.Interface, error)
 }
 
 type BackendTransformer interface {
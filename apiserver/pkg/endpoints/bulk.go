@@ -0,0 +1,134 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/emicklei/go-restful"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+)
+
+// BulkRegistry holds the resources installed by each API group version that objects can be
+// applied to, and routes the objects of a bulk apply to them through the RESTMapper of their group.
+type BulkRegistry struct {
+	lock    sync.RWMutex
+	mappers map[string]meta.RESTMapper
+	targets map[schema.GroupVersionResource]*handlers.BulkTarget
+}
+
+// NewBulkRegistry returns an empty BulkRegistry, the installer adds the resources of each API
+// group version to it.
+func NewBulkRegistry() *BulkRegistry {
+	return &BulkRegistry{
+		mappers: map[string]meta.RESTMapper{},
+		targets: map[schema.GroupVersionResource]*handlers.BulkTarget{},
+	}
+}
+
+// add makes the resource of the target a destination of bulk applies.
+func (r *BulkRegistry) add(mapper meta.RESTMapper, target *handlers.BulkTarget) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.mappers[target.Scope.Resource.Group] = mapper
+	r.targets[target.Scope.Resource] = target
+}
+
+// Route satisfies the handlers.BulkRouter interface.
+func (r *BulkRegistry) Route(gvk schema.GroupVersionKind) (*handlers.BulkTarget, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	mapper, ok := r.mappers[gvk.Group]
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("no resource is served for %s", gvk))
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	target, ok := r.targets[gvk.GroupVersion().WithResource(mapping.Resource)]
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("%s does not support apply", mapping.Resource))
+	}
+	return target, nil
+}
+
+// BulkApply provides a webservice applying multi-document streams of objects to the resources
+// of a BulkRegistry.
+type BulkApply struct {
+	Registry   *BulkRegistry
+	Serializer runtime.NegotiatedSerializer
+	// Decoder is the universal deserializer, it decodes the objects of every group.
+	Decoder    runtime.Decoder
+	Authorizer authorizer.Authorizer
+	Context    request.RequestContextMapper
+	// MaxRequestBodyBytes is the largest stream the handler will read, zero or less for no limit.
+	MaxRequestBodyBytes int64
+}
+
+// Install registers the bulk apply handler at path, e.g. POST /bulk.
+func (b BulkApply) Install(path string, c *restful.Container) error {
+	scope := handlers.BulkScope{
+		ContextFunc: func(req *http.Request) request.Context {
+			if ctx, ok := b.Context.Get(req); ok {
+				return request.WithUserAgent(ctx, req.Header.Get("User-Agent"))
+			}
+			return request.WithUserAgent(request.NewContext(), req.Header.Get("User-Agent"))
+		},
+		Serializer:          b.Serializer,
+		Decoder:             b.Decoder,
+		Router:              b.Registry,
+		Authorizer:          b.Authorizer,
+		MaxRequestBodyBytes: b.MaxRequestBodyBytes,
+		MetaGroupVersion:    metav1.SchemeGroupVersion,
+	}
+	handler := handlers.BulkApply(scope)
+	mediaTypes, _ := negotiation.MediaTypesForSerializer(b.Serializer)
+
+	ws := new(restful.WebService)
+	ws.Path(path)
+	ws.Doc("apply a stream of objects")
+	route := ws.POST("/").To(func(req *restful.Request, res *restful.Response) {
+		handler(res.ResponseWriter, req.Request)
+	}).
+		Doc("apply each object of a multi-document YAML or JSON stream").
+		Operation("bulkApply").
+		Produces(mediaTypes...).
+		Consumes(string(types.ApplyPatchType), "application/yaml", runtime.ContentTypeJSON).
+		Returns(http.StatusOK, "OK", metav1.BulkApplyResult{}).
+		Writes(metav1.BulkApplyResult{})
+	if err := addObjectParams(ws, route, &metav1.BulkApplyOptions{}); err != nil {
+		return err
+	}
+	ws.Route(route)
+
+	c.Add(ws)
+	return nil
+}
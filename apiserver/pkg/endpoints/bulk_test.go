@@ -0,0 +1,54 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package endpoints
+
+import (
+	"testing"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers"
+)
+
+func fakeInterfaces(version schema.GroupVersion) (*meta.VersionInterfaces, error) {
+	return &meta.VersionInterfaces{}, nil
+}
+
+func TestBulkRegistryRoute(t *testing.T) {
+	gv := schema.GroupVersion{Group: "core", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gv}, fakeInterfaces)
+	mapper.Add(gv.WithKind("Account"), meta.RESTScopeRoot)
+	mapper.Add(gv.WithKind("User"), meta.RESTScopeNamespace)
+
+	// only accounts are installed, users are known to the mapper but not served
+	target := &handlers.BulkTarget{Scope: handlers.RequestScope{Resource: gv.WithResource("accounts")}}
+	registry := NewBulkRegistry()
+	registry.add(mapper, target)
+
+	if routed, err := registry.Route(gv.WithKind("Account")); err != nil || routed != target {
+		t.Errorf("expected accounts to be routed to their target, got %v %v", routed, err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: "widgets", Version: "v1", Kind: "Widget"},
+		gv.WithKind("Widget"),
+		{Group: "core", Version: "v2", Kind: "Account"},
+		gv.WithKind("User"),
+	} {
+		if routed, err := registry.Route(gvk); !errors.IsBadRequest(err) {
+			t.Errorf("%s: expected a bad request, got %v %v", gvk, routed, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
+)
+
+func newTestCodecs() serializer.CodecFactory {
+	scheme := runtime.NewScheme()
+	scheme.AddUnversionedTypes(schema.GroupVersion{Version: "v1"},
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+	return serializer.NewCodecFactory(scheme)
+}
+
+func TestRootAPIsHandler(t *testing.T) {
+	handler := NewRootAPIsHandler("/api", newTestCodecs())
+	handler.AddGroup(metav1.APIGroup{Name: "core"})
+	handler.AddGroup(metav1.APIGroup{Name: "billing"})
+	handler.AddGroup(metav1.APIGroup{Name: "core", PreferredVersion: metav1.GroupVersionForDiscovery{Version: "v1"}})
+	handler.RemoveGroup("billing")
+
+	req, _ := http.NewRequest("GET", "/api", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	list := metav1.APIGroupList{}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Kind != "APIGroupList" {
+		t.Errorf("expected kind APIGroupList, got %q", list.Kind)
+	}
+	if len(list.Groups) != 1 || list.Groups[0].Name != "core" || list.Groups[0].PreferredVersion.Version != "v1" {
+		t.Errorf("unexpected groups: %#v", list.Groups)
+	}
+}
+
+func TestAPIVersionHandler(t *testing.T) {
+	resources := []metav1.APIResource{{Name: "accounts", Kind: "Account", Verbs: metav1.Verbs{"get", "list"}}}
+	handler := NewAPIVersionHandler(newTestCodecs(), schema.GroupVersion{Group: "core", Version: "v1"}, APIResourceListerFunc(func() []metav1.APIResource {
+		return resources
+	}))
+
+	req, _ := http.NewRequest("GET", "/api/core/v1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	list := metav1.APIResourceList{}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.GroupVersion != "core/v1" || len(list.APIResources) != 1 || list.APIResources[0].Name != "accounts" {
+		t.Errorf("unexpected resource list: %#v", list)
+	}
+}
@@ -0,0 +1,220 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit/policy"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// maxAuditedBodyBytes is the largest request or response body recorded in an audit event.
+// Larger bodies are left out of the event.
+const maxAuditedBodyBytes = 1024 * 1024
+
+// WithAudit records an audit event of every request the policy selects, at the stages of its
+// handling, and sends them to sink. It must run after authentication, as the policy selects
+// requests by user.
+func WithAudit(handler http.Handler, requestContextMapper request.RequestContextMapper, sink audit.Sink, checker policy.Checker, longRunningCheck request.LongRunningRequestCheck) http.Handler {
+	if sink == nil || checker == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request"))
+			return
+		}
+		attribs, err := GetAuthorizerAttributes(ctx)
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+
+		level, omitStages := checker.LevelAndStages(attribs)
+		if level == audit.LevelNone {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		ev := audit.NewEventFromRequest(req, level, attribs)
+		ev.RequestID, _ = request.RequestIDFrom(ctx)
+		// Handlers complete the event, e.g. with the name of a created object.
+		if err := requestContextMapper.Update(req, request.WithAuditEvent(ctx, ev)); err != nil {
+			responsewriters.InternalError(w, req, fmt.Errorf("failed to attach audit event to the context: %v", err))
+			return
+		}
+		processAuditEvent(sink, ev, omitStages)
+
+		var requestBody *auditBuffer
+		if !level.Less(audit.LevelRequest) && req.Body != nil {
+			requestBody = &auditBuffer{}
+			req.Body = &auditReadCloser{ReadCloser: req.Body, buffer: requestBody}
+		}
+		respWriter := &auditResponseWriter{ResponseWriter: w, event: ev}
+		requestInfo, _ := request.RequestInfoFrom(ctx)
+		if longRunningCheck != nil && requestInfo != nil && longRunningCheck(req, requestInfo) {
+			// Long-running requests record when their response starts, and never their body.
+			respWriter.sink = sink
+			respWriter.omitStages = omitStages
+		} else if level == audit.LevelRequestResponse {
+			respWriter.body = &auditBuffer{}
+		}
+
+		// Send the final event of the request once it is handled, either cleanly or via a panic.
+		defer func() {
+			if r := recover(); r != nil {
+				defer panic(r)
+				audit.SetPanic(ev, r)
+				audit.SetStage(ev, audit.StagePanic)
+				processAuditEvent(sink, ev, omitStages)
+				return
+			}
+
+			// A handler writing nothing responded 200.
+			respWriter.processCode(http.StatusOK)
+			if requestBody != nil {
+				ev.RequestObject = requestBody.json()
+			}
+			if respWriter.body != nil {
+				ev.ResponseObject = respWriter.body.json()
+			}
+			audit.SetStage(ev, audit.StageResponseComplete)
+			processAuditEvent(sink, ev, omitStages)
+		}()
+		handler.ServeHTTP(respWriter, req)
+	})
+}
+
+func processAuditEvent(sink audit.Sink, ev *audit.Event, omitStages []audit.Stage) {
+	for _, stage := range omitStages {
+		if ev.Stage == stage {
+			return
+		}
+	}
+	sink.ProcessEvents(ev)
+}
+
+// auditBuffer holds a request or response body, up to maxAuditedBodyBytes.
+type auditBuffer struct {
+	bytes.Buffer
+	overflow bool
+}
+
+func (b *auditBuffer) add(p []byte) {
+	if b.overflow {
+		return
+	}
+	if b.Len()+len(p) > maxAuditedBodyBytes {
+		b.overflow = true
+		b.Reset()
+		return
+	}
+	b.Write(p)
+}
+
+// json returns the body if it is JSON, which is all the audit event records.
+func (b *auditBuffer) json() json.RawMessage {
+	if b.overflow || b.Len() == 0 {
+		return nil
+	}
+	// Unmarshal checks the whole body is valid JSON before copying it.
+	var raw json.RawMessage
+	if err := json.Unmarshal(b.Bytes(), &raw); err != nil {
+		return nil
+	}
+	return raw
+}
+
+// auditReadCloser copies the request body as it is read.
+type auditReadCloser struct {
+	io.ReadCloser
+	buffer *auditBuffer
+}
+
+func (r *auditReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buffer.add(p[:n])
+	return n, err
+}
+
+// auditResponseWriter records the response code, and body if asked, of a request.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	event *audit.Event
+	// sink, if set, is sent the event as the response starts.
+	sink       audit.Sink
+	omitStages []audit.Stage
+	// body, if set, holds the response body.
+	body *auditBuffer
+	once sync.Once
+}
+
+func (a *auditResponseWriter) processCode(code int) {
+	a.once.Do(func() {
+		audit.SetResponseCode(a.event, code)
+		if a.sink != nil {
+			audit.SetStage(a.event, audit.StageResponseStarted)
+			processAuditEvent(a.sink, a.event, a.omitStages)
+		}
+	})
+}
+
+func (a *auditResponseWriter) Write(b []byte) (int, error) {
+	a.processCode(http.StatusOK)
+	if a.body != nil {
+		a.body.add(b)
+	}
+	return a.ResponseWriter.Write(b)
+}
+
+func (a *auditResponseWriter) WriteHeader(code int) {
+	a.processCode(code)
+	a.ResponseWriter.WriteHeader(code)
+}
+
+// Flush implements http.Flusher, for streaming responses.
+func (a *auditResponseWriter) Flush() {
+	if flusher, ok := a.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	} else {
+		glog.V(4).Infof("Unable to convert %+v into http.Flusher", a.ResponseWriter)
+	}
+}
+
+// CloseNotify implements http.CloseNotifier
+func (a *auditResponseWriter) CloseNotify() <-chan bool {
+	return a.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Hijack implements http.Hijacker.
+func (a *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	a.processCode(http.StatusSwitchingProtocols)
+	return a.ResponseWriter.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,176 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit/policy"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+)
+
+type fakeAuditSink struct {
+	lock   sync.Mutex
+	events []*audit.Event
+}
+
+func (s *fakeAuditSink) ProcessEvents(events ...*audit.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, ev := range events {
+		s.events = append(s.events, audit.Copy(ev))
+	}
+}
+
+type fakeAuditChecker audit.Level
+
+func (c fakeAuditChecker) LevelAndStages(authorizer.Attributes) (audit.Level, []audit.Stage) {
+	return audit.Level(c), nil
+}
+
+func newAuditServer(mapper request.RequestContextMapper, handler http.Handler, sink audit.Sink, checker policy.Checker) *httptest.Server {
+	handler = WithAudit(handler, mapper, sink, checker, func(req *http.Request, requestInfo *request.RequestInfo) bool {
+		return requestInfo.Verb == "watch"
+	})
+	handler = WithAuthentication(handler, mapper, &fakeAuthenticator{user: &user.DefaultInfo{Name: "alice"}}, nil)
+	resolver := &request.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	handler = WithRequestInfo(handler, resolver, mapper)
+	handler = WithRequestID(handler, mapper)
+	handler = request.WithRequestContext(handler, mapper)
+	return httptest.NewServer(handler)
+}
+
+type fakeAuthenticator struct {
+	user user.Info
+}
+
+func (a *fakeAuthenticator) AuthenticateRequest(req *http.Request) (user.Info, bool, error) {
+	return a.user, true, nil
+}
+
+func (s *fakeAuditSink) stages() []audit.Stage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stages := []audit.Stage{}
+	for _, ev := range s.events {
+		stages = append(stages, ev.Stage)
+	}
+	return stages
+}
+
+func TestAuditAccountCreate(t *testing.T) {
+	sink := &fakeAuditSink{}
+	mapper := request.NewRequestContextMapper()
+	server := newAuditServer(mapper, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if !strings.Contains(string(body), "acme") {
+			t.Errorf("unexpected body %q", body)
+		}
+		// As the create handler does, once the account is created.
+		ctx, _ := mapper.Get(req)
+		audit.LogObjectRef(request.AuditEventFrom(ctx), &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme", UID: "1234"}})
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"kind":"Account","metadata":{"name":"acme","uid":"1234"}}`))
+	}), sink, fakeAuditChecker(audit.LevelRequestResponse))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/core/v1/accounts", "application/json", strings.NewReader(`{"kind":"Account","metadata":{"name":"acme"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if stages := sink.stages(); !reflect.DeepEqual(stages, []audit.Stage{audit.StageRequestReceived, audit.StageResponseComplete}) {
+		t.Fatalf("unexpected stages %v", stages)
+	}
+	received, complete := sink.events[0], sink.events[1]
+	if received.AuditID != complete.AuditID {
+		t.Errorf("expected the events of a request to share their audit ID")
+	}
+	if requestID := resp.Header.Get(request.RequestIDHeader); len(requestID) == 0 || complete.RequestID != requestID {
+		t.Errorf("expected the event to hold the request ID %q, got %q", requestID, complete.RequestID)
+	}
+	if received.ObjectRef.Name != "" || received.ResponseStatus != nil {
+		t.Errorf("expected the RequestReceived event not to change once sent, got %#v", received)
+	}
+	if complete.User.Username != "alice" || complete.Verb != "create" {
+		t.Errorf("unexpected user %q or verb %q", complete.User.Username, complete.Verb)
+	}
+	expectedRef := audit.ObjectReference{Resource: "accounts", Name: "acme", UID: "1234", APIGroup: "core", APIVersion: "v1"}
+	if complete.ObjectRef == nil || *complete.ObjectRef != expectedRef {
+		t.Errorf("expected object reference %#v, got %#v", expectedRef, complete.ObjectRef)
+	}
+	if complete.ResponseStatus == nil || complete.ResponseStatus.Code != http.StatusCreated {
+		t.Errorf("expected response code 201, got %#v", complete.ResponseStatus)
+	}
+	if !strings.Contains(string(complete.RequestObject), "acme") || !strings.Contains(string(complete.ResponseObject), "1234") {
+		t.Errorf("expected the request and response bodies, got %q and %q", complete.RequestObject, complete.ResponseObject)
+	}
+	if len(complete.Latency) == 0 {
+		t.Errorf("expected the latency to be recorded")
+	}
+}
+
+func TestAuditLevels(t *testing.T) {
+	testCases := []struct {
+		level  audit.Level
+		path   string
+		stages []audit.Stage
+	}{
+		{audit.LevelNone, "/api/core/v1/accounts", []audit.Stage{}},
+		{audit.LevelMetadata, "/api/core/v1/accounts", []audit.Stage{audit.StageRequestReceived, audit.StageResponseComplete}},
+		{audit.LevelRequestResponse, "/api/core/v1/accounts?watch=true", []audit.Stage{audit.StageRequestReceived, audit.StageResponseStarted, audit.StageResponseComplete}},
+	}
+	for _, tc := range testCases {
+		sink := &fakeAuditSink{}
+		server := newAuditServer(request.NewRequestContextMapper(), http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(`{"kind":"AccountList"}`))
+		}), sink, fakeAuditChecker(tc.level))
+
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.level, err)
+		}
+		resp.Body.Close()
+		server.Close()
+
+		if stages := sink.stages(); !reflect.DeepEqual(stages, tc.stages) {
+			t.Errorf("%s: expected stages %v, got %v", tc.level, tc.stages, stages)
+			continue
+		}
+		for _, ev := range sink.events {
+			if ev.Level != audit.LevelRequestResponse && (ev.RequestObject != nil || ev.ResponseObject != nil) {
+				t.Errorf("%s: expected no body to be recorded", tc.level)
+			}
+			if ev.Stage == audit.StageResponseComplete && ev.ResponseObject != nil {
+				// Long-running requests never record their response.
+				t.Errorf("%s %s: unexpected response body %q", tc.level, tc.path, ev.ResponseObject)
+			}
+		}
+	}
+}
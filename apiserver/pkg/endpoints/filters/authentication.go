@@ -26,6 +26,7 @@ import (
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/authenticator"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/metrics"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
 
@@ -40,6 +41,9 @@ func WithAuthentication(handler http.Handler, mapper request.RequestContextMappe
 			if err != nil || !ok {
 				if err != nil {
 					glog.Errorf("Unable to authenticate the request due to an error: %v", err)
+					metrics.RecordAuthentication("error")
+				} else {
+					metrics.RecordAuthentication("failure")
 				}
 				if failed != nil {
 					failed.ServeHTTP(w, req)
@@ -47,6 +51,7 @@ func WithAuthentication(handler http.Handler, mapper request.RequestContextMappe
 				return
 			}
 
+			metrics.RecordAuthentication("success")
 			req.Header.Del("Authorization")
 
 			if ctx, ok := mapper.Get(req); ok {
@@ -23,6 +23,7 @@ import (
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/metrics"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
 
@@ -46,14 +47,17 @@ func WithAuthorization(handler http.Handler, requestContextMapper request.Reques
 		}
 		authorized, reason, err := a.Authorize(attributes)
 		if authorized {
+			metrics.RecordAuthorization("allowed")
 			handler.ServeHTTP(w, req)
 			return
 		}
 		if err != nil {
+			metrics.RecordAuthorization("error")
 			responsewriters.InternalError(w, req, err)
 			return
 		}
 
+		metrics.RecordAuthorization("denied")
 		glog.V(4).Infof("Forbidden: %#v, Reason: %q", req.RequestURI, reason)
 		responsewriters.Forbidden(ctx, attributes, w, req, reason, s)
 	})
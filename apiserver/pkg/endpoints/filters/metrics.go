@@ -0,0 +1,126 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/metrics"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+var readOnlyVerbs = sets.NewString("get", "list", "watch")
+
+// WithMetrics records the count, latency and response size of every request, by verb,
+// resource, subresource, scope and response code, and the number of requests in flight.
+func WithMetrics(handler http.Handler, requestContextMapper request.RequestContextMapper, longRunningCheck request.LongRunningRequestCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request"))
+			return
+		}
+		requestInfo, ok := request.RequestInfoFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no RequestInfo found in the context"))
+			return
+		}
+		labels := metrics.LabelsFor(requestInfo)
+		delegate := &metricsResponseWriter{ResponseWriter: w}
+
+		if longRunningCheck != nil && longRunningCheck(req, requestInfo) {
+			done := metrics.MonitorLongRunning(labels)
+			defer func() {
+				done(delegate.status())
+			}()
+			handler.ServeHTTP(delegate, req)
+			return
+		}
+
+		kind := metrics.MutatingKind
+		if readOnlyVerbs.Has(requestInfo.Verb) {
+			kind = metrics.ReadOnlyKind
+		}
+		defer metrics.MonitorInflight(kind)()
+
+		start := time.Now()
+		defer func() {
+			metrics.Monitor(labels, delegate.status(), delegate.size, time.Since(start))
+		}()
+		handler.ServeHTTP(delegate, req)
+	})
+}
+
+// metricsResponseWriter records the response code and size of a request.
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	code int
+	size int
+}
+
+// status returns the response code, 200 if the handler wrote nothing.
+func (m *metricsResponseWriter) status() int {
+	if m.code == 0 {
+		return http.StatusOK
+	}
+	return m.code
+}
+
+func (m *metricsResponseWriter) WriteHeader(code int) {
+	if m.code == 0 {
+		m.code = code
+	}
+	m.ResponseWriter.WriteHeader(code)
+}
+
+func (m *metricsResponseWriter) Write(b []byte) (int, error) {
+	if m.code == 0 {
+		m.code = http.StatusOK
+	}
+	n, err := m.ResponseWriter.Write(b)
+	m.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher, for streaming responses.
+func (m *metricsResponseWriter) Flush() {
+	if flusher, ok := m.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	} else {
+		glog.V(4).Infof("Unable to convert %+v into http.Flusher", m.ResponseWriter)
+	}
+}
+
+// CloseNotify implements http.CloseNotifier
+func (m *metricsResponseWriter) CloseNotify() <-chan bool {
+	return m.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Hijack implements http.Hijacker.
+func (m *metricsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if m.code == 0 {
+		m.code = http.StatusSwitchingProtocols
+	}
+	return m.ResponseWriter.(http.Hijacker).Hijack()
+}
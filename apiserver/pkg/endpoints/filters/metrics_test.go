@@ -0,0 +1,116 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/metrics"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// metricValue returns the value of the counter or gauge name with the given labels, 0 if not found.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metric:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if labels[pair.GetName()] != pair.GetValue() {
+					continue metric
+				}
+			}
+			if m.Counter != nil {
+				return m.Counter.GetValue()
+			}
+			return m.Gauge.GetValue()
+		}
+	}
+	return 0
+}
+
+func TestMetrics(t *testing.T) {
+	metrics.Register()
+	mapper := request.NewRequestContextMapper()
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" {
+			w.Write([]byte(`{"kind":"AccountList"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	})
+	handler = WithMetrics(handler, mapper, func(req *http.Request, requestInfo *request.RequestInfo) bool {
+		return requestInfo.Verb == "watch"
+	})
+	resolver := &request.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	handler = WithRequestInfo(handler, resolver, mapper)
+	handler = request.WithRequestContext(handler, mapper)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	list := map[string]string{"verb": "LIST", "resource": "accounts", "subresource": "", "scope": "cluster", "code": "200"}
+	deleted := map[string]string{"verb": "DELETE", "resource": "accounts", "subresource": "", "scope": "resource", "code": "403"}
+	watch := map[string]string{"verb": "WATCH", "resource": "accounts", "subresource": "", "scope": "cluster", "code": "200"}
+	listBefore := metricValue(t, "apiserver_request_total", list)
+	deleteBefore := metricValue(t, "apiserver_request_total", deleted)
+	watchBefore := metricValue(t, "apiserver_request_total", watch)
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(server.URL + "/api/core/v1/accounts")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	req, _ := http.NewRequest("DELETE", server.URL+"/api/core/v1/accounts/acme", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/api/core/v1/accounts?watch=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := metricValue(t, "apiserver_request_total", list) - listBefore; got != 2 {
+		t.Errorf("expected 2 list requests, got %v", got)
+	}
+	if got := metricValue(t, "apiserver_request_total", deleted) - deleteBefore; got != 1 {
+		t.Errorf("expected 1 forbidden delete request, got %v", got)
+	}
+	if got := metricValue(t, "apiserver_request_total", watch) - watchBefore; got != 1 {
+		t.Errorf("expected 1 watch request, got %v", got)
+	}
+	if got := metricValue(t, "apiserver_longrunning_requests", map[string]string{"verb": "WATCH", "resource": "accounts", "subresource": "", "scope": "cluster"}); got != 0 {
+		t.Errorf("expected no open watch, got %v", got)
+	}
+	if got := metricValue(t, "apiserver_current_inflight_requests", map[string]string{"request_kind": metrics.ReadOnlyKind}); got != 0 {
+		t.Errorf("expected no request in flight, got %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/uuid"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// maxRequestIDLength is the longest request ID accepted from a client.
+const maxRequestIDLength = 128
+
+// WithRequestID attaches the request's ID to the context. The ID is the client's X-Request-Id
+// when it is valid, and a new one otherwise. It is set on the request and returned in the
+// response headers, so that filters running before the context is populated (e.g. httplog) and
+// the client can quote it.
+func WithRequestID(handler http.Handler, requestContextMapper request.RequestContextMapper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request"))
+			return
+		}
+
+		requestID := req.Header.Get(request.RequestIDHeader)
+		if !validRequestID(requestID) {
+			if len(requestID) != 0 {
+				glog.V(4).Infof("Ignoring invalid request ID %q of %s %s", requestID, req.Method, req.URL.Path)
+			}
+			requestID = string(uuid.NewUUID())
+		}
+		req.Header.Set(request.RequestIDHeader, requestID)
+		w.Header().Set(request.RequestIDHeader, requestID)
+
+		if err := requestContextMapper.Update(req, request.WithRequestID(ctx, requestID)); err != nil {
+			responsewriters.InternalError(w, req, fmt.Errorf("failed to attach request ID to the context: %v", err))
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// validRequestID returns true if id is safe to log and forward: non-empty, at most
+// maxRequestIDLength long and made of letters, digits, '-', '_', '.' and ':' only.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
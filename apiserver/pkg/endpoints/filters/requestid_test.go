@@ -0,0 +1,78 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+func TestRequestID(t *testing.T) {
+	mapper := request.NewRequestContextMapper()
+	var seen string
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, _ := mapper.Get(req)
+		seen, _ = request.RequestIDFrom(ctx)
+		if req.Header.Get(request.RequestIDHeader) != seen {
+			t.Errorf("expected the request header to hold %q, got %q", seen, req.Header.Get(request.RequestIDHeader))
+		}
+	})
+	handler = WithRequestID(handler, mapper)
+	handler = request.WithRequestContext(handler, mapper)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "client ID", header: "req-42_a.b:c", expected: "req-42_a.b:c"},
+		{name: "no ID"},
+		{name: "invalid ID", header: "a b\tc"},
+		{name: "too long ID", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+	generated := map[string]bool{}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest("GET", server.URL+"/api", nil)
+		if len(tc.header) != 0 {
+			req.Header.Set(request.RequestIDHeader, tc.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		resp.Body.Close()
+
+		returned := resp.Header.Get(request.RequestIDHeader)
+		if returned != seen {
+			t.Errorf("%s: expected the response header to hold %q, got %q", tc.name, seen, returned)
+		}
+		if len(tc.expected) != 0 {
+			if seen != tc.expected {
+				t.Errorf("%s: expected ID %q, got %q", tc.name, tc.expected, seen)
+			}
+			continue
+		}
+		if len(seen) == 0 || seen == tc.header || generated[seen] {
+			t.Errorf("%s: expected a new ID, got %q", tc.name, seen)
+		}
+		generated[seen] = true
+	}
+}
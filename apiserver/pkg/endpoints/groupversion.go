@@ -26,6 +26,7 @@ import (
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 	utilerrors "github.com/rantuttl/cloudops/apimachinery/pkg/util/errors"
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/discovery"
 	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
 )
@@ -48,16 +49,23 @@ type APIGroupVersion struct {
 	// accessible from this API group version.
 	SubresourceGroupVersionKind map[string]schema.GroupVersionKind
 	MinRequestTimeout time.Duration
+	// MaxRequestBodyBytes is the limit on the size of request bodies read by the handlers.
+	// Zero or less means no limit.
+	MaxRequestBodyBytes int64
+	// BulkRegistry, if set, is given the resources of this group version objects can be applied
+	// to, for the bulk apply handler.
+	BulkRegistry *BulkRegistry
+	// ShutdownCh, if set, is closed when the server shuts down, which ends the open watches.
+	ShutdownCh <-chan struct{}
 }
 
 func (g *APIGroupVersion) InstallREST(container *restful.Container) error {
 	installer := g.newInstaller()
 	ws := installer.NewWebService()
 	apiResources, registrationErrors := installer.Install(ws)
-	// TODO (rantuttl): Figure out discovery, and add all resources to list all resources, i.e., GET "/"
-	lister := staticLister{apiResources}
-	// make compiler happy
-	_ = lister
+	// Serve the resources of this group version for discovery, i.e., GET "/"
+	versionDiscoveryHandler := discovery.NewAPIVersionHandler(g.Serializer, g.GroupVersion, staticLister{apiResources})
+	versionDiscoveryHandler.AddToWebService(ws)
 
 	container.Add(ws)
 	return utilerrors.NewAggregate(registrationErrors)
@@ -68,6 +76,10 @@ type staticLister struct {
 	list []metav1.APIResource
 }
 
+func (s staticLister) ListAPIResources() []metav1.APIResource {
+	return s.list
+}
+
 func (g *APIGroupVersion) newInstaller() *APIInstaller {
 	// /api/<group-name>/<version>
 	prefix := path.Join(g.Root, g.GroupVersion.Group, g.GroupVersion.Version)
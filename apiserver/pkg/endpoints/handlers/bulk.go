@@ -0,0 +1,300 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/validation/field"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/dryrun"
+)
+
+// bulkMediaTypes are the content types of a bulk apply body, a stream of YAML or JSON documents.
+var bulkMediaTypes = []string{string(types.ApplyPatchType), "application/yaml", runtime.ContentTypeJSON}
+
+// BulkTarget is a resource the objects of a bulk apply can be applied to.
+type BulkTarget struct {
+	Storage rest.Patcher
+	// Scope is the request scope of the resource, its FieldManager applies the objects.
+	Scope RequestScope
+	// Namespaced is true when the objects of the resource live in a namespace.
+	Namespaced bool
+}
+
+// BulkRouter finds the resource that stores the objects of a kind.
+type BulkRouter interface {
+	Route(gvk schema.GroupVersionKind) (*BulkTarget, error)
+}
+
+// BulkScope holds what the bulk apply handler needs, for all resources.
+type BulkScope struct {
+	ContextFunc
+
+	Serializer runtime.NegotiatedSerializer
+	// Decoder decodes a document to the kind it names, whatever its group. It is the universal
+	// deserializer of the codecs.
+	Decoder runtime.Decoder
+	Router  BulkRouter
+	// Authorizer checks each object against the resource it is applied to, the request itself
+	// only names the bulk path. Nil when authorization is disabled.
+	Authorizer authorizer.Authorizer
+
+	// MaxRequestBodyBytes is the largest request body the handler will read, zero or less for no limit.
+	MaxRequestBodyBytes int64
+
+	MetaGroupVersion schema.GroupVersion
+}
+
+func (scope *BulkScope) err(err error, w http.ResponseWriter, req *http.Request) {
+	ctx := scope.ContextFunc(req)
+	responsewriters.ErrorNegotiated(ctx, err, scope.Serializer, scope.MetaGroupVersion, w, req)
+}
+
+// bulkObject is an object of a bulk apply, routed and authorized, ready to be applied.
+type bulkObject struct {
+	target    *BulkTarget
+	namespace string
+	name      string
+	objInfo   *applyObjectInfo
+}
+
+// BulkApply returns a function that applies each object of a multi-document YAML or JSON stream
+// to the resource of its kind, in order, as an apply patch would. An object that fails does not
+// stop the others, unless the request is atomic: then every object is first applied as a dry
+// run, and none is written if one fails. The response is a meta/v1 BulkApplyResult with the
+// outcome of each object; it is returned with 200 whenever the stream could be read, so the
+// client must check the result of each object.
+func BulkApply(scope BulkScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		contentType := req.Header.Get("Content-Type")
+		// Remove "; charset=" if included in header.
+		if idx := strings.Index(contentType, ";"); idx > 0 {
+			contentType = contentType[:idx]
+		}
+		if !isBulkMediaType(strings.TrimSpace(contentType)) {
+			scope.err(negotiation.NewUnsupportedMediaTypeError(bulkMediaTypes), w, req)
+			return
+		}
+
+		options := &metav1.BulkApplyOptions{}
+		if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, options); err != nil {
+			err = errors.NewBadRequest(err.Error())
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateDryRun("BulkApplyOptions", options.DryRun); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		if len(options.FieldManager) == 0 {
+			errs := field.ErrorList{field.Required(field.NewPath("fieldManager"), "is required for apply")}
+			scope.err(errors.NewInvalid(metav1.SchemeGroupVersion.WithKind("BulkApplyOptions").GroupKind(), "", errs), w, req)
+			return
+		}
+		if err := validateFieldManager("BulkApplyOptions", options.FieldManager); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		docs, err := readDocuments(req, scope.MaxRequestBodyBytes)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		if len(docs) == 0 {
+			scope.err(errors.NewBadRequest("the request body is empty"), w, req)
+			return
+		}
+
+		// The timeout bounds the whole request, not each object
+		ctx := scope.ContextFunc(req)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
+		force := options.Force != nil && *options.Force
+		result := &metav1.BulkApplyResult{Items: make([]metav1.BulkApplyResultItem, len(docs))}
+		objects := make([]*bulkObject, len(docs))
+		failed := false
+		for i, doc := range docs {
+			result.Items[i].Index = int32(i)
+			obj, err := scope.prepare(ctx, req, doc, options.FieldManager, force, &result.Items[i])
+			if err != nil {
+				setBulkFailure(&result.Items[i], err)
+				failed = true
+				continue
+			}
+			objects[i] = obj
+		}
+
+		// TODO (rantuttl): Install admission control mechanisms here to permit this operation.
+
+		// An atomic request validates every object with a dry run before the first write
+		if options.Atomic && !dryrun.IsDryRun(options.DryRun) {
+			for i, obj := range objects {
+				if obj == nil {
+					continue
+				}
+				if _, err := applyBulkObject(ctx, obj, []string{metav1.DryRunAll}, timeout); err != nil {
+					setBulkFailure(&result.Items[i], err)
+					objects[i] = nil
+					failed = true
+				}
+			}
+			if failed {
+				for i, obj := range objects {
+					if obj != nil {
+						result.Items[i].Result = metav1.BulkApplySkipped
+					}
+				}
+				responsewriters.WriteObjectNegotiated(ctx, scope.Serializer, scope.MetaGroupVersion, w, req, http.StatusOK, result)
+				return
+			}
+		}
+
+		for i, obj := range objects {
+			if obj == nil {
+				continue
+			}
+			created, err := applyBulkObject(ctx, obj, options.DryRun, timeout)
+			switch {
+			case err != nil:
+				setBulkFailure(&result.Items[i], err)
+			case created:
+				result.Items[i].Result = metav1.BulkApplyCreated
+			default:
+				result.Items[i].Result = metav1.BulkApplyConfigured
+			}
+		}
+		responsewriters.WriteObjectNegotiated(ctx, scope.Serializer, scope.MetaGroupVersion, w, req, http.StatusOK, result)
+	}
+}
+
+// prepare decodes a document of a bulk apply, routes it to the resource of its kind, and checks
+// the user may apply it. What could be read of the object is recorded in item.
+func (scope *BulkScope) prepare(ctx request.Context, req *http.Request, doc []byte, manager string, force bool, item *metav1.BulkApplyResultItem) (*bulkObject, error) {
+	obj, gvk, err := scope.Decoder.Decode(doc, nil, nil)
+	if gvk != nil {
+		item.APIVersion, item.Kind = gvk.GroupVersion().String(), gvk.Kind
+	}
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("%s objects can not be applied", gvk.Kind))
+	}
+	item.Namespace, item.Name = accessor.GetNamespace(), accessor.GetName()
+
+	target, err := scope.Router.Route(*gvk)
+	if err != nil {
+		return nil, err
+	}
+	errs := field.ErrorList{}
+	if len(item.Name) == 0 {
+		errs = append(errs, field.Required(field.NewPath("metadata", "name"), "is required for apply"))
+	}
+	if target.Namespaced && len(item.Namespace) == 0 {
+		errs = append(errs, field.Required(field.NewPath("metadata", "namespace"), "is required for a namespaced resource"))
+	}
+	if !target.Namespaced && len(item.Namespace) > 0 {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "namespace"), item.Namespace, "must be empty for a cluster-scoped resource"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.NewInvalid(gvk.GroupKind(), item.Name, errs)
+	}
+
+	if scope.Authorizer != nil {
+		attributes := authorizer.AttributesRecord{
+			Verb:            "patch",
+			Namespace:       item.Namespace,
+			APIGroup:        target.Scope.Resource.Group,
+			APIVersion:      target.Scope.Resource.Version,
+			Resource:        target.Scope.Resource.Resource,
+			Name:            item.Name,
+			ResourceRequest: true,
+			Path:            req.URL.Path,
+		}
+		if user, ok := request.UserFrom(ctx); ok {
+			attributes.User = user
+		}
+		authorized, reason, err := scope.Authorizer.Authorize(attributes)
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		if !authorized {
+			return nil, responsewriters.ForbiddenError(attributes, reason)
+		}
+	}
+
+	return &bulkObject{
+		target:    target,
+		namespace: item.Namespace,
+		name:      item.Name,
+		objInfo: &applyObjectInfo{
+			fieldManager: target.Scope.FieldManager,
+			patch:        doc,
+			manager:      manager,
+			force:        force,
+		},
+	}, nil
+}
+
+// applyBulkObject applies an object of a bulk apply and reports whether it was created.
+func applyBulkObject(ctx request.Context, obj *bulkObject, dryRun []string, timeout time.Duration) (bool, error) {
+	ctx = request.WithNamespace(ctx, obj.namespace)
+	wasCreated := false
+	_, err := finishRequest(timeout, func() (runtime.Object, error) {
+		result, created, err := applyObject(ctx, obj.target.Storage, obj.name, obj.objInfo, dryRun)
+		wasCreated = created
+		return result, err
+	})
+	return wasCreated, err
+}
+
+// setBulkFailure records the error of an object of a bulk apply.
+func setBulkFailure(item *metav1.BulkApplyResultItem, err error) {
+	item.Result = metav1.BulkApplyFailed
+	item.Status = responsewriters.ErrorToAPIStatus(err)
+}
+
+func isBulkMediaType(contentType string) bool {
+	for _, mediaType := range bulkMediaTypes {
+		if contentType == mediaType {
+			return true
+		}
+	}
+	return false
+}
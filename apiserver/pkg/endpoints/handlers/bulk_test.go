@@ -0,0 +1,288 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	_ "github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core/install"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/fieldmanager"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/dryrun"
+)
+
+// fakeApplyStorage stores objects in memory, by namespace and name, and counts its writes.
+// Objects named "invalid" are rejected.
+type fakeApplyStorage struct {
+	newFunc func() runtime.Object
+	objects map[string]runtime.Object
+	writes  int
+}
+
+func newFakeApplyStorage(newFunc func() runtime.Object) *fakeApplyStorage {
+	return &fakeApplyStorage{newFunc: newFunc, objects: map[string]runtime.Object{}}
+}
+
+func (s *fakeApplyStorage) New() runtime.Object {
+	return s.newFunc()
+}
+
+func (s *fakeApplyStorage) key(ctx request.Context, name string) string {
+	return request.NamespaceValue(ctx) + "/" + name
+}
+
+func (s *fakeApplyStorage) Get(ctx request.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	obj, ok := s.objects[s.key(ctx, name)]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "objects"}, name)
+	}
+	return obj, nil
+}
+
+func (s *fakeApplyStorage) Update(ctx request.Context, name string, objInfo rest.UpdatedObjectInfo, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	existing, err := s.Get(ctx, name, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	obj, err := objInfo.UpdatedObject(ctx, existing)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.write(ctx, name, obj, options.DryRun)
+}
+
+func (s *fakeApplyStorage) Create(ctx request.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	name := obj.(interface {
+		GetName() string
+	}).GetName()
+	obj, _, err := s.write(ctx, name, obj, options.DryRun)
+	return obj, err
+}
+
+func (s *fakeApplyStorage) write(ctx request.Context, name string, obj runtime.Object, dryRun []string) (runtime.Object, bool, error) {
+	if name == "invalid" {
+		return nil, false, errors.NewBadRequest("invalid object")
+	}
+	if dryrun.IsDryRun(dryRun) {
+		return obj, false, nil
+	}
+	s.objects[s.key(ctx, name)] = obj
+	s.writes++
+	return obj, false, nil
+}
+
+// fakeBulkRouter routes the kinds to their targets.
+type fakeBulkRouter map[string]*BulkTarget
+
+func (r fakeBulkRouter) Route(gvk schema.GroupVersionKind) (*BulkTarget, error) {
+	target, ok := r[gvk.Kind]
+	if !ok {
+		return nil, errors.NewBadRequest("no resource is served for " + gvk.String())
+	}
+	return target, nil
+}
+
+func newBulkTarget(storage rest.Patcher, kind, resource string, namespaced bool) *BulkTarget {
+	gvk := schema.GroupVersionKind{Group: "core", Version: "v1", Kind: kind}
+	return &BulkTarget{
+		Storage: storage,
+		Scope: RequestScope{
+			FieldManager: fieldmanager.NewFieldManager(api.Scheme, api.Scheme, api.Scheme, gvk, schema.GroupVersion{Group: "core", Version: runtime.APIVersionInternal}),
+			Resource:     gvk.GroupVersion().WithResource(resource),
+			Kind:         gvk,
+		},
+		Namespaced: namespaced,
+	}
+}
+
+type bulkTest struct {
+	accounts *fakeApplyStorage
+	handler  http.HandlerFunc
+}
+
+// newBulkTest returns a bulk apply handler with an existing account, and an authorizer denying
+// the objects named "denied". Accounts are served as a namespaced kind if namespaced is set.
+func newBulkTest(namespaced bool) *bulkTest {
+	b := &bulkTest{
+		accounts: newFakeApplyStorage(func() runtime.Object { return &core.Account{} }),
+	}
+	b.accounts.objects["/existing"] = &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}
+	b.handler = BulkApply(BulkScope{
+		ContextFunc: func(req *http.Request) request.Context { return request.NewContext() },
+		Serializer:  api.Codecs,
+		Decoder:     api.Codecs.UniversalDeserializer(),
+		Router: fakeBulkRouter{
+			"Account": newBulkTarget(b.accounts, "Account", "accounts", namespaced),
+		},
+		Authorizer: authorizer.AuthorizerFunc(func(a authorizer.Attributes) (bool, string, error) {
+			return a.GetName() != "denied", "", nil
+		}),
+		MetaGroupVersion: metav1.SchemeGroupVersion,
+	})
+	return b
+}
+
+// apply posts the documents to the handler and returns the response code and result.
+func (b *bulkTest) apply(t *testing.T, query string, docs ...string) (int, *metav1.BulkApplyResult) {
+	req, _ := http.NewRequest("POST", "/bulk?"+query, strings.NewReader(strings.Join(docs, "\n---\n")))
+	req.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+	b.handler(w, req)
+	if w.Code != http.StatusOK {
+		status := &metav1.Status{}
+		if err := json.Unmarshal(w.Body.Bytes(), status); err != nil || status.Code != int32(w.Code) {
+			t.Errorf("expected a status with code %d, got %q", w.Code, w.Body.String())
+		}
+		return w.Code, nil
+	}
+	result := &metav1.BulkApplyResult{}
+	if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+		t.Fatalf("unable to decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, result
+}
+
+func account(name, namespace string) string {
+	doc := "apiVersion: core/v1\nkind: Account\nmetadata:\n  name: " + name + "\n"
+	if len(namespace) > 0 {
+		doc += "  namespace: " + namespace + "\n"
+	}
+	return doc + "  labels:\n    team: gitops\n"
+}
+
+// expectResults checks the result of each item, and the code of the failed ones.
+func expectResults(t *testing.T, name string, result *metav1.BulkApplyResult, expected []metav1.BulkApplyResultType, codes map[int]int32) {
+	if result == nil || len(result.Items) != len(expected) {
+		t.Fatalf("%s: expected %d items, got %#v", name, len(expected), result)
+	}
+	for i, item := range result.Items {
+		if item.Index != int32(i) || item.Result != expected[i] {
+			t.Errorf("%s: expected item %d to be %s, got %#v", name, i, expected[i], item)
+		}
+		code, failed := codes[i]
+		switch {
+		case failed && (item.Status == nil || item.Status.Code != code):
+			t.Errorf("%s: expected item %d to fail with %d, got %#v", name, i, code, item.Status)
+		case !failed && item.Status != nil:
+			t.Errorf("%s: unexpected status of item %d: %#v", name, i, item.Status)
+		}
+	}
+}
+
+func TestBulkApplyMixed(t *testing.T) {
+	b := newBulkTest(false)
+	code, result := b.apply(t, "fieldManager=ci",
+		account("acme", ""),
+		account("existing", ""),
+		"apiVersion: core/v1\nkind: Account\nmetadata:\n  labels:\n    team: gitops\n",
+		account("invalid", ""),
+	)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	expectResults(t, "mixed", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplyCreated, metav1.BulkApplyConfigured, metav1.BulkApplyFailed, metav1.BulkApplyFailed},
+		map[int]int32{2: http.StatusUnprocessableEntity, 3: http.StatusBadRequest})
+	if item := result.Items[0]; item.APIVersion != "core/v1" || item.Kind != "Account" || item.Name != "acme" {
+		t.Errorf("unexpected item %#v", item)
+	}
+	if b.accounts.writes != 2 {
+		t.Errorf("expected 2 writes, got %d", b.accounts.writes)
+	}
+	if acme, ok := b.accounts.objects["/acme"].(*core.Account); !ok || acme.Labels["team"] != "gitops" || len(acme.ManagedFields) != 1 {
+		t.Errorf("expected acme to be applied by ci, got %#v", b.accounts.objects["/acme"])
+	}
+}
+
+func TestBulkApplyAtomic(t *testing.T) {
+	b := newBulkTest(false)
+	_, result := b.apply(t, "fieldManager=ci&atomic=true",
+		account("acme", ""),
+		account("invalid", ""),
+		account("existing", ""),
+	)
+	expectResults(t, "atomic", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplySkipped, metav1.BulkApplyFailed, metav1.BulkApplySkipped},
+		map[int]int32{1: http.StatusBadRequest})
+	if b.accounts.writes != 0 {
+		t.Errorf("expected nothing to be written, got %d writes", b.accounts.writes)
+	}
+
+	_, result = b.apply(t, "fieldManager=ci&atomic=true", account("acme", ""), account("existing", ""))
+	expectResults(t, "atomic success", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplyCreated, metav1.BulkApplyConfigured}, nil)
+	if b.accounts.writes != 2 {
+		t.Errorf("expected 2 writes, got %d", b.accounts.writes)
+	}
+}
+
+func TestBulkApplyRouting(t *testing.T) {
+	b := newBulkTest(false)
+	_, result := b.apply(t, "fieldManager=ci",
+		"apiVersion: core/v1\nkind: Widget\nmetadata:\n  name: foo\n",
+		account("acme", "tenant"),
+	)
+	expectResults(t, "cluster-scoped", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplyFailed, metav1.BulkApplyFailed},
+		map[int]int32{0: http.StatusBadRequest, 1: http.StatusUnprocessableEntity})
+	if item := result.Items[0]; item.Kind != "Widget" {
+		t.Errorf("expected the unknown kind to be reported, got %#v", item)
+	}
+	if b.accounts.writes != 0 {
+		t.Errorf("expected nothing to be written, got %d writes", b.accounts.writes)
+	}
+
+	b = newBulkTest(true)
+	_, result = b.apply(t, "fieldManager=ci", account("acme", ""), account("acme", "tenant"))
+	expectResults(t, "namespaced", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplyFailed, metav1.BulkApplyCreated},
+		map[int]int32{0: http.StatusUnprocessableEntity})
+	if _, ok := b.accounts.objects["tenant/acme"]; !ok || b.accounts.writes != 1 {
+		t.Errorf("expected the account to be written in its namespace, got %v", b.accounts.objects)
+	}
+}
+
+func TestBulkApplyFieldManagerRequired(t *testing.T) {
+	b := newBulkTest(false)
+	if code, _ := b.apply(t, "", account("acme", "")); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", code)
+	}
+	if b.accounts.writes != 0 {
+		t.Errorf("expected nothing to be written, got %d writes", b.accounts.writes)
+	}
+}
+
+func TestBulkApplyAuthorization(t *testing.T) {
+	b := newBulkTest(false)
+	_, result := b.apply(t, "fieldManager=ci", account("acme", ""), account("denied", ""))
+	expectResults(t, "authorization", result,
+		[]metav1.BulkApplyResultType{metav1.BulkApplyCreated, metav1.BulkApplyFailed},
+		map[int]int32{1: http.StatusForbidden})
+	if _, ok := b.accounts.objects["/denied"]; ok {
+		t.Errorf("expected the denied account not to be written")
+	}
+}
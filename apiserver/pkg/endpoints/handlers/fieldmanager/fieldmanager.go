@@ -0,0 +1,384 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package fieldmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+)
+
+// FieldsTypeV1 is the only supported format of ManagedFieldsEntry.FieldsV1.
+const FieldsTypeV1 = "FieldsV1"
+
+// FieldManager tracks which manager owns which fields of an object, and merges applied
+// configurations into live objects. Fields are tracked in the external version of the kind,
+// the objects it is handed and returns are internal.
+type FieldManager struct {
+	convertor runtime.ObjectConvertor
+	creater   runtime.ObjectCreater
+	copier    runtime.ObjectCopier
+	kind      schema.GroupVersionKind
+	hub       schema.GroupVersion
+}
+
+// NewFieldManager creates a FieldManager for the external kind, whose internal objects are
+// in the hub group version.
+func NewFieldManager(convertor runtime.ObjectConvertor, creater runtime.ObjectCreater, copier runtime.ObjectCopier, kind schema.GroupVersionKind, hub schema.GroupVersion) *FieldManager {
+	return &FieldManager{
+		convertor: convertor,
+		creater:   creater,
+		copier:    copier,
+		kind:      kind,
+		hub:       hub,
+	}
+}
+
+// Update records that manager owns the fields newObj changes from liveObj, which is nil on
+// create. Other managers lose the fields that changed; there are no conflicts on update. The
+// managed fields sent by the client are discarded, they are carried over from liveObj.
+func (f *FieldManager) Update(liveObj, newObj runtime.Object, manager string) (runtime.Object, error) {
+	var managed []metav1.ManagedFieldsEntry
+	liveU := map[string]interface{}{}
+	if liveObj != nil {
+		var err error
+		if managed, err = managedFieldsOf(liveObj); err != nil {
+			return nil, err
+		}
+		if liveU, err = f.toUnstructured(liveObj); err != nil {
+			return nil, err
+		}
+	}
+	newU, err := f.toUnstructured(newObj)
+	if err != nil {
+		return nil, err
+	}
+
+	liveFields, newFields := fieldsOf(liveU), fieldsOf(newU)
+	changed := liveFields.difference(newFields)
+	for _, p := range newFields {
+		if !sameValue(liveU, newU, p) {
+			changed.insert(p)
+		}
+	}
+
+	entries, err := decodeEntries(managed)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].fields = entries[i].fields.intersection(newFields).difference(changed)
+	}
+	entries = setEntry(entries, manager, metav1.ManagedFieldsOperationUpdate, changed.intersection(newFields), true)
+
+	return f.withManagedFields(newObj, entries)
+}
+
+// Apply merges the applied configuration, a JSON object, into liveObj on behalf of manager,
+// which then owns exactly the fields of the configuration. Fields the manager applied before
+// and left out now are removed, unless another manager owns them as well. Changing the value
+// of a field owned by another manager is a conflict, unless force is set, in which case the
+// field is taken over. liveObj is an empty object when apply creates it.
+func (f *FieldManager) Apply(liveObj runtime.Object, patch []byte, manager string, force bool) (runtime.Object, error) {
+	config, err := f.decodeConfig(patch)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := managedFieldsOf(liveObj)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decodeEntries(managed)
+	if err != nil {
+		return nil, err
+	}
+	liveU, err := f.toUnstructured(liveObj)
+	if err != nil {
+		return nil, err
+	}
+
+	configFields := fieldsOf(config)
+
+	conflicts := []Conflict{}
+	for i, e := range entries {
+		// a manager does not conflict with itself
+		if e.Manager == manager {
+			continue
+		}
+		lost := fieldSet{}
+		for _, owned := range e.fields.sorted() {
+			for _, p := range configFields.sorted() {
+				if p.overlaps(owned) && !sameValue(liveU, config, p) {
+					conflicts = append(conflicts, Conflict{Manager: e.Manager, Field: owned.String()})
+					lost.insert(owned)
+					break
+				}
+			}
+		}
+		entries[i].fields = e.fields.difference(lost)
+	}
+	if len(conflicts) > 0 && !force {
+		return nil, NewConflictError(conflicts)
+	}
+
+	var previous fieldSet
+	for _, e := range entries {
+		if e.Manager == manager && e.Operation == metav1.ManagedFieldsOperationApply {
+			previous = e.fields
+		}
+	}
+	for _, p := range previous.difference(configFields).sorted() {
+		if !ownedByOthers(entries, manager, p) {
+			removeAt(liveU, p)
+		}
+	}
+
+	merged := merge(liveU, config)
+	mergedFields := fieldsOf(merged)
+	for i := range entries {
+		entries[i].fields = entries[i].fields.intersection(mergedFields)
+	}
+	entries = setEntry(entries, manager, metav1.ManagedFieldsOperationApply, configFields, false)
+
+	obj, err := f.fromUnstructured(merged)
+	if err != nil {
+		return nil, err
+	}
+	return f.withManagedFields(obj, entries)
+}
+
+// Conflict is a field owned by another manager whose value an apply request changes.
+type Conflict struct {
+	Manager string
+	Field   string
+}
+
+// NewConflictError returns the 409 reported for an apply request that changes fields owned by
+// other managers, with one cause per field.
+func NewConflictError(conflicts []Conflict) *errors.StatusError {
+	causes := make([]metav1.StatusCause, 0, len(conflicts))
+	messages := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: fmt.Sprintf("conflict with %q", c.Manager),
+			Field:   c.Field,
+		})
+		messages = append(messages, fmt.Sprintf("conflict with %q: %s", c.Manager, c.Field))
+	}
+	noun := "conflict"
+	if len(conflicts) != 1 {
+		noun = "conflicts"
+	}
+	return &errors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusConflict,
+		Reason:  metav1.StatusReasonConflict,
+		Message: fmt.Sprintf("Apply failed with %d %s: %s", len(conflicts), noun, strings.Join(messages, ", ")),
+		Details: &metav1.StatusDetails{Causes: causes},
+	}}
+}
+
+// entry is a decoded ManagedFieldsEntry.
+type entry struct {
+	metav1.ManagedFieldsEntry
+	fields  fieldSet
+	changed bool
+}
+
+func decodeEntries(managed []metav1.ManagedFieldsEntry) ([]entry, error) {
+	entries := make([]entry, 0, len(managed))
+	for _, m := range managed {
+		if len(m.FieldsType) > 0 && m.FieldsType != FieldsTypeV1 {
+			return nil, errors.NewInternalError(fmt.Errorf("unsupported managed fields type %q of %q", m.FieldsType, m.Manager))
+		}
+		fields, err := fieldSetFromFieldsV1(m.FieldsV1)
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		entries = append(entries, entry{ManagedFieldsEntry: m, fields: fields})
+	}
+	return entries, nil
+}
+
+// setEntry gives manager the fields for operation. An update adds to the fields the manager
+// already owns, an apply replaces them.
+func setEntry(entries []entry, manager string, operation metav1.ManagedFieldsOperationType, fields fieldSet, add bool) []entry {
+	for i, e := range entries {
+		if e.Manager == manager && e.Operation == operation {
+			if add {
+				fields = e.fields.union(fields)
+			}
+			entries[i].fields = fields
+			entries[i].changed = true
+			return entries
+		}
+	}
+	return append(entries, entry{ManagedFieldsEntry: metav1.ManagedFieldsEntry{Manager: manager, Operation: operation}, fields: fields, changed: true})
+}
+
+func ownedByOthers(entries []entry, manager string, p path) bool {
+	for _, e := range entries {
+		if e.Manager == manager && e.Operation == metav1.ManagedFieldsOperationApply {
+			continue
+		}
+		if e.fields.overlaps(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func managedFieldsOf(obj runtime.Object) ([]metav1.ManagedFieldsEntry, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return accessor.GetManagedFields(), nil
+}
+
+// withManagedFields sets the entries that still own fields on obj.
+func (f *FieldManager) withManagedFields(obj runtime.Object, entries []entry) (runtime.Object, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	now := metav1.Now()
+	var managed []metav1.ManagedFieldsEntry
+	for _, e := range entries {
+		if len(e.fields) == 0 {
+			continue
+		}
+		fieldsV1, err := e.fields.toFieldsV1()
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		m := e.ManagedFieldsEntry
+		if e.changed || m.FieldsV1 == nil || !bytes.Equal(m.FieldsV1.Raw, fieldsV1.Raw) {
+			m = metav1.ManagedFieldsEntry{
+				Manager:    e.Manager,
+				Operation:  e.Operation,
+				APIVersion: f.kind.GroupVersion().String(),
+				Time:       &now,
+				FieldsType: FieldsTypeV1,
+				FieldsV1:   fieldsV1,
+			}
+		}
+		managed = append(managed, m)
+	}
+	accessor.SetManagedFields(managed)
+	return obj, nil
+}
+
+// decodeConfig reads an applied configuration. Values the kind has no field for are dropped,
+// as they would be by a create or update. The status is owned by the server.
+func (f *FieldManager) decodeConfig(patch []byte) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if err := unmarshal(patch, &config); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if m, ok := config["metadata"].(map[string]interface{}); ok {
+		if _, ok := m["managedFields"]; ok {
+			return nil, errors.NewBadRequest("metadata.managedFields must not be set in an applied configuration")
+		}
+	}
+	versioned, err := f.creater.New(f.kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, versioned); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	data, err := json.Marshal(versioned)
+	if err != nil {
+		return nil, err
+	}
+	typed := map[string]interface{}{}
+	if err := unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	prune(config, typed)
+	delete(config, "status")
+	return config, nil
+}
+
+// prune drops the values of config that have no counterpart in typed.
+func prune(config, typed map[string]interface{}) {
+	for k, v := range config {
+		t, ok := typed[k]
+		if !ok {
+			delete(config, k)
+			continue
+		}
+		if configMap, ok := v.(map[string]interface{}); ok {
+			if typedMap, ok := t.(map[string]interface{}); ok {
+				prune(configMap, typedMap)
+			}
+		}
+	}
+}
+
+// unmarshal decodes JSON, keeping numbers as they were written.
+func unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// toUnstructured converts an internal object to the JSON representation of the external kind.
+func (f *FieldManager) toUnstructured(obj runtime.Object) (map[string]interface{}, error) {
+	copied, err := f.copier.Copy(obj)
+	if err != nil {
+		return nil, err
+	}
+	versioned, err := f.convertor.ConvertToVersion(copied, f.kind.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(versioned)
+	if err != nil {
+		return nil, err
+	}
+	u := map[string]interface{}{}
+	if err := unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// fromUnstructured converts the JSON representation of the external kind to an internal object.
+func (f *FieldManager) fromUnstructured(u map[string]interface{}) (runtime.Object, error) {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	versioned, err := f.creater.New(f.kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, versioned); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	return f.convertor.ConvertToVersion(versioned, f.hub)
+}
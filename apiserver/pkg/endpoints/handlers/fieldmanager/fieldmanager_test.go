@@ -0,0 +1,163 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package fieldmanager
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	_ "github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core/install"
+)
+
+func newTestFieldManager() *FieldManager {
+	return NewFieldManager(api.Scheme, api.Scheme, api.Scheme,
+		schema.GroupVersionKind{Group: "core", Version: "v1", Kind: "Account"},
+		schema.GroupVersion{Group: "core", Version: runtime.APIVersionInternal})
+}
+
+func apply(t *testing.T, f *FieldManager, live runtime.Object, patch, manager string, force bool) (*core.Account, error) {
+	obj, err := f.Apply(live, []byte(patch), manager, force)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := obj.(*core.Account)
+	if !ok {
+		t.Fatalf("unexpected object %T", obj)
+	}
+	return account, nil
+}
+
+func managers(account *core.Account) map[string]fieldSet {
+	out := map[string]fieldSet{}
+	for _, m := range account.ManagedFields {
+		fields, _ := fieldSetFromFieldsV1(m.FieldsV1)
+		out[m.Manager+"/"+string(m.Operation)] = fields
+	}
+	return out
+}
+
+func TestApply(t *testing.T) {
+	f := newTestFieldManager()
+
+	account, err := apply(t, f, &core.Account{}, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"team":"gitops","env":"prod"}},"unknown":true}`, "ci", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if account.Name != "foo" || !reflect.DeepEqual(account.Labels, map[string]string{"team": "gitops", "env": "prod"}) {
+		t.Fatalf("unexpected object after first apply: %#v", account.ObjectMeta)
+	}
+	expected := map[string]fieldSet{"ci/Apply": newFieldSet(path{"metadata", "labels", "team"}, path{"metadata", "labels", "env"})}
+	if got := managers(account); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected managers %v, got %v", expected, got)
+	}
+
+	// an equal value is shared, not a conflict
+	account, err = apply(t, f, account, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"team":"gitops"}}}`, "controller", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := managers(account); len(got) != 2 || !got["controller/Apply"].has(path{"metadata", "labels", "team"}) {
+		t.Fatalf("expected team to be shared, got %v", got)
+	}
+
+	// changing a field owned by another manager is a conflict
+	_, err = apply(t, f, account, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"env":"dev"}}}`, "controller", false)
+	status, ok := err.(*errors.StatusError)
+	if !ok || status.ErrStatus.Code != http.StatusConflict {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	causes := status.ErrStatus.Details.Causes
+	if len(causes) != 1 || causes[0].Type != metav1.CauseTypeFieldManagerConflict || causes[0].Field != ".metadata.labels.env" {
+		t.Fatalf("unexpected causes: %#v", causes)
+	}
+
+	// unless forced, then the field is taken over
+	account, err = apply(t, f, account, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"env":"dev"}}}`, "controller", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = map[string]fieldSet{
+		"ci/Apply":         newFieldSet(path{"metadata", "labels", "team"}),
+		"controller/Apply": newFieldSet(path{"metadata", "labels", "env"}),
+	}
+	if got := managers(account); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected managers %v, got %v", expected, got)
+	}
+	if !reflect.DeepEqual(account.Labels, map[string]string{"team": "gitops", "env": "dev"}) {
+		t.Fatalf("unexpected labels after forced apply: %v", account.Labels)
+	}
+
+	// a field left out by its only manager is removed
+	account, err = apply(t, f, account, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","annotations":{"owner":"ci"}}}`, "ci", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(account.Labels, map[string]string{"env": "dev"}) {
+		t.Fatalf("expected team to be removed, got %v", account.Labels)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	f := newTestFieldManager()
+
+	live, err := apply(t, f, &core.Account{}, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"team":"gitops","env":"prod"}}}`, "ci", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"team": "gitops", "env": "dev"}}}
+	obj, err := f.Update(live, updated, "kubectl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]fieldSet{
+		"ci/Apply":       newFieldSet(path{"metadata", "labels", "team"}),
+		"kubectl/Update": newFieldSet(path{"metadata", "labels", "env"}),
+	}
+	if got := managers(obj.(*core.Account)); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected managers %v, got %v", expected, got)
+	}
+
+	// the applier gets a conflict for the field the update took
+	if _, err := apply(t, f, obj, `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"foo","labels":{"team":"gitops","env":"prod"}}}`, "ci", false); !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+}
+
+func TestFieldsV1RoundTrip(t *testing.T) {
+	s := newFieldSet(path{"spec"}, path{"spec", "owner"}, path{"metadata", "labels", "app.cloudops.io/name"})
+	fields, err := s.toFieldsV1()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"f:metadata":{"f:labels":{"f:app.cloudops.io/name":{}}},"f:spec":{".":{},"f:owner":{}}}`
+	if string(fields.Raw) != expected {
+		t.Errorf("expected %s, got %s", expected, fields.Raw)
+	}
+	got, err := fieldSetFromFieldsV1(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("expected %v, got %v", s, got)
+	}
+}
@@ -0,0 +1,304 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package fieldmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+)
+
+// path is the list of field names, or map keys, leading to a value of an object.
+type path []string
+
+// String renders the path the way users write it, e.g. ".spec.owner".
+func (p path) String() string {
+	return "." + strings.Join(p, ".")
+}
+
+// key is a unique representation of the path, map keys may contain dots.
+func (p path) key() string {
+	return strings.Join(p, "\x00")
+}
+
+// hasPrefix is true when p is prefix, or lies below it.
+func (p path) hasPrefix(prefix path) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i := range prefix {
+		if p[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps is true when one of the paths lies below, or is equal to, the other.
+func (p path) overlaps(o path) bool {
+	return p.hasPrefix(o) || o.hasPrefix(p)
+}
+
+// fieldSet is a set of the leaf fields of an object. Maps are walked, any other value,
+// lists included, is owned as a whole.
+// TODO (rantuttl): Lists are atomic. Ownership of individual items, e.g. keyed by name, needs
+// schema information we do not have yet.
+type fieldSet map[string]path
+
+func newFieldSet(paths ...path) fieldSet {
+	s := fieldSet{}
+	for _, p := range paths {
+		s.insert(p)
+	}
+	return s
+}
+
+func (s fieldSet) insert(p path) {
+	s[p.key()] = append(path(nil), p...)
+}
+
+func (s fieldSet) has(p path) bool {
+	_, ok := s[p.key()]
+	return ok
+}
+
+// overlaps is true when any path of the set overlaps p.
+func (s fieldSet) overlaps(p path) bool {
+	for _, q := range s {
+		if q.overlaps(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s fieldSet) union(o fieldSet) fieldSet {
+	out := fieldSet{}
+	for k, p := range s {
+		out[k] = p
+	}
+	for k, p := range o {
+		out[k] = p
+	}
+	return out
+}
+
+func (s fieldSet) difference(o fieldSet) fieldSet {
+	out := fieldSet{}
+	for k, p := range s {
+		if _, ok := o[k]; !ok {
+			out[k] = p
+		}
+	}
+	return out
+}
+
+func (s fieldSet) intersection(o fieldSet) fieldSet {
+	out := fieldSet{}
+	for k, p := range s {
+		if _, ok := o[k]; ok {
+			out[k] = p
+		}
+	}
+	return out
+}
+
+// sorted returns the paths of the set in a stable order.
+func (s fieldSet) sorted() []path {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]path, 0, len(s))
+	for _, k := range keys {
+		out = append(out, s[k])
+	}
+	return out
+}
+
+// toFieldsV1 serializes the set as a trie, e.g. {"f:spec":{"f:owner":{}}}. A field that is
+// owned itself and also has owned children carries a "." key.
+func (s fieldSet) toFieldsV1() (*metav1.FieldsV1, error) {
+	root := map[string]interface{}{}
+	for _, p := range s.sorted() {
+		node := root
+		for _, name := range p {
+			child, ok := node["f:"+name].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node["f:"+name] = child
+			}
+			node = child
+		}
+	}
+	markInnerLeaves(root, s, nil)
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.FieldsV1{Raw: data}, nil
+}
+
+// markInnerLeaves adds the "." key to the owned fields that also have owned children.
+func markInnerLeaves(node map[string]interface{}, s fieldSet, prefix path) {
+	for k, v := range node {
+		if !strings.HasPrefix(k, "f:") {
+			continue
+		}
+		child := v.(map[string]interface{})
+		p := append(append(path(nil), prefix...), strings.TrimPrefix(k, "f:"))
+		if len(child) > 0 && s.has(p) {
+			child["."] = map[string]interface{}{}
+		}
+		markInnerLeaves(child, s, p)
+	}
+}
+
+// fieldSetFromFieldsV1 parses the trie written by toFieldsV1.
+func fieldSetFromFieldsV1(f *metav1.FieldsV1) (fieldSet, error) {
+	s := fieldSet{}
+	if f == nil || len(f.Raw) == 0 {
+		return s, nil
+	}
+	root := map[string]interface{}{}
+	if err := json.Unmarshal(f.Raw, &root); err != nil {
+		return nil, err
+	}
+	if err := walkFieldsV1(root, nil, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func walkFieldsV1(node map[string]interface{}, prefix path, s fieldSet) error {
+	if len(node) == 0 && len(prefix) > 0 {
+		s.insert(prefix)
+		return nil
+	}
+	for k, v := range node {
+		if k == "." {
+			s.insert(prefix)
+			continue
+		}
+		if !strings.HasPrefix(k, "f:") {
+			return fmt.Errorf("unknown key %q in managed fields at %s", k, prefix)
+		}
+		child, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an object for %q in managed fields at %s", k, prefix)
+		}
+		p := append(append(path(nil), prefix...), strings.TrimPrefix(k, "f:"))
+		if err := walkFieldsV1(child, p, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldsOf returns the ownable fields set in obj. The type information and status are not
+// ownable, and of the metadata only labels and annotations are.
+func fieldsOf(obj map[string]interface{}) fieldSet {
+	s := fieldSet{}
+	for k, v := range obj {
+		switch k {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			if m, ok := v.(map[string]interface{}); ok {
+				for _, name := range []string{"labels", "annotations"} {
+					if child, ok := m[name]; ok {
+						collectFields(child, path{k, name}, s)
+					}
+				}
+			}
+			continue
+		}
+		collectFields(v, path{k}, s)
+	}
+	return s
+}
+
+func collectFields(v interface{}, p path, s fieldSet) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		if v != nil {
+			s.insert(p)
+		}
+		return
+	}
+	for k, child := range m {
+		collectFields(child, append(append(path(nil), p...), k), s)
+	}
+}
+
+// valueAt returns the value of obj at p.
+func valueAt(obj map[string]interface{}, p path) (interface{}, bool) {
+	var v interface{} = obj
+	for _, name := range p {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// sameValue is true when both objects hold an equal value at p.
+func sameValue(a, b map[string]interface{}, p path) bool {
+	va, oka := valueAt(a, p)
+	vb, okb := valueAt(b, p)
+	return oka == okb && reflect.DeepEqual(va, vb)
+}
+
+// removeAt deletes the value of obj at p, if it is there.
+func removeAt(obj map[string]interface{}, p path) {
+	if len(p) == 0 {
+		return
+	}
+	parent, ok := valueAt(obj, p[:len(p)-1])
+	if !ok {
+		return
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		delete(m, p[len(p)-1])
+	}
+}
+
+// merge sets every value of src on dst. Maps are merged recursively, any other value replaces
+// the one in dst.
+func merge(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = map[string]interface{}{}
+		}
+		dst[k] = merge(dstMap, srcMap)
+	}
+	return dst
+}
@@ -143,6 +143,17 @@ func NegotiateOutputSerializer(req *http.Request, ns runtime.NegotiatedSerialize
 	return info, err
 }
 
+func NegotiateOutputStreamSerializer(req *http.Request, ns runtime.NegotiatedSerializer) (runtime.SerializerInfo, error) {
+	_, info, err := NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions)
+	if err != nil {
+		return runtime.SerializerInfo{}, err
+	}
+	if info.StreamSerializer == nil {
+		return runtime.SerializerInfo{}, NewNotAcceptableError([]string{info.MediaType})
+	}
+	return info, nil
+}
+
 // AcceptedMediaTypesForEndpoint returns an array of structs that are used to efficiently check which
 // allowed media types the server exposes.
 func AcceptedMediaTypesForEndpoint(ns runtime.NegotiatedSerializer) []AcceptedMediaType {
@@ -0,0 +1,210 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/validation/field"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/fieldmanager"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
+)
+
+// PatchResource returns a function that will handle a resource patch. Only server-side apply is
+// supported: the body is the configuration the field manager wants, a full or partial object in
+// YAML or JSON sent as application/apply-patch+yaml. An apply creates the object if it does not
+// exist yet.
+func PatchResource(r rest.Patcher, scope RequestScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		contentType := req.Header.Get("Content-Type")
+		// Remove "; charset=" if included in header.
+		if idx := strings.Index(contentType, ";"); idx > 0 {
+			contentType = contentType[:idx]
+		}
+		if types.PatchType(strings.TrimSpace(contentType)) != types.ApplyPatchType {
+			scope.err(negotiation.NewUnsupportedMediaTypeError([]string{string(types.ApplyPatchType)}), w, req)
+			return
+		}
+		if scope.FieldManager == nil {
+			scope.err(errors.NewBadRequest(fmt.Sprintf("apply is not supported for %s", scope.Resource.GroupResource())), w, req)
+			return
+		}
+
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		namespace, name, err := scope.Namer.Name(req)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		ctx := scope.ContextFunc(req)
+		ctx = request.WithNamespace(ctx, namespace)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
+
+		options := &metav1.PatchOptions{}
+		if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, options); err != nil {
+			err = errors.NewBadRequest(err.Error())
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateDryRun("PatchOptions", options.DryRun); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		if len(options.FieldManager) == 0 {
+			errs := field.ErrorList{field.Required(field.NewPath("fieldManager"), "is required for apply patch")}
+			scope.err(errors.NewInvalid(metav1.SchemeGroupVersion.WithKind("PatchOptions").GroupKind(), "", errs), w, req)
+			return
+		}
+		if err := validateFieldManager("PatchOptions", options.FieldManager); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		// The configuration is streamed from the body as YAML or JSON and converted to JSON
+		patch, err := readSingleDocument(req, scope.MaxRequestBodyBytes)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		// The configuration must name its kind, it is decoded to check it against the resource
+		// before the field manager reads it.
+		info, ok := runtime.SerializerInfoForMediaType(scope.Serializer.SupportedMediaTypes(), runtime.ContentTypeJSON)
+		if !ok {
+			scope.err(errors.NewInternalError(fmt.Errorf("no JSON serializer is registered for %s", scope.Kind.GroupVersion())), w, req)
+			return
+		}
+		decoder := scope.Serializer.DecoderToVersion(info.Serializer, scope.Kind.GroupVersion())
+		applied, gvk, err := decoder.Decode(patch, nil, nil)
+		if err != nil {
+			err = transformDecodeError(scope.Typer, err, r.New(), gvk, patch)
+			scope.err(err, w, req)
+			return
+		}
+		if *gvk != scope.Kind {
+			err = errors.NewBadRequest(fmt.Sprintf("the kind in the data (%s) does not match the expected kind (%s)", gvk, scope.Kind))
+			scope.err(err, w, req)
+			return
+		}
+		if err := checkName(applied, name, namespace, scope.Namer); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		// TODO (rantuttl): Install admission control mechanisms here to permit this operation.
+
+		objInfo := &applyObjectInfo{
+			fieldManager: scope.FieldManager,
+			patch:        patch,
+			manager:      options.FieldManager,
+			force:        options.Force != nil && *options.Force,
+		}
+		wasCreated := false
+		result, err := finishRequest(timeout, func() (runtime.Object, error) {
+			obj, created, err := applyObject(ctx, r, name, objInfo, options.DryRun)
+			wasCreated = created
+			return obj, err
+		})
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		requestInfo, ok := request.RequestInfoFrom(ctx)
+		if !ok {
+			scope.err(fmt.Errorf("missing requestInfo"), w, req)
+			return
+		}
+		if err := setSelfLink(result, requestInfo, scope.Namer); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		status := http.StatusOK
+		if wasCreated {
+			status = http.StatusCreated
+		}
+		transformResponseObject(ctx, scope, req, w, status, result)
+	}
+}
+
+// applyObject applies the configuration held by objInfo to the named object. Apply creates the
+// object from the configuration when it does not exist and the storage can create it, the
+// returned bool reports whether it did.
+func applyObject(ctx request.Context, r rest.Patcher, name string, objInfo *applyObjectInfo, dryRun []string) (runtime.Object, bool, error) {
+	obj, _, err := r.Update(ctx, name, objInfo, &metav1.UpdateOptions{DryRun: dryRun})
+	creater, isCreater := r.(rest.Creater)
+	if !errors.IsNotFound(err) || !isCreater {
+		return obj, false, err
+	}
+	obj, err = objInfo.UpdatedObject(ctx, creater.New())
+	if err != nil {
+		return nil, false, err
+	}
+	obj, err = creater.Create(ctx, obj, &metav1.CreateOptions{DryRun: dryRun})
+	return obj, true, err
+}
+
+// applyObjectInfo implements rest.UpdatedObjectInfo, it applies the configuration to the
+// object read by the storage.
+type applyObjectInfo struct {
+	fieldManager *fieldmanager.FieldManager
+	patch        []byte
+	manager      string
+	force        bool
+}
+
+// Preconditions satisfies the UpdatedObjectInfo interface. A resourceVersion in the applied
+// configuration is kept by the merge, and checked by the storage.
+func (i *applyObjectInfo) Preconditions() *metav1.Preconditions {
+	return nil
+}
+
+// UpdatedObject satisfies the UpdatedObjectInfo interface.
+func (i *applyObjectInfo) UpdatedObject(ctx request.Context, oldObj runtime.Object) (runtime.Object, error) {
+	return i.fieldManager.Apply(oldObj, i.patch, i.manager, i.force)
+}
+
+// managedObjectInfo wraps the rest.UpdatedObjectInfo of an update to record the fields the
+// update changes as owned by its manager.
+type managedObjectInfo struct {
+	rest.UpdatedObjectInfo
+	fieldManager *fieldmanager.FieldManager
+	manager      string
+}
+
+// UpdatedObject satisfies the UpdatedObjectInfo interface.
+func (i *managedObjectInfo) UpdatedObject(ctx request.Context, oldObj runtime.Object) (runtime.Object, error) {
+	newObj, err := i.UpdatedObjectInfo.UpdatedObject(ctx, oldObj)
+	if err != nil {
+		return nil, err
+	}
+	return i.fieldManager.Update(oldObj, newObj, i.manager)
+}
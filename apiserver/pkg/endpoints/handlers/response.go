@@ -16,16 +16,153 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
 )
 
 // transformResponseObject takes a loaded object and performs any necessary transformations.
 func transformResponseObject(ctx request.Context, scope RequestScope, req *http.Request, w http.ResponseWriter, statusCode int, result runtime.Object) {
-	// TODO (rantuttl): Put any transformations needed here
+	// TODO (rantuttl): fetch the media type much earlier in request processing and pass it into this method.
+	mediaType, info, err := negotiation.NegotiateOutputMediaType(req, scope.Serializer, &scope)
+	if err != nil {
+		status := responsewriters.ErrorToAPIStatus(err)
+		responsewriters.WriteRawJSON(int(status.Code), status, w)
+		return
+	}
+
+	// If conversion was allowed by the scope, perform it before writing the response
+	if target := mediaType.Convert; target != nil {
+		var converted runtime.Object
+		switch target.Kind {
+		case "Table":
+			// table cells hold arbitrary values, so a Table can only be served in a text format
+			if !info.EncodesAsText {
+				supported, _ := negotiation.MediaTypesForSerializer(scope.Serializer)
+				status := responsewriters.ErrorToAPIStatus(negotiation.NewNotAcceptableError(supported))
+				responsewriters.WriteRawJSON(int(status.Code), status, w)
+				return
+			}
+			converted, err = asTable(ctx, result, req, scope)
+		case "PartialObjectMetadata":
+			converted, err = asPartialObjectMetadata(result)
+		case "PartialObjectMetadataList":
+			converted, err = asPartialObjectMetadataList(result)
+		default:
+			// this block should only be hit if scope AllowsConversion is incorrect
+			supported, _ := negotiation.MediaTypesForSerializer(scope.Serializer)
+			status := responsewriters.ErrorToAPIStatus(negotiation.NewNotAcceptableError(supported))
+			responsewriters.WriteRawJSON(int(status.Code), status, w)
+			return
+		}
+		if err != nil {
+			// the accepted media type may not allow a plain Status, so write it as negotiated
+			status := responsewriters.ErrorToAPIStatus(err)
+			encoder := scope.Serializer.EncoderForVersion(info.Serializer, scope.Kind.GroupVersion())
+			responsewriters.SerializeObject(info.MediaType, encoder, w, req, int(status.Code), status)
+			return
+		}
+		// converted objects are always served as meta/v1, whatever the group of the resource
+		encoder := scope.Serializer.EncoderForVersion(info.Serializer, metav1.SchemeGroupVersion)
+		responsewriters.SerializeObject(info.MediaType, encoder, w, req, statusCode, converted)
+		return
+	}
 
 	responsewriters.WriteObject(ctx, statusCode, scope.Kind.GroupVersion(), scope.Serializer, result, w, req)
 }
+
+// asTable converts result into a Table, including as much of each object as the request asks for.
+func asTable(ctx request.Context, result runtime.Object, req *http.Request, scope RequestScope) (runtime.Object, error) {
+	opts := &metav1.TableOptions{}
+	if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, opts); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	switch opts.IncludeObject {
+	case "", metav1.IncludeNone, metav1.IncludeMetadata, metav1.IncludeObject:
+	default:
+		return nil, errors.NewBadRequest(fmt.Sprintf("unrecognized includeObject value: %q", opts.IncludeObject))
+	}
+
+	table, err := scope.TableConvertor.ConvertToTable(ctx, result, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range table.Rows {
+		item := &table.Rows[i]
+		switch opts.IncludeObject {
+		case metav1.IncludeObject:
+			item.Object.Object, err = scope.Convertor.ConvertToVersion(item.Object.Object, scope.Kind.GroupVersion())
+			if err != nil {
+				return nil, err
+			}
+		case metav1.IncludeMetadata:
+			m, err := meta.Accessor(item.Object.Object)
+			if err != nil {
+				return nil, err
+			}
+			item.Object.Object = withPartialObjectMetadataKind(meta.AsPartialObjectMetadata(m))
+		case metav1.IncludeNone, "":
+			item.Object.Object = nil
+		}
+	}
+
+	return table, nil
+}
+
+// asPartialObjectMetadata returns only the metadata of a single object.
+func asPartialObjectMetadata(result runtime.Object) (runtime.Object, error) {
+	if meta.IsListType(result) {
+		return nil, errors.NewBadRequest(fmt.Sprintf("you requested PartialObjectMetadata, but the requested object is a list (%T), request PartialObjectMetadataList instead", result))
+	}
+	m, err := meta.Accessor(result)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the requested object (%T) has no metadata", result))
+	}
+	return withPartialObjectMetadataKind(meta.AsPartialObjectMetadata(m)), nil
+}
+
+// asPartialObjectMetadataList returns only the metadata of each item of a list.
+func asPartialObjectMetadataList(result runtime.Object) (runtime.Object, error) {
+	if !meta.IsListType(result) {
+		return nil, errors.NewBadRequest(fmt.Sprintf("you requested PartialObjectMetadataList, but the requested object is not a list (%T), request PartialObjectMetadata instead", result))
+	}
+	items, err := meta.ExtractList(result)
+	if err != nil {
+		return nil, err
+	}
+	list := &metav1.PartialObjectMetadataList{
+		Items: make([]metav1.PartialObjectMetadata, 0, len(items)),
+	}
+	list.GetObjectKind().SetGroupVersionKind(metav1.SchemeGroupVersion.WithKind("PartialObjectMetadataList"))
+	for _, item := range items {
+		m, err := meta.Accessor(item)
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("the requested list contains an object (%T) without metadata", item))
+		}
+		list.Items = append(list.Items, *withPartialObjectMetadataKind(meta.AsPartialObjectMetadata(m)))
+	}
+	if l, err := meta.ListAccessor(result); err == nil {
+		list.ResourceVersion = l.GetResourceVersion()
+		list.SelfLink = l.GetSelfLink()
+		if li, ok := l.(metav1.ListInterface); ok {
+			list.Continue = li.GetContinue()
+		}
+	}
+	return list, nil
+}
+
+// withPartialObjectMetadataKind sets the kind of partial, which is not set by the encoder on
+// objects nested in a list or table.
+func withPartialObjectMetadataKind(partial *metav1.PartialObjectMetadata) *metav1.PartialObjectMetadata {
+	partial.GetObjectKind().SetGroupVersionKind(metav1.SchemeGroupVersion.WithKind("PartialObjectMetadata"))
+	return partial
+}
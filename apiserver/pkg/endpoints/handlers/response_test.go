@@ -0,0 +1,133 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+func newPartialTestAccount(name string) core.Account {
+	return core.Account{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID("uid-" + name), ResourceVersion: "7", Labels: map[string]string{"team": "ci"}},
+		Spec:       core.AccountSpec{},
+		Status:     core.AccountStatus{Phase: core.AccountActive},
+	}
+}
+
+func TestAsPartialObjectMetadata(t *testing.T) {
+	account := newPartialTestAccount("acme")
+	obj, err := asPartialObjectMetadata(&account)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	partial, ok := obj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		t.Fatalf("expected a PartialObjectMetadata, got %T", obj)
+	}
+	if partial.APIVersion != "meta/v1" || partial.Kind != "PartialObjectMetadata" {
+		t.Errorf("unexpected type meta %#v", partial.TypeMeta)
+	}
+	if partial.Name != "acme" || partial.UID != "uid-acme" || partial.ResourceVersion != "7" || partial.Labels["team"] != "ci" {
+		t.Errorf("expected the metadata of the account, got %#v", partial.ObjectMeta)
+	}
+
+	if _, err := asPartialObjectMetadata(&core.AccountList{}); !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for a list, got %v", err)
+	}
+}
+
+func TestAsPartialObjectMetadataList(t *testing.T) {
+	list := &core.AccountList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "42", Continue: "next-page"},
+		Items:    []core.Account{newPartialTestAccount("acme"), newPartialTestAccount("initech")},
+	}
+	obj, err := asPartialObjectMetadataList(list)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	partials, ok := obj.(*metav1.PartialObjectMetadataList)
+	if !ok {
+		t.Fatalf("expected a PartialObjectMetadataList, got %T", obj)
+	}
+	if partials.APIVersion != "meta/v1" || partials.Kind != "PartialObjectMetadataList" {
+		t.Errorf("unexpected type meta %#v", partials.TypeMeta)
+	}
+	if partials.ResourceVersion != "42" || partials.Continue != "next-page" {
+		t.Errorf("expected the list metadata to be kept, got %#v", partials.ListMeta)
+	}
+	if len(partials.Items) != 2 {
+		t.Fatalf("expected 2 items, got %#v", partials.Items)
+	}
+	for i, item := range partials.Items {
+		if item.Name != list.Items[i].Name || item.Kind != "PartialObjectMetadata" || item.APIVersion != "meta/v1" {
+			t.Errorf("unexpected item %#v", item)
+		}
+	}
+
+	if _, err := asPartialObjectMetadataList(&list.Items[0]); !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for a single object, got %v", err)
+	}
+}
+
+func TestTransformResponseObjectPartial(t *testing.T) {
+	scope := RequestScope{
+		Serializer:       api.Codecs,
+		Kind:             schema.GroupVersionKind{Group: "core", Version: "v1", Kind: "Account"},
+		MetaGroupVersion: metav1.SchemeGroupVersion,
+	}
+	account := newPartialTestAccount("acme")
+	for _, test := range []struct {
+		as   string
+		code int
+	}{
+		{"PartialObjectMetadata", http.StatusOK},
+		{"PartialObjectMetadataList", http.StatusBadRequest},
+		{"Widget", http.StatusNotAcceptable},
+	} {
+		req := httptest.NewRequest("GET", "/api/core/v1/accounts/acme", nil)
+		req.Header.Set("Accept", "application/json;as="+test.as+";v=v1;g=meta")
+		w := httptest.NewRecorder()
+		transformResponseObject(request.NewContext(), scope, req, w, http.StatusOK, &account)
+		if w.Code != test.code {
+			t.Errorf("as=%s: expected %d, got %d %s", test.as, test.code, w.Code, w.Body.String())
+			continue
+		}
+		if test.code != http.StatusOK {
+			continue
+		}
+		body := map[string]interface{}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("as=%s: unexpected error: %v", test.as, err)
+		}
+		if _, ok := body["spec"]; ok || body["kind"] != "PartialObjectMetadata" || body["apiVersion"] != "meta/v1" {
+			t.Errorf("as=%s: expected only the metadata of the account, got %s", test.as, w.Body.String())
+		}
+		if _, ok := body["status"]; ok || !strings.Contains(w.Body.String(), `"name":"acme"`) {
+			t.Errorf("as=%s: expected only the metadata of the account, got %s", test.as, w.Body.String())
+		}
+	}
+}
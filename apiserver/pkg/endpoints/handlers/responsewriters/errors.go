@@ -41,18 +41,26 @@ func BadGatewayError(w http.ResponseWriter, req *http.Request) {
 
 // Forbidden renders a simple forbidden error
 func Forbidden(ctx request.Context, attributes authorizer.Attributes, w http.ResponseWriter, req *http.Request, reason string, s runtime.NegotiatedSerializer) {
-	msg := sanitizer.Replace(forbiddenMessage(attributes))
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 
+	gv := schema.GroupVersion{Group: attributes.GetAPIGroup(), Version: attributes.GetAPIVersion()}
+	ErrorNegotiated(ctx, ForbiddenError(attributes, reason), s, gv, w, req)
+}
+
+// ForbiddenError returns the error of a request the authorizer denied for the given reason.
+// Handlers that authorize more than the request itself, e.g. each object of a bulk apply,
+// return it for the part that was denied.
+func ForbiddenError(attributes authorizer.Attributes, reason string) error {
+	msg := sanitizer.Replace(forbiddenMessage(attributes))
+
 	var errMsg string
 	if len(reason) == 0 {
 		errMsg = fmt.Sprintf("%s", msg)
 	} else {
 		errMsg = fmt.Sprintf("%s: %q", msg, reason)
 	}
-	gv := schema.GroupVersion{Group: attributes.GetAPIGroup(), Version: attributes.GetAPIVersion()}
 	gr := schema.GroupResource{Group: attributes.GetAPIGroup(), Resource: attributes.GetResource()}
-	ErrorNegotiated(ctx, apierrors.NewForbidden(gr, attributes.GetName(), fmt.Errorf(errMsg)), s, gv, w, req)
+	return apierrors.NewForbidden(gr, attributes.GetName(), fmt.Errorf(errMsg))
 }
 
 func forbiddenMessage(attributes authorizer.Attributes) string {
@@ -67,3 +67,21 @@ func ErrorToAPIStatus(err error) *metav1.Status {
 		}
 	}
 }
+
+// withRequestID returns a copy of status with requestID added to its details, so that a client
+// reporting the error can quote it. The details of status are shared with the error it was
+// converted from, and are not modified.
+func withRequestID(status *metav1.Status, requestID string) *metav1.Status {
+	details := metav1.StatusDetails{}
+	if status.Details != nil {
+		details = *status.Details
+	}
+	causes := make([]metav1.StatusCause, 0, len(details.Causes)+1)
+	details.Causes = append(append(causes, details.Causes...), metav1.StatusCause{
+		Type:    metav1.CauseTypeRequestID,
+		Message: requestID,
+	})
+	withID := *status
+	withID.Details = &details
+	return &withID
+}
@@ -0,0 +1,48 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package responsewriters
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+)
+
+func TestWithRequestID(t *testing.T) {
+	err := apierrors.NewConflict(schema.GroupResource{Resource: "accounts"}, "acme", nil)
+	status := ErrorToAPIStatus(err)
+	withID := withRequestID(status, "req-1")
+
+	expected := []metav1.StatusCause{{Type: metav1.CauseTypeRequestID, Message: "req-1"}}
+	if withID.Details == nil || !reflect.DeepEqual(withID.Details.Causes, expected) {
+		t.Errorf("expected causes %#v, got %#v", expected, withID.Details)
+	}
+	if withID.Details.Name != "acme" || withID.Code != http.StatusConflict {
+		t.Errorf("expected the rest of the status to be kept, got %#v", withID)
+	}
+	if len(err.Status().Details.Causes) != 0 || len(status.Details.Causes) != 0 {
+		t.Errorf("expected the details of the error not to be modified")
+	}
+
+	// Errors not converted to a status have no details to begin with.
+	withID = withRequestID(ErrorToAPIStatus(http.ErrBodyNotAllowed), "req-2")
+	if withID.Details == nil || len(withID.Details.Causes) != 1 || withID.Details.Causes[0].Message != "req-2" {
+		t.Errorf("unexpected details %#v", withID.Details)
+	}
+}
@@ -74,6 +74,11 @@ func WriteObjectNegotiated(ctx request.Context, s runtime.NegotiatedSerializer,
 func ErrorNegotiated(ctx request.Context, err error, s runtime.NegotiatedSerializer, gv schema.GroupVersion, w http.ResponseWriter, req *http.Request) int {
 	status := ErrorToAPIStatus(err)
 	code := int(status.Code)
+	if ctx != nil {
+		if requestID, ok := request.RequestIDFrom(ctx); ok {
+			status = withRequestID(status, requestID)
+		}
+	}
 	// when writing an error, check to see if the status indicates a retry after period
 	if status.Details != nil && status.Details.RetryAfterSeconds > 0 {
 		delay := strconv.Itoa(int(status.Details.RetryAfterSeconds))
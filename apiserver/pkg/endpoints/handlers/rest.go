@@ -20,17 +20,28 @@ import (
 	"time"
 	"net/http"
 	//"net/url"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"encoding/hex"
+	"encoding/json"
+	"strings"
 
 	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/fields"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	utilruntime "github.com/rantuttl/cloudops/apimachinery/pkg/util/runtime"
 	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
+	metav1validation "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1/validation"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/validation/field"
+	utilyaml "github.com/rantuttl/cloudops/apimachinery/pkg/util/yaml"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/fieldmanager"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
 )
@@ -51,13 +62,18 @@ type RequestScope struct {
 	// FIXME (rantuttl)
 	//UnsafeConvertor runtime.ObjectConvertor
 
-	// FIXME (rantuttl)
-	//TableConvertor rest.TableConvertor
+	TableConvertor rest.TableConvertor
+	FieldManager   *fieldmanager.FieldManager
 
 	Resource    schema.GroupVersionResource
 	Kind        schema.GroupVersionKind
 	Subresource string
 
+	// MaxRequestBodyBytes is the largest request body the handlers will read, zero or less for no limit.
+	MaxRequestBodyBytes int64
+	// ShutdownCh, if set, is closed when the server shuts down. Open watches are then ended.
+	ShutdownCh <-chan struct{}
+
 	MetaGroupVersion schema.GroupVersion
 }
 
@@ -66,6 +82,32 @@ func (scope *RequestScope) err(err error, w http.ResponseWriter, req *http.Reque
 	responsewriters.ErrorNegotiated(ctx, err, scope.Serializer, scope.Kind.GroupVersion(), w, req)
 }
 
+// AllowsConversion is true for the meta/v1 Table, if the resource can be converted to one, and
+// for PartialObjectMetadata and PartialObjectMetadataList. A kind requested without group and
+// version is taken to be meta/v1.
+func (scope *RequestScope) AllowsConversion(gvk schema.GroupVersionKind) bool {
+	if gvk.GroupVersion() != metav1.SchemeGroupVersion && !gvk.GroupVersion().Empty() {
+		return false
+	}
+	switch gvk.Kind {
+	case "Table":
+		return scope.TableConvertor != nil
+	case "PartialObjectMetadata", "PartialObjectMetadataList":
+		return true
+	}
+	return false
+}
+
+// AllowsServerVersion is false, the server group version is never overridden.
+func (scope *RequestScope) AllowsServerVersion(version string) bool {
+	return false
+}
+
+// AllowsStreamSchema allows watch streams only.
+func (scope *RequestScope) AllowsStreamSchema(s string) bool {
+	return s == "watch"
+}
+
 // CreateResource returns a function that will handle a resource creation.
 // FIXME (rantuttl): 'Typer' already sent in scope object. Remove from this and associated method signatures
 func CreateResource(r rest.Creater, scope RequestScope, typer runtime.ObjectTyper) http.HandlerFunc {
@@ -76,18 +118,20 @@ type namedCreaterAdapter struct {
 	rest.Creater
 }
 
-func (c *namedCreaterAdapter) Create(ctx request.Context, name string, obj runtime.Object, includeUninitialized bool) (runtime.Object, error) {
-	return c.Creater.Create(ctx, obj, includeUninitialized)
+func (c *namedCreaterAdapter) Create(ctx request.Context, name string, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	return c.Creater.Create(ctx, obj, options)
 }
 
 // FIXME (rantuttl): 'Typer' already sent in scope object. Remove from this and associated method signatures
 func createHandler(r rest.NamedCreater, scope RequestScope, typer runtime.ObjectTyper, includeName bool) http.HandlerFunc {
 	return func (w http.ResponseWriter, req *http.Request) {
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
-		var (
-			namespace, name string
-			err             error
-		)
+		var namespace, name string
 		if includeName {
 			namespace, name, err = scope.Namer.Name(req)
 		} else {
@@ -96,6 +140,23 @@ func createHandler(r rest.NamedCreater, scope RequestScope, typer runtime.Object
 
 		ctx := scope.ContextFunc(req)
 		ctx = request.WithNamespace(ctx, namespace)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
+
+		options := &metav1.CreateOptions{}
+		if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, options); err != nil {
+			err = errors.NewBadRequest(err.Error())
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateDryRun("CreateOptions", options.DryRun); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateFieldManager("CreateOptions", options.FieldManager); err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
 		s, err := negotiation.NegotiateInputSerializer(req, scope.Serializer)
 		if err != nil {
@@ -105,7 +166,7 @@ func createHandler(r rest.NamedCreater, scope RequestScope, typer runtime.Object
 		gv := scope.Kind.GroupVersion()
 		decoder := scope.Serializer.DecoderToVersion(s.Serializer, schema.GroupVersion{Group: gv.Group, Version: runtime.APIVersionInternal})
 
-		body, err := readBody(req)
+		body, err := readBody(req, scope.MaxRequestBodyBytes)
 		if err != nil {
 			scope.err(err, w, req)
 			return
@@ -124,20 +185,25 @@ func createHandler(r rest.NamedCreater, scope RequestScope, typer runtime.Object
 			scope.err(err, w, req)
 			return
 		}
+		if scope.FieldManager != nil {
+			if obj, err = scope.FieldManager.Update(nil, obj, managerFor(req, options.FieldManager)); err != nil {
+				scope.err(err, w, req)
+				return
+			}
+		}
 		// TODO (rantuttl): Install admission control mechanisms here to permit this operation.
 
-		includeUninitialized := req.URL.Query().Get("includeUninitialized") == "1"
-
-		// TODO (rantuttl): Decide how we want to handle establishing timeout values. For now, hardcode,
-		// but could provide via the API installation, either through the group registration and/or via a default setting.
-		timeout := 30 * time.Second
+		// The URL of a create does not name the object, record it for auditing. Its UID is
+		// only known once it is created.
+		audit.LogObjectRef(request.AuditEventFrom(ctx), obj)
 		result, err := finishRequest(timeout, func() (runtime.Object, error) {
-			return r.Create(ctx, name, obj, includeUninitialized)
+			return r.Create(ctx, name, obj, options)
 		})
 		if err != nil {
 			scope.err(err, w, req)
 			return
 		}
+		audit.LogObjectRef(request.AuditEventFrom(ctx), result)
 
 		requestInfo, ok := request.RequestInfoFrom(ctx)
 		if !ok {
@@ -157,6 +223,160 @@ func createHandler(r rest.NamedCreater, scope RequestScope, typer runtime.Object
 	}
 }
 
+// UpdateResource returns a function that will handle a resource update
+func UpdateResource(r rest.Updater, scope RequestScope, typer runtime.ObjectTyper) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		namespace, name, err := scope.Namer.Name(req)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		ctx := scope.ContextFunc(req)
+		ctx = request.WithNamespace(ctx, namespace)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
+
+		options := &metav1.UpdateOptions{}
+		if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, options); err != nil {
+			err = errors.NewBadRequest(err.Error())
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateDryRun("UpdateOptions", options.DryRun); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		if err := validateFieldManager("UpdateOptions", options.FieldManager); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		body, err := readBody(req, scope.MaxRequestBodyBytes)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		s, err := negotiation.NegotiateInputSerializer(req, scope.Serializer)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		defaultGVK := scope.Kind
+		original := r.New()
+		decoder := scope.Serializer.DecoderToVersion(s.Serializer, schema.GroupVersion{Group: defaultGVK.Group, Version: runtime.APIVersionInternal})
+		obj, gvk, err := decoder.Decode(body, &defaultGVK, original)
+		if err != nil {
+			err = transformDecodeError(typer, err, original, gvk, body)
+			scope.err(err, w, req)
+			return
+		}
+		if gvk.GroupVersion() != defaultGVK.GroupVersion() {
+			err = errors.NewBadRequest(fmt.Sprintf("the API version in the data (%s) does not match the expected API version (%s)", gvk.GroupVersion(), defaultGVK.GroupVersion()))
+			scope.err(err, w, req)
+			return
+		}
+
+		if err := checkName(obj, name, namespace, scope.Namer); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		// TODO (rantuttl): Install admission control mechanisms here to permit this operation.
+
+		objInfo := rest.DefaultUpdatedObjectInfo(obj, scope.Copier)
+		if scope.FieldManager != nil {
+			objInfo = &managedObjectInfo{
+				UpdatedObjectInfo:	objInfo,
+				fieldManager:		scope.FieldManager,
+				manager:		managerFor(req, options.FieldManager),
+			}
+		}
+
+		wasCreated := false
+		result, err := finishRequest(timeout, func() (runtime.Object, error) {
+			obj, created, err := r.Update(ctx, name, objInfo, options)
+			wasCreated = created
+			return obj, err
+		})
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		requestInfo, ok := request.RequestInfoFrom(ctx)
+		if !ok {
+			scope.err(fmt.Errorf("missing requestInfo"), w, req)
+			return
+		}
+		if err := setSelfLink(result, requestInfo, scope.Namer); err != nil {
+			scope.err(err, w, req)
+			return
+		}
+
+		status := http.StatusOK
+		if wasCreated {
+			status = http.StatusCreated
+		}
+		transformResponseObject(ctx, scope, req, w, status, result)
+	}
+}
+
+// validateDryRun rejects dryRun directives this server does not understand as a bad request,
+// before any processing of the request takes place.
+func validateDryRun(optionsKind string, dryRun []string) error {
+	if errs := metav1validation.ValidateDryRun(field.NewPath("dryRun"), dryRun); len(errs) > 0 {
+		return errors.NewBadRequest(fmt.Sprintf("invalid %s: %v", optionsKind, errs.ToAggregate()))
+	}
+	return nil
+}
+
+// validateFieldManager rejects field manager names that are too long or not printable.
+func validateFieldManager(optionsKind string, fieldManager string) error {
+	if errs := metav1validation.ValidateFieldManager(fieldManager, field.NewPath("fieldManager")); len(errs) > 0 {
+		return errors.NewInvalid(metav1.SchemeGroupVersion.WithKind(optionsKind).GroupKind(), "", errs)
+	}
+	return nil
+}
+
+// managerFor returns the field manager of a create or update. When the client did not name
+// one, it is the product of the User-Agent, e.g. "curl" for "curl/7.58.0".
+func managerFor(req *http.Request, fieldManager string) string {
+	if len(fieldManager) > 0 {
+		return fieldManager
+	}
+	manager := strings.TrimSpace(strings.Split(req.UserAgent(), "/")[0])
+	if len(manager) > metav1validation.FieldManagerMaxLength {
+		manager = manager[:metav1validation.FieldManagerMaxLength]
+	}
+	if len(manager) == 0 {
+		return "unknown"
+	}
+	return manager
+}
+
+// checkName checks the provided name against the request
+func checkName(obj runtime.Object, name, namespace string, namer ScopeNamer) error {
+	if objNamespace, objName, err := namer.ObjectName(obj); err == nil {
+		if objName != name {
+			return errors.NewBadRequest(fmt.Sprintf(
+				"the name of the object (%s) does not match the name on the URL (%s)", objName, name))
+		}
+		if len(namespace) > 0 {
+			if len(objNamespace) > 0 && objNamespace != namespace {
+				return errors.NewBadRequest(fmt.Sprintf(
+					"the namespace of the object (%s) does not match the namespace on the request (%s)", objNamespace, namespace))
+			}
+		}
+	}
+	return nil
+}
+
 // GetResource returns a function that handles retrieving a single resource from a rest.Storage object.
 func GetResource(r rest.Getter, e rest.Exporter, scope RequestScope) http.HandlerFunc {
 	return getResourceHandler(scope,
@@ -189,6 +409,11 @@ func GetResource(r rest.Getter, e rest.Exporter, scope RequestScope) http.Handle
 // passed-in getterFunc to perform the actual get.
 func getResourceHandler(scope RequestScope, getter getterFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
 		namespace, name, err := scope.Namer.Name(req)
 		if err != nil {
@@ -197,6 +422,8 @@ func getResourceHandler(scope RequestScope, getter getterFunc) http.HandlerFunc
 		}
 		ctx := scope.ContextFunc(req)
 		ctx = request.WithNamespace(ctx, namespace)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
 
 		result, err := getter(ctx, name, req)
 		if err != nil {
@@ -219,39 +446,123 @@ func getResourceHandler(scope RequestScope, getter getterFunc) http.HandlerFunc
 
 func ListResource(r rest.Lister, rw rest.Watcher, scope RequestScope, forceWatch bool, minRequestTimeout time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		namespace, name, err := scope.Namer.Name(req)
+		namespace, err := scope.Namer.Namespace(req)
 		if err != nil {
 			scope.err(err, w, req)
 			return
 		}
-		ctx := scope.ContextFunc(req)
-		ctx = request.WithNamespace(ctx, namespace)
 
+		// Watches for single objects are routed to this function.
+		// Treat a name parameter the same as a field selector entry.
 		hasName := true
-		_, name, err = scope.Namer.Name(req)
+		_, name, err := scope.Namer.Name(req)
 		if err != nil {
 			hasName = false
 		}
 
+		ctx := scope.ContextFunc(req)
+		ctx = request.WithNamespace(ctx, namespace)
+
 		opts := metainternalversion.ListOptions{}
 		if err := metainternalversion.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, &opts); err != nil {
 			err = errors.NewBadRequest(err.Error())
 			scope.err(err, w, req)
 			return
 		}
-		// FIXME (rantuttl): Finish implemention. ./staging/src/k8s.io/apiserver/pkg/endpoints/handlers/rest.go
-		_ = hasName
-		_ = name
 
+		// transform fields
+		if opts.FieldSelector != nil {
+			fn := func(label, value string) (newLabel, newValue string, err error) {
+				return scope.Convertor.ConvertFieldLabel(scope.Kind.GroupVersion().String(), scope.Kind.Kind, label, value)
+			}
+			if opts.FieldSelector, err = opts.FieldSelector.Transform(fn); err != nil {
+				err = errors.NewBadRequest(err.Error())
+				scope.err(err, w, req)
+				return
+			}
+		}
+
+		if hasName {
+			// metadata.name is the canonical internal name.
+			// SelectionPredicate will notice that this is
+			// a request for a single object and optimize the
+			// storage query accordingly.
+			nameSelector := fields.OneTermEqualSelector("metadata.name", name)
+			if opts.FieldSelector != nil && !opts.FieldSelector.Empty() {
+				// It doesn't make sense to ask for both a name
+				// and a field selector, since just the name is
+				// sufficient to narrow down the request to a
+				// single object.
+				scope.err(errors.NewBadRequest("both a name and a field selector provided; please provide one or the other."), w, req)
+				return
+			}
+			opts.FieldSelector = nameSelector
+		}
+
+		if (opts.Watch || forceWatch) && rw != nil {
+			if len(opts.Continue) > 0 {
+				scope.err(errors.NewBadRequest("continue is not supported for watch"), w, req)
+				return
+			}
+			// TODO: Currently we explicitly ignore ?timeout= and use only ?timeoutSeconds=.
+			timeout := time.Duration(0)
+			if opts.TimeoutSeconds != nil {
+				timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+			}
+			if timeout == 0 && minRequestTimeout > 0 {
+				// Spread the watches between minRequestTimeout and twice that, so the clients
+				// do not all come back to re-establish them at the same time.
+				timeout = time.Duration(float64(minRequestTimeout) * (rand.Float64() + 1.0))
+			}
+			ctx, cancel := withRequestTimeout(ctx, req, timeout)
+			defer cancel()
+			watcher, err := rw.Watch(ctx, &opts)
+			if err != nil {
+				scope.err(err, w, req)
+				return
+			}
+			serveWatch(watcher, scope, req, w, timeout)
+			return
+		}
+
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
+		result, err := r.List(ctx, &opts)
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
+		// Ensure empty lists return a non-nil items slice
+		if meta.IsListType(result) {
+			items, err := meta.ExtractList(result)
+			if err != nil {
+				scope.err(err, w, req)
+				return
+			}
+			if len(items) == 0 {
+				if err := meta.SetList(result, []runtime.Object{}); err != nil {
+					scope.err(err, w, req)
+					return
+				}
+			}
+		}
+		transformResponseObject(ctx, scope, req, w, http.StatusOK, result)
 	}
 }
 
 // DeleteResource returns a function that will handle a resource deletion
 func DeleteResource(r rest.GracefulDeleter, allowsOptions bool, scope RequestScope) http.HandlerFunc {
         return func(w http.ResponseWriter, req *http.Request) {
-		// TODO (rantuttl): Decide how we want to handle establishing timeout values. For now, hardcode,
-		// but could provide via the API installation, either through the group registration and/or via a default setting.
-		timeout := 30 * time.Second
+		timeout, err := parseTimeout(req.URL.Query().Get("timeout"))
+		if err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
 		namespace, name, err := scope.Namer.Name(req)
 		if err != nil {
@@ -259,11 +570,13 @@ func DeleteResource(r rest.GracefulDeleter, allowsOptions bool, scope RequestSco
 		}
 		ctx := scope.ContextFunc(req)
 		ctx = request.WithNamespace(ctx, namespace)
+		ctx, cancel := withRequestTimeout(ctx, req, timeout)
+		defer cancel()
 
 		options := &metav1.DeleteOptions{}
 		/*/ Graceful deletion support
 		if allowsOptions {
-			body, err := readBody(req)
+			body, err := readBody(req, scope.MaxRequestBodyBytes)
 			if err != nil {
 				scope.err(err, w, req)
 				return
@@ -295,6 +608,17 @@ func DeleteResource(r rest.GracefulDeleter, allowsOptions bool, scope RequestSco
 				}
 			}
 		}*/
+		if values := req.URL.Query(); len(values) > 0 {
+			if err := metainternalversion.ParameterCodec.DecodeParameters(values, scope.MetaGroupVersion, options); err != nil {
+				err = errors.NewBadRequest(err.Error())
+				scope.err(err, w, req)
+				return
+			}
+		}
+		if err := validateDryRun("DeleteOptions", options.DryRun); err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
 		// delete the object now
 		result, err := finishRequest(timeout, func() (runtime.Object, error) {
@@ -360,9 +684,121 @@ func summarizeData(data []byte, maxLength int) string {
 	}
 }
 
-func readBody(req *http.Request) ([]byte, error) {
+// readBody reads the whole request body, failing with a 413 once it grows past limit bytes.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
 	defer req.Body.Close()
-	return ioutil.ReadAll(req.Body)
+	if limit > 0 && req.ContentLength > limit {
+		return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("limit is %d", limit))
+	}
+	return ioutil.ReadAll(newLimitedReader(req.Body, limit))
+}
+
+// limitedReader reads from r until more than limit bytes have been read, after which every
+// read fails with a 413, so decoders streaming from it stop rather than buffer the rest.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func newLimitedReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	// one byte past the limit is enough to tell a body of exactly limit bytes from a larger one
+	return &limitedReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.limit {
+		return 0, errors.NewRequestEntityTooLargeError(fmt.Sprintf("limit is %d", l.limit))
+	}
+	return n, err
+}
+
+// readSingleDocument streams the YAML or JSON documents in the request body and returns the
+// only one as JSON. Empty documents are skipped, a second document is a bad request.
+func readSingleDocument(req *http.Request, limit int64) ([]byte, error) {
+	docs, err := readDocuments(req, limit)
+	if err != nil {
+		return nil, err
+	}
+	switch len(docs) {
+	case 0:
+		return nil, errors.NewBadRequest("the request body is empty")
+	case 1:
+		return docs[0], nil
+	default:
+		return nil, errors.NewBadRequest("the request body must contain a single document")
+	}
+}
+
+// readDocuments streams the YAML or JSON documents in the request body and returns each as
+// JSON, in order. Empty documents are skipped.
+func readDocuments(req *http.Request, limit int64) ([][]byte, error) {
+	defer req.Body.Close()
+	if limit > 0 && req.ContentLength > limit {
+		return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("limit is %d", limit))
+	}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(newLimitedReader(req.Body, limit), 4096)
+	docs := [][]byte{}
+	for {
+		var next json.RawMessage
+		if err := decoder.Decode(&next); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if errors.IsRequestEntityTooLargeError(err) {
+				return nil, err
+			}
+			return nil, errors.NewBadRequest(err.Error())
+		}
+		if len(next) == 0 || string(next) == "null" {
+			continue
+		}
+		docs = append(docs, next)
+	}
+	return docs, nil
+}
+
+// defaultRequestTimeout bounds the requests that do not set the timeout query parameter.
+const defaultRequestTimeout = 30 * time.Second
+
+// parseTimeout returns the duration of the timeout query parameter of a request, e.g. "10s",
+// or the default when it is not set.
+func parseTimeout(str string) (time.Duration, error) {
+	if len(str) == 0 {
+		return defaultRequestTimeout, nil
+	}
+	timeout, err := time.ParseDuration(str)
+	if err != nil || timeout <= 0 {
+		return 0, errors.NewBadRequest(fmt.Sprintf("invalid timeout %q: must be a positive duration, e.g. 10s", str))
+	}
+	return timeout, nil
+}
+
+// withRequestTimeout returns the context of a request, with the deadline of its timeout. A zero
+// timeout sets no deadline. The context is also canceled when the client goes away, so that
+// the storage makes no more CAL calls for a request nobody waits for. A CAL call already made
+// is not abandoned: that is deferred until the CAL HTTP client exists, see calHelper. The
+// returned function releases the context, it must be called once the request is served.
+func withRequestTimeout(ctx request.Context, req *http.Request, timeout time.Duration) (request.Context, func()) {
+	var cancel func()
+	if timeout > 0 {
+		ctx, cancel = request.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = request.WithCancel(ctx)
+	}
+	go func() {
+		select {
+		case <-req.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
 }
 
 // getterFunc performs a get request with the given context and object name. The request
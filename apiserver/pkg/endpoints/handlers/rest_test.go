@@ -0,0 +1,277 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+)
+
+func newBodyRequest(body string, contentLength int64) *http.Request {
+	req, _ := http.NewRequest("POST", "/api/core/v1/accounts", strings.NewReader(body))
+	req.ContentLength = contentLength
+	return req
+}
+
+func TestReadBody(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		contentLength int64
+		limit         int64
+		tooLarge      bool
+	}{
+		{name: "no limit", body: strings.Repeat("a", 64), contentLength: 64},
+		{name: "at the limit", body: strings.Repeat("a", 16), contentLength: 16, limit: 16},
+		{name: "declared too large", body: "a", contentLength: 17, limit: 16, tooLarge: true},
+		{name: "chunked too large", body: strings.Repeat("a", 17), contentLength: -1, limit: 16, tooLarge: true},
+	}
+	for _, tc := range testCases {
+		data, err := readBody(newBodyRequest(tc.body, tc.contentLength), tc.limit)
+		if tc.tooLarge {
+			if !errors.IsRequestEntityTooLargeError(err) {
+				t.Errorf("%s: expected a request entity too large error, got %v", tc.name, err)
+			}
+			if status, ok := err.(errors.APIStatus); !ok || status.Status().Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s: expected a 413 status, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if string(data) != tc.body {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.body, data)
+		}
+	}
+}
+
+func TestReadSingleDocument(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		limit    int64
+		expected string
+		tooLarge bool
+		bad      bool
+	}{
+		{name: "json", body: `{"kind":"Account"}`, expected: `{"kind":"Account"}`},
+		{name: "yaml", body: "kind: Account\nmetadata:\n  name: foo\n", expected: `{"kind":"Account","metadata":{"name":"foo"}}`},
+		{name: "yaml with separators", body: "---\nkind: Account\n---\n", expected: `{"kind":"Account"}`},
+		{name: "multiple yaml documents", body: "kind: Account\n---\nkind: Account\n", bad: true},
+		{name: "multiple json documents", body: `{"kind":"Account"} {"kind":"Account"}`, bad: true},
+		{name: "empty", body: "", bad: true},
+		{name: "too large", body: "kind: " + strings.Repeat("a", 64) + "\n", limit: 32, tooLarge: true},
+	}
+	for _, tc := range testCases {
+		data, err := readSingleDocument(newBodyRequest(tc.body, -1), tc.limit)
+		switch {
+		case tc.tooLarge:
+			if !errors.IsRequestEntityTooLargeError(err) {
+				t.Errorf("%s: expected a request entity too large error, got %v", tc.name, err)
+			}
+		case tc.bad:
+			if !errors.IsBadRequest(err) {
+				t.Errorf("%s: expected a bad request error, got %v", tc.name, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		case string(data) != tc.expected:
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, data)
+		}
+	}
+}
+
+func TestReadDocuments(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		limit    int64
+		expected []string
+		tooLarge bool
+		bad      bool
+	}{
+		{name: "single", body: `{"kind":"Account"}`, expected: []string{`{"kind":"Account"}`}},
+		{
+			name:     "yaml stream",
+			body:     "---\nkind: Account\nmetadata:\n  name: foo\n---\n---\nkind: User\nmetadata:\n  name: bar\n",
+			expected: []string{`{"kind":"Account","metadata":{"name":"foo"}}`, `{"kind":"User","metadata":{"name":"bar"}}`},
+		},
+		{name: "json stream", body: `{"kind":"Account"} {"kind":"User"}`, expected: []string{`{"kind":"Account"}`, `{"kind":"User"}`}},
+		{name: "empty", body: "---\n", expected: []string{}},
+		{name: "malformed", body: "kind: Account\n---\nkind: [User\n", bad: true},
+		{name: "too large", body: strings.Repeat("kind: Account\n---\n", 8), limit: 64, tooLarge: true},
+	}
+	for _, tc := range testCases {
+		docs, err := readDocuments(newBodyRequest(tc.body, -1), tc.limit)
+		switch {
+		case tc.tooLarge:
+			if !errors.IsRequestEntityTooLargeError(err) {
+				t.Errorf("%s: expected a request entity too large error, got %v", tc.name, err)
+			}
+			continue
+		case tc.bad:
+			if !errors.IsBadRequest(err) {
+				t.Errorf("%s: expected a bad request error, got %v", tc.name, err)
+			}
+			continue
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if len(docs) != len(tc.expected) {
+			t.Errorf("%s: expected %d documents, got %d", tc.name, len(tc.expected), len(docs))
+			continue
+		}
+		for i := range docs {
+			if string(docs[i]) != tc.expected[i] {
+				t.Errorf("%s: expected document %d to be %s, got %s", tc.name, i, tc.expected[i], docs[i])
+			}
+		}
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		bad      bool
+	}{
+		{value: "", expected: defaultRequestTimeout},
+		{value: "10s", expected: 10 * time.Second},
+		{value: "1m30s", expected: 90 * time.Second},
+		{value: "10", bad: true},
+		{value: "0s", bad: true},
+		{value: "-5s", bad: true},
+	}
+	for _, tc := range testCases {
+		timeout, err := parseTimeout(tc.value)
+		if tc.bad {
+			if !errors.IsBadRequest(err) {
+				t.Errorf("%q: expected a bad request error, got %v", tc.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.value, err)
+			continue
+		}
+		if timeout != tc.expected {
+			t.Errorf("%q: expected %v, got %v", tc.value, tc.expected, timeout)
+		}
+	}
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	req := newBodyRequest("", 0)
+	ctx, cancel := withRequestTimeout(request.NewContext(), req, 10*time.Millisecond)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatalf("expected a deadline")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the context to expire")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, got %v", ctx.Err())
+	}
+
+	// the client going away cancels a request without a deadline
+	reqCtx, clientGone := context.WithCancel(context.Background())
+	ctx, cancel = withRequestTimeout(request.NewContext(), req.WithContext(reqCtx), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("expected no deadline")
+	}
+	clientGone()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the context to be canceled")
+	}
+}
+
+// fakeRESTStorage counts the requests reaching it.
+type fakeRESTStorage struct {
+	calls int
+}
+
+func (s *fakeRESTStorage) New() runtime.Object {
+	return &core.Account{}
+}
+
+func (s *fakeRESTStorage) Create(ctx request.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	s.calls++
+	return obj, nil
+}
+
+func (s *fakeRESTStorage) Update(ctx request.Context, name string, objInfo rest.UpdatedObjectInfo, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	s.calls++
+	return nil, false, errors.NewNotFound(core.Resource("accounts"), name)
+}
+
+func (s *fakeRESTStorage) Delete(ctx request.Context, name string, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	s.calls++
+	return nil, false, errors.NewNotFound(core.Resource("accounts"), name)
+}
+
+func TestDryRunValidation(t *testing.T) {
+	storage := &fakeRESTStorage{}
+	contextFunc := func(req *http.Request) request.Context {
+		return request.WithRequestInfo(request.NewContext(), &request.RequestInfo{IsResourceRequest: true, Resource: "accounts", Name: "acme"})
+	}
+	scope := RequestScope{
+		Namer:            ContextBasedNaming{GetContext: contextFunc, ClusterScoped: true},
+		ContextFunc:      contextFunc,
+		Serializer:       api.Codecs,
+		Kind:             schema.GroupVersionKind{Group: "core", Version: "v1", Kind: "Account"},
+		MetaGroupVersion: metav1.SchemeGroupVersion,
+	}
+	body := `{"apiVersion":"core/v1","kind":"Account","metadata":{"name":"acme"}}`
+	for method, handler := range map[string]http.HandlerFunc{
+		"POST":   CreateResource(storage, scope, api.Scheme),
+		"PUT":    UpdateResource(storage, scope, api.Scheme),
+		"DELETE": DeleteResource(storage, false, scope),
+	} {
+		req := httptest.NewRequest(method, "/api/core/v1/accounts/acme?dryRun=Foo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "dryRun") {
+			t.Errorf("%s: expected dryRun=Foo to be rejected with 400, got %d %s", method, w.Code, w.Body.String())
+		}
+	}
+	if storage.calls != 0 {
+		t.Errorf("expected the storage not to be called, got %d calls", storage.calls)
+	}
+}
@@ -32,8 +32,11 @@ import (
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/conversion"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/fieldmanager"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/negotiation"
 	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
@@ -59,6 +62,17 @@ type action struct {
 	AllNamespaces	bool			// true iff the action is namespaced
 }
 
+// toDiscoveryVerb maps an action.Verb to the logical verb advertised through discovery.
+var toDiscoveryVerb = map[string]string{
+	"DELETE":	"delete",
+	"GET":		"get",
+	"LIST":		"list",
+	"PATCH":	"patch",
+	"POST":		"create",
+	"PUT":		"update",
+	"WATCH":	"watch",
+}
+
 // Installs handlers for API resources.
 func (a *APIInstaller) Install(ws *restful.WebService) (apiResources []metav1.APIResource, errors []error) {
 	errors = make([]error, 0)
@@ -133,6 +147,8 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 	defaultVersionedObject := indirectArbitraryPointer(versionedPtr)
 
 	creater, isCreater := storage.(rest.Creater)
+	updater, isUpdater := storage.(rest.Updater)
+	patcher, isPatcher := storage.(rest.Patcher)
 	lister, isLister := storage.(rest.Lister)
 	getter, isGetter := storage.(rest.Getter)
 	deleter, isDeleter := storage.(rest.Deleter)
@@ -167,7 +183,30 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 	}
 	versionedStatus := indirectArbitraryPointer(versionedStatusPtr)
 
+	var versionedCreateOptions runtime.Object
+	if isCreater {
+		versionedCreateOptions, err = a.group.Creater.New(optionsExternalVersion.WithKind("CreateOptions"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var versionedUpdateOptions runtime.Object
+	if isUpdater {
+		versionedUpdateOptions, err = a.group.Creater.New(optionsExternalVersion.WithKind("UpdateOptions"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var versionedPatchOptions runtime.Object
+	if isPatcher {
+		versionedPatchOptions, err = a.group.Creater.New(optionsExternalVersion.WithKind("PatchOptions"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var versionedList interface{}
+	var versionedListOptions runtime.Object
 	if isLister {
 		list := lister.NewList()
 		listGVKs, _, err := a.group.Typer.ObjectKinds(list)
@@ -179,6 +218,11 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 			return nil, err
 		}
 		versionedList = indirectArbitraryPointer(versionedListPtr)
+
+		versionedListOptions, err = a.group.Creater.New(optionsExternalVersion.WithKind("ListOptions"))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var ctxFn handlers.ContextFunc
@@ -228,13 +272,78 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 
 		// Add actions at the item path
 		actions = appendIf(actions, action{"GET", itemPath, nameParams, namer, false}, isGetter)
+		actions = appendIf(actions, action{"PUT", itemPath, nameParams, namer, false}, isUpdater)
+		actions = appendIf(actions, action{"PATCH", itemPath, nameParams, namer, false}, isPatcher)
 		actions = appendIf(actions, action{"DELETE", itemPath, nameParams, namer, false}, isDeleter)
 		break
-	//case meta.RESTScopeNameNamespace:
+	case meta.RESTScopeNameNamespace:
+		// Resources owned by an account live under a namespace, e.g.
+		// /api/{group}/{version}/namespaces/{namespace}/{resource}/{name}
+		namespaceParam := ws.PathParameter(scope.ArgumentName(), scope.ParamDescription()).DataType("string")
+		namespacedPath := scope.ParamName() + "/{" + scope.ArgumentName() + "}/" + resource
+		namespaceParams := []*restful.Parameter{namespaceParam}
+
+		resourcePath := namespacedPath
+		resourceParams := namespaceParams
+		itemPath := namespacedPath + "/{name}"
+		nameParams := append(namespaceParams, nameParam)
+		itemPathSuffix := ""
+		if hasSubresource {
+			itemPathSuffix = "/" + subresource
+			itemPath = itemPath + itemPathSuffix
+			resourcePath = itemPath
+			resourceParams = nameParams
+		}
+		apiResource.Name = path
+		apiResource.Namespaced = true
+		apiResource.Kind = resourceKind
+		namer := handlers.ContextBasedNaming{
+			GetContext:		ctxFn,
+			SelfLinker:		a.group.Linker,
+			ClusterScoped:		false,
+			SelfLinkPathPrefix:	gpath.Join(a.prefix, scope.ParamName()) + "/",
+			SelfLinkPathSuffix:	itemPathSuffix,
+		}
+
+		// Add actions at the resource path
+		actions = appendIf(actions, action{"LIST", resourcePath, resourceParams, namer, false}, isLister)
+		actions = appendIf(actions, action{"POST", resourcePath, resourceParams, namer, false}, isCreater)
+
+		// Add actions at the item path
+		actions = appendIf(actions, action{"GET", itemPath, nameParams, namer, false}, isGetter)
+		actions = appendIf(actions, action{"PUT", itemPath, nameParams, namer, false}, isUpdater)
+		actions = appendIf(actions, action{"PATCH", itemPath, nameParams, namer, false}, isPatcher)
+		actions = appendIf(actions, action{"DELETE", itemPath, nameParams, namer, false}, isDeleter)
+
+		// list or watch across all namespaces, e.g. LIST all users of every account with
+		// a request at /api/{group}/{version}/users
+		if !hasSubresource {
+			actions = appendIf(actions, action{"LIST", resource, params, namer, true}, isLister)
+		}
+		break
 	default:
 		return nil, fmt.Errorf("unsupported REST scope: %s", scope.Name())
 	}
 
+	// Advertise the verbs, names and categories of the resource through discovery
+	verbs := sets.NewString()
+	for _, action := range actions {
+		if verb, found := toDiscoveryVerb[action.Verb]; found {
+			verbs.Insert(verb)
+		}
+		if action.Verb == "LIST" && watcher != nil {
+			verbs.Insert(toDiscoveryVerb["WATCH"])
+		}
+	}
+	apiResource.Verbs = verbs.List()
+	apiResource.SingularName = strings.ToLower(resourceKind)
+	if shortNamesProvider, ok := storage.(rest.ShortNamesProvider); ok {
+		apiResource.ShortNames = shortNamesProvider.ShortNames()
+	}
+	if categoriesProvider, ok := storage.(rest.CategoriesProvider); ok {
+		apiResource.Categories = categoriesProvider.Categories()
+	}
+
 	mediaTypes, streamMediaTypes := negotiation.MediaTypesForSerializer(a.group.Serializer)
 	allMediaTypes := append(mediaTypes, streamMediaTypes...)
 	ws.Produces(allMediaTypes...)
@@ -253,6 +362,34 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 		Resource:		a.group.GroupVersion.WithResource(resource),
 		Subresource:		subresource,
 		Kind:			fqKindToRegister,
+
+		MaxRequestBodyBytes:	a.group.MaxRequestBodyBytes,
+		ShutdownCh:		a.group.ShutdownCh,
+
+		MetaGroupVersion:	metav1.SchemeGroupVersion,
+	}
+	if tableConvertor, ok := storage.(rest.TableConvertor); ok {
+		reqScope.TableConvertor = tableConvertor
+	} else {
+		reqScope.TableConvertor = rest.NewDefaultTableConvertor(a.group.GroupVersion.WithResource(resource).GroupResource())
+	}
+	// Field ownership is tracked for objects with metadata, changed through the resource itself
+	if _, err := meta.Accessor(storage.New()); err == nil && !hasSubresource {
+		reqScope.FieldManager = fieldmanager.NewFieldManager(
+			a.group.Convertor,
+			a.group.Creater,
+			a.group.Copier,
+			fqKindToRegister,
+			schema.GroupVersion{Group: fqKindToRegister.Group, Version: runtime.APIVersionInternal},
+		)
+		// objects of the resource can be applied in bulk
+		if isPatcher && a.group.BulkRegistry != nil {
+			a.group.BulkRegistry.add(a.group.Mapper, &handlers.BulkTarget{
+				Storage:    patcher,
+				Scope:      reqScope,
+				Namespaced: scope.Name() == meta.RESTScopeNameNamespace,
+			})
+		}
 	}
 
 
@@ -299,6 +436,45 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 				Writes(versionedObject)
 			addParams(route, action.Params)
 			routes = append(routes, route)
+		case "PUT": // Update a resource
+			var handler restful.RouteFunction
+
+			handler = restfulUpdateResource(updater, reqScope, a.group.Typer)
+			doc := "replace the specified " + resourceKind
+
+			route := ws.PUT(action.Path).To(handler).
+				Doc(doc).
+				Param(ws.QueryParameter("pretty", "If 'true', then the output is pretty printed.")).
+				Operation("replace"+namespaced+resourceKind+strings.Title(subresource)+operationSuffix).
+				Produces(append(storageMeta.ProducesMIMETypes(action.Verb), mediaTypes...)...).
+				Returns(http.StatusOK, "OK", versionedObject).
+				Reads(versionedObject).
+				Writes(versionedObject)
+			if err := addObjectParams(ws, route, versionedUpdateOptions); err != nil {
+				return nil, err
+			}
+			addParams(route, action.Params)
+			routes = append(routes, route)
+		case "PATCH": // Partially update a resource
+			var handler restful.RouteFunction
+
+			handler = restfulPatchResource(patcher, reqScope)
+			doc := "apply changes to the specified " + resourceKind
+
+			route := ws.PATCH(action.Path).To(handler).
+				Doc(doc).
+				Param(ws.QueryParameter("pretty", "If 'true', then the output is pretty printed.")).
+				Consumes(string(types.ApplyPatchType)).
+				Operation("patch"+namespaced+resourceKind+strings.Title(subresource)+operationSuffix).
+				Produces(append(storageMeta.ProducesMIMETypes(action.Verb), mediaTypes...)...).
+				Returns(http.StatusOK, "OK", versionedObject).
+				Reads(versionedObject).
+				Writes(versionedObject)
+			if err := addObjectParams(ws, route, versionedPatchOptions); err != nil {
+				return nil, err
+			}
+			addParams(route, action.Params)
+			routes = append(routes, route)
 		case "DELETE": // Delete a resource
 			var handler restful.RouteFunction
 
@@ -335,23 +511,32 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 				Returns(http.StatusOK, "OK", versionedObject).
 				Reads(versionedObject).
 				Writes(versionedObject)
+			if err := addObjectParams(ws, route, versionedCreateOptions); err != nil {
+				return nil, err
+			}
 			addParams(route, action.Params)
 			routes = append(routes, route)
 		case "LIST":
 			var handler restful.RouteFunction
 
-			// FIXME (rantuttl): Fix up for watcher later, especially docs and subresources.
+			// FIXME (rantuttl): Fix up docs for subresources later.
 			handler = restfulListResource(lister, watcher, reqScope, false, a.minRequestTimeout)
 			doc := "list objects of kind " + resourceKind
+			if watcher != nil {
+				doc = "list or watch objects of kind " + resourceKind
+			}
 
 			route := ws.GET(action.Path).To(handler).
 				Doc(doc).
 				Param(ws.QueryParameter("pretty", "If 'true', then the output is pretty printed.")).
 				Operation("list"+namespaced+resourceKind+strings.Title(subresource)+operationSuffix).
-				Produces(append(storageMeta.ProducesMIMETypes(action.Verb), mediaTypes...)...).
+				Produces(append(storageMeta.ProducesMIMETypes(action.Verb), allMediaTypes...)...).
 				Returns(http.StatusOK, "OK", versionedList).
 				Reads(versionedList).
 				Writes(versionedList)
+			if err := addObjectParams(ws, route, versionedListOptions); err != nil {
+				return nil, err
+			}
 			addParams(route, action.Params)
 			routes = append(routes, route)
 
@@ -536,6 +721,18 @@ func restfulCreateResource(r rest.Creater, scope handlers.RequestScope, typer ru
 	}
 }
 
+func restfulUpdateResource(r rest.Updater, scope handlers.RequestScope, typer runtime.ObjectTyper) restful.RouteFunction {
+	return func(req *restful.Request, res *restful.Response) {
+		handlers.UpdateResource(r, scope, typer)(res.ResponseWriter, req.Request)
+	}
+}
+
+func restfulPatchResource(r rest.Patcher, scope handlers.RequestScope) restful.RouteFunction {
+	return func(req *restful.Request, res *restful.Response) {
+		handlers.PatchResource(r, scope)(res.ResponseWriter, req.Request)
+	}
+}
+
 func restfulGetResource(r rest.Getter, e rest.Exporter, scope handlers.RequestScope) restful.RouteFunction {
 	return func(req *restful.Request, res *restful.Response) {
 		handlers.GetResource(r, e, scope)(res.ResponseWriter, req.Request)
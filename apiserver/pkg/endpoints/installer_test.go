@@ -0,0 +1,222 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package endpoints
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/conversion"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
+	corev1 "github.com/rantuttl/cloudops/apiserver/pkg/api/core/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+)
+
+// fakeUserStorage serves users, and records the namespace of each call it gets.
+type fakeUserStorage struct {
+	namespaces []string
+}
+
+func (s *fakeUserStorage) record(ctx request.Context) {
+	s.namespaces = append(s.namespaces, request.NamespaceValue(ctx))
+}
+
+func (s *fakeUserStorage) New() runtime.Object {
+	return &core.User{}
+}
+
+func (s *fakeUserStorage) NewList() runtime.Object {
+	return &core.UserList{}
+}
+
+func (s *fakeUserStorage) Get(ctx request.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	s.record(ctx)
+	return &core.User{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: request.NamespaceValue(ctx)}}, nil
+}
+
+func (s *fakeUserStorage) List(ctx request.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	s.record(ctx)
+	return &core.UserList{}, nil
+}
+
+func (s *fakeUserStorage) Watch(ctx request.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	s.record(ctx)
+	w := watch.NewFake()
+	w.Stop()
+	return w, nil
+}
+
+func (s *fakeUserStorage) Create(ctx request.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	s.record(ctx)
+	return obj, nil
+}
+
+func (s *fakeUserStorage) Delete(ctx request.Context, name string, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	s.record(ctx)
+	return &metav1.Status{Status: metav1.StatusSuccess}, true, nil
+}
+
+// newUserGroupVersion returns the core/v1 group version serving users from storage, with a
+// scheme and RESTMapper of its own since users are not registered with the server's.
+func newUserGroupVersion(storage *fakeUserStorage, mapper request.RequestContextMapper) *APIGroupVersion {
+	scheme := runtime.NewScheme()
+	if err := core.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	scheme.AddKnownTypes(core.SchemeGroupVersion, &core.User{}, &core.UserList{})
+	scheme.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.User{}, &corev1.UserList{})
+	if err := scheme.AddConversionFuncs(
+		func(in *core.User, out *corev1.User, s conversion.Scope) error {
+			out.ObjectMeta = in.ObjectMeta
+			out.Spec = corev1.UserSpec(in.Spec)
+			return nil
+		},
+		func(in *corev1.User, out *core.User, s conversion.Scope) error {
+			out.ObjectMeta = in.ObjectMeta
+			out.Spec = core.UserSpec(in.Spec)
+			return nil
+		},
+		func(in *core.UserList, out *corev1.UserList, s conversion.Scope) error {
+			out.ListMeta = in.ListMeta
+			return nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion}, func(version schema.GroupVersion) (*meta.VersionInterfaces, error) {
+		return &meta.VersionInterfaces{ObjectConvertor: scheme, MetadataAccessor: meta.NewAccessor()}, nil
+	})
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("User"), meta.RESTScopeNamespace)
+
+	return &APIGroupVersion{
+		Root:         "/api",
+		Storage:      map[string]rest.Storage{"users": storage},
+		GroupVersion: corev1.SchemeGroupVersion,
+		Mapper:       restMapper,
+		Serializer:   serializer.NewCodecFactory(scheme),
+		Typer:        scheme,
+		Creater:      scheme,
+		Copier:       scheme,
+		Convertor:    scheme,
+		Defaulter:    scheme,
+		Linker:       meta.NewAccessor(),
+		Context:      mapper,
+	}
+}
+
+func TestInstallNamespacedResource(t *testing.T) {
+	storage := &fakeUserStorage{}
+	mapper := request.NewRequestContextMapper()
+	container := restful.NewContainer()
+	if err := newUserGroupVersion(storage, mapper).InstallREST(container); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	operations := map[string]string{}
+	for _, ws := range container.RegisteredWebServices() {
+		for _, route := range ws.Routes() {
+			operations[route.Method+" "+route.Path] = route.Operation
+		}
+	}
+	expected := map[string]string{
+		"GET /api/core/v1/namespaces/{namespace}/users":           "listNamespacedUser",
+		"POST /api/core/v1/namespaces/{namespace}/users":          "createNamespacedUser",
+		"GET /api/core/v1/namespaces/{namespace}/users/{name}":    "readNamespacedUser",
+		"DELETE /api/core/v1/namespaces/{namespace}/users/{name}": "deleteNamespacedUser",
+		"GET /api/core/v1/users":                                  "listUserForAllNamespaces",
+	}
+	for route, operation := range expected {
+		if operations[route] != operation {
+			t.Errorf("expected route %s to be operation %q, got %q", route, operation, operations[route])
+		}
+	}
+	for route := range operations {
+		// the group version is discovered at its root
+		if _, ok := expected[route]; !ok && route != "GET /api/core/v1/" {
+			t.Errorf("unexpected route %s", route)
+		}
+	}
+
+	resolver := &request.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	// A watch needs a real connection to be served.
+	server := httptest.NewServer(request.WithRequestContext(filters.WithRequestInfo(container, resolver, mapper), mapper))
+	defer server.Close()
+	for _, test := range []struct {
+		method    string
+		path      string
+		namespace string
+	}{
+		{"GET", "/api/core/v1/namespaces/acme/users/alice", "acme"},
+		{"GET", "/api/core/v1/namespaces/acme/users", "acme"},
+		{"DELETE", "/api/core/v1/namespaces/acme/users/alice", "acme"},
+		{"GET", "/api/core/v1/users", ""},
+		{"GET", "/api/core/v1/users?watch=true", ""},
+	} {
+		storage.namespaces = nil
+		req, _ := http.NewRequest(test.method, server.URL+test.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %v", test.method, test.path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s %s: expected 200, got %d %s", test.method, test.path, resp.StatusCode, body)
+			continue
+		}
+		if len(storage.namespaces) != 1 || storage.namespaces[0] != test.namespace {
+			t.Errorf("%s %s: expected the storage to be called in namespace %q, got %v", test.method, test.path, test.namespace, storage.namespaces)
+		}
+	}
+}
+
+func TestInstallNamespacedResourceDiscovery(t *testing.T) {
+	apiResources, errs := newUserGroupVersion(&fakeUserStorage{}, request.NewRequestContextMapper()).newInstaller().Install(new(restful.WebService))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(apiResources) != 1 {
+		t.Fatalf("expected one resource, got %v", apiResources)
+	}
+	resource := apiResources[0]
+	verbs := append([]string{}, resource.Verbs...)
+	sort.Strings(verbs)
+	if resource.Name != "users" || !resource.Namespaced || resource.Kind != "User" {
+		t.Errorf("unexpected resource %#v", resource)
+	}
+	if expected := []string{"create", "delete", "get", "list", "watch"}; !reflect.DeepEqual(verbs, expected) {
+		t.Errorf("expected verbs %v, got %v", expected, verbs)
+	}
+}
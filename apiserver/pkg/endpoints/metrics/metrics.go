@@ -0,0 +1,238 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package metrics holds the Prometheus metrics of the API requests the server serves.
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+const (
+	// ReadOnlyKind and MutatingKind are the kinds of requests in flight.
+	ReadOnlyKind = "readOnly"
+	MutatingKind = "mutating"
+)
+
+var (
+	requestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "apiserver_request_total",
+			Help: "Counter of apiserver requests broken out for each verb, API resource, subresource, scope and HTTP response code.",
+		},
+		[]string{"verb", "resource", "subresource", "scope", "code"},
+	)
+	requestLatencies = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "apiserver_request_duration_seconds",
+			Help: "Response latency distribution in seconds for each verb, API resource, subresource and scope.",
+			// From 5ms to 60s, long-running requests are not observed.
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"verb", "resource", "subresource", "scope"},
+	)
+	responseSizes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "apiserver_response_sizes",
+			Help: "Response size distribution in bytes for each verb, API resource, subresource and scope.",
+			// Use buckets ranging from 1000 bytes (1KB) to 10^9 bytes (1GB).
+			Buckets: prometheus.ExponentialBuckets(1000, 10.0, 7),
+		},
+		[]string{"verb", "resource", "subresource", "scope"},
+	)
+	currentInflightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "apiserver_current_inflight_requests",
+			Help: "Number of requests currently being served, by kind of request, long-running requests excluded.",
+		},
+		[]string{"request_kind"},
+	)
+	longRunningRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "apiserver_longrunning_requests",
+			Help: "Number of long-running requests, e.g. watches, currently open, by verb, API resource, subresource and scope.",
+		},
+		[]string{"verb", "resource", "subresource", "scope"},
+	)
+
+	authenticationAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authentication_attempts",
+			Help: "Counter of authenticated requests broken out by result: success, failure or error.",
+		},
+		[]string{"result"},
+	)
+	authorizationDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authorization_decisions",
+			Help: "Counter of authorization decisions broken out by decision: allowed, denied or error.",
+		},
+		[]string{"decision"},
+	)
+
+	// validVerbs bounds the verb label, as the verb of non-resource requests is the HTTP method.
+	validVerbs = sets.NewString("GET", "LIST", "WATCH", "CREATE", "UPDATE", "PATCH", "DELETE", "DELETECOLLECTION", "POST", "PUT", "HEAD", "OPTIONS", "CONNECT", "PROXY")
+)
+
+var registerMetrics sync.Once
+
+// Register all metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(requestCounter)
+		prometheus.MustRegister(requestLatencies)
+		prometheus.MustRegister(responseSizes)
+		prometheus.MustRegister(currentInflightRequests)
+		prometheus.MustRegister(longRunningRequests)
+		prometheus.MustRegister(authenticationAttempts)
+		prometheus.MustRegister(authorizationDecisions)
+	})
+}
+
+// Labels are the labels a request is recorded under.
+type Labels struct {
+	Verb        string
+	Resource    string
+	Subresource string
+	Scope       string
+}
+
+// LabelsFor returns the labels of the request described by requestInfo.
+func LabelsFor(requestInfo *request.RequestInfo) Labels {
+	verb := strings.ToUpper(requestInfo.Verb)
+	if !validVerbs.Has(verb) {
+		verb = "other"
+	}
+	if !requestInfo.IsResourceRequest {
+		return Labels{Verb: verb}
+	}
+	return Labels{
+		Verb:        verb,
+		Resource:    requestInfo.Resource,
+		Subresource: requestInfo.Subresource,
+		Scope:       scopeFor(requestInfo),
+	}
+}
+
+// scopeFor tells whether the request is for a single object, a namespace, or the cluster.
+func scopeFor(requestInfo *request.RequestInfo) string {
+	switch {
+	case len(requestInfo.Name) > 0:
+		return "resource"
+	case len(requestInfo.Namespace) > 0:
+		return "namespace"
+	}
+	return "cluster"
+}
+
+// Monitor records a completed request.
+func Monitor(labels Labels, httpCode, respSize int, elapsed time.Duration) {
+	requestCounter.WithLabelValues(labels.Verb, labels.Resource, labels.Subresource, labels.Scope, codeToString(httpCode)).Inc()
+	requestLatencies.WithLabelValues(labels.Verb, labels.Resource, labels.Subresource, labels.Scope).Observe(elapsed.Seconds())
+	// We are only interested in response sizes of read requests.
+	if labels.Verb == "GET" || labels.Verb == "LIST" {
+		responseSizes.WithLabelValues(labels.Verb, labels.Resource, labels.Subresource, labels.Scope).Observe(float64(respSize))
+	}
+}
+
+// MonitorLongRunning records a long-running request as open until the returned func is called,
+// and its code once done.
+func MonitorLongRunning(labels Labels) func(httpCode int) {
+	gauge := longRunningRequests.WithLabelValues(labels.Verb, labels.Resource, labels.Subresource, labels.Scope)
+	gauge.Inc()
+	return func(httpCode int) {
+		gauge.Dec()
+		requestCounter.WithLabelValues(labels.Verb, labels.Resource, labels.Subresource, labels.Scope, codeToString(httpCode)).Inc()
+	}
+}
+
+// MonitorInflight records a request of kind as in flight until the returned func is called.
+func MonitorInflight(kind string) func() {
+	gauge := currentInflightRequests.WithLabelValues(kind)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// RecordAuthentication records the result of authenticating a request: "success", "failure"
+// when no authenticator recognized the request, or "error".
+func RecordAuthentication(result string) {
+	authenticationAttempts.WithLabelValues(result).Inc()
+}
+
+// RecordAuthorization records an authorization decision: "allowed", "denied" or "error".
+func RecordAuthorization(decision string) {
+	authorizationDecisions.WithLabelValues(decision).Inc()
+}
+
+// Small optimization over Itoa
+func codeToString(s int) string {
+	switch s {
+	case 100:
+		return "100"
+	case 101:
+		return "101"
+
+	case 200:
+		return "200"
+	case 201:
+		return "201"
+	case 202:
+		return "202"
+	case 204:
+		return "204"
+
+	case 304:
+		return "304"
+
+	case 400:
+		return "400"
+	case 401:
+		return "401"
+	case 403:
+		return "403"
+	case 404:
+		return "404"
+	case 405:
+		return "405"
+	case 409:
+		return "409"
+	case 413:
+		return "413"
+	case 415:
+		return "415"
+	case 422:
+		return "422"
+	case 429:
+		return "429"
+
+	case 500:
+		return "500"
+	case 503:
+		return "503"
+	case 504:
+		return "504"
+
+	default:
+		return strconv.Itoa(s)
+	}
+}
@@ -21,8 +21,7 @@ import (
 
 	"golang.org/x/net/context"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
-	// FIXME (rantuttl): Figure out how to use this. In the meantime, comment
-	//"k8s.io/apiserver/pkg/apis/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
 )
 
@@ -67,6 +66,9 @@ const (
 	// auditKey is the context key for the audit event.
 	auditKey
 
+	// requestIDKey is the context key for the request ID.
+	requestIDKey
+
 	namespaceDefault = "default" // TODO(sttts): solve import cycle when using metav1.NamespaceDefault
 )
 
@@ -89,6 +91,26 @@ func WithValue(parent Context, key interface{}, val interface{}) Context {
 	return context.WithValue(internalCtx, key, val)
 }
 
+// WithTimeout returns a copy of parent that is canceled once timeout has elapsed, and the
+// function that cancels it sooner. The function must be called when the work is done.
+func WithTimeout(parent Context, timeout time.Duration) (Context, context.CancelFunc) {
+	internalCtx, ok := parent.(context.Context)
+	if !ok {
+		panic(stderrs.New("Invalid context type"))
+	}
+	return context.WithTimeout(internalCtx, timeout)
+}
+
+// WithCancel returns a copy of parent with no deadline of its own, and the function that
+// cancels it. The function must be called when the work is done.
+func WithCancel(parent Context) (Context, context.CancelFunc) {
+	internalCtx, ok := parent.(context.Context)
+	if !ok {
+		panic(stderrs.New("Invalid context type"))
+	}
+	return context.WithCancel(internalCtx)
+}
+
 // WithNamespace returns a copy of parent in which the namespace value is set
 func WithNamespace(parent Context, namespace string) Context {
 	return WithValue(parent, namespaceKey, namespace)
@@ -148,9 +170,7 @@ func UserAgentFrom(ctx Context) (string, bool) {
 	return userAgent, ok
 }
 
-// FIXME (rantuttl): Figure out how to use this. In the meantime, comment
 // WithAuditEvent returns set audit event struct.
-/*
 func WithAuditEvent(parent Context, ev *audit.Event) Context {
 	return WithValue(parent, auditKey, ev)
 }
@@ -160,4 +180,18 @@ func AuditEventFrom(ctx Context) *audit.Event {
 	ev, _ := ctx.Value(auditKey).(*audit.Event)
 	return ev
 }
-*/
+
+// RequestIDHeader is the header a client sets to choose the ID of its request, and the
+// server returns the ID in.
+const RequestIDHeader = "X-Request-Id"
+
+// WithRequestID returns a copy of parent in which the request ID value is set
+func WithRequestID(parent Context, requestID string) Context {
+	return WithValue(parent, requestIDKey, requestID)
+}
+
+// RequestIDFrom returns the value of the request ID key on the ctx
+func RequestIDFrom(ctx Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey).(string)
+	return requestID, ok
+}
@@ -0,0 +1,130 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+
+	auditlog "github.com/rantuttl/cloudops/apiserver/pkg/audit/log"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/httplog"
+)
+
+// AccessLogOptions configures the line logged for every request.
+type AccessLogOptions struct {
+	Format string
+	// Path is the file the json and logfmt lines are written to, '-' means standard out.
+	Path       string
+	SampleRate float64
+	// Verbosity is the glog verbosity text lines are logged at, StatusVerbosity overrides it
+	// by status. Json and logfmt lines are written unless a status rule is above it.
+	Verbosity       int
+	StatusVerbosity []string
+}
+
+func NewAccessLogOptions() *AccessLogOptions {
+	defaults := httplog.NewDefaultConfig()
+	return &AccessLogOptions{
+		Format:     string(defaults.Format),
+		Path:       "-",
+		SampleRate: defaults.SampleRate,
+		Verbosity:  int(defaults.Verbosity),
+	}
+}
+
+func (o *AccessLogOptions) Validate() []error {
+	allErrors := []error{}
+	if !validFormat(o.Format) {
+		allErrors = append(allErrors, fmt.Errorf("--access-log-format must be one of %s", formatNames()))
+	}
+	if len(o.Path) == 0 {
+		allErrors = append(allErrors, fmt.Errorf("--access-log-path must be set, '-' means standard out"))
+	}
+	if o.SampleRate < 0 || o.SampleRate > 1 {
+		allErrors = append(allErrors, fmt.Errorf("--access-log-sample-rate must be between 0 and 1"))
+	}
+	if o.Verbosity < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--access-log-verbosity must not be negative"))
+	}
+	if _, err := httplog.ParseStatusVerbosity(o.StatusVerbosity); err != nil {
+		allErrors = append(allErrors, fmt.Errorf("--access-log-status-verbosity: %v", err))
+	}
+	return allErrors
+}
+
+func (o *AccessLogOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Format, "access-log-format", o.Format, ""+
+		"Format of the line logged for every request, one of "+formatNames()+". The json and "+
+		"logfmt formats hold the request ID, user, verb, resource, name, status, latency, bytes "+
+		"written, user agent and remote IP of the request, and are written to --access-log-path. "+
+		"Text lines are written to the server log.")
+	fs.StringVar(&o.Path, "access-log-path", o.Path, ""+
+		"File the json and logfmt lines are written to, one per request. '-' means standard out.")
+	fs.Float64Var(&o.SampleRate, "access-log-sample-rate", o.SampleRate, ""+
+		"Fraction, between 0 and 1, of the requests answered with a status below 400 that are logged. "+
+		"Requests answered with an error status are always logged.")
+	fs.IntVar(&o.Verbosity, "access-log-verbosity", o.Verbosity, ""+
+		"Log verbosity (--v) text lines are logged at. The json and logfmt lines do not depend on --v, "+
+		"they are written for every request but those --access-log-status-verbosity sets above it.")
+	fs.StringSliceVar(&o.StatusVerbosity, "access-log-status-verbosity", o.StatusVerbosity, ""+
+		"List of <status>=<verbosity> rules overriding --access-log-verbosity for the requests answered "+
+		"with status, comma separated. Status is a code, e.g. 404, or a class, e.g. 5xx; codes take "+
+		"precedence over classes.")
+}
+
+func (o *AccessLogOptions) ApplyTo(c *server.Config) error {
+	statusVerbosity, err := httplog.ParseStatusVerbosity(o.StatusVerbosity)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = os.Stdout
+	if o.Path != "-" && o.Format != string(httplog.FormatText) {
+		out, err = auditlog.NewRotatingFile(o.Path, 0, 0, 0)
+		if err != nil {
+			return fmt.Errorf("unable to open the access log: %v", err)
+		}
+	}
+	c.AccessLogConfig = &httplog.Config{
+		Format:          httplog.Format(o.Format),
+		Out:             out,
+		SampleRate:      o.SampleRate,
+		Verbosity:       glog.Level(o.Verbosity),
+		StatusVerbosity: statusVerbosity,
+	}
+	return nil
+}
+
+func validFormat(format string) bool {
+	for _, f := range httplog.Formats {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNames() string {
+	names := []string{}
+	for _, f := range httplog.Formats {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,139 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	auditlog "github.com/rantuttl/cloudops/apiserver/pkg/audit/log"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit/policy"
+	auditwebhook "github.com/rantuttl/cloudops/apiserver/pkg/audit/webhook"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
+)
+
+// AuditOptions configures the audit policy and the sinks audit events are sent to.
+type AuditOptions struct {
+	// PolicyFile is the audit policy selecting the requests audited, and at which level.
+	PolicyFile string
+
+	LogOptions     AuditLogOptions
+	WebhookOptions AuditWebhookOptions
+}
+
+// AuditLogOptions configures the audit log file, written as JSON lines.
+type AuditLogOptions struct {
+	Path       string
+	MaxAge     int
+	MaxBackups int
+	MaxSize    int
+}
+
+// AuditWebhookOptions configures the webhook audit events are sent to in batches.
+type AuditWebhookOptions struct {
+	URL          string
+	BatchMaxSize int
+	BatchMaxWait time.Duration
+}
+
+func NewAuditOptions() *AuditOptions {
+	return &AuditOptions{
+		WebhookOptions: AuditWebhookOptions{
+			BatchMaxSize: auditwebhook.DefaultBatchMaxSize,
+			BatchMaxWait: auditwebhook.DefaultBatchMaxWait,
+		},
+	}
+}
+
+func (o *AuditOptions) Validate() []error {
+	allErrors := []error{}
+	if len(o.PolicyFile) == 0 && (len(o.LogOptions.Path) > 0 || len(o.WebhookOptions.URL) > 0) {
+		allErrors = append(allErrors, fmt.Errorf("--audit-policy-file must be specified to audit requests"))
+	}
+	if o.LogOptions.MaxAge < 0 || o.LogOptions.MaxBackups < 0 || o.LogOptions.MaxSize < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--audit-log-maxage, --audit-log-maxbackup and --audit-log-maxsize must not be negative"))
+	}
+	if o.WebhookOptions.BatchMaxSize <= 0 || o.WebhookOptions.BatchMaxWait <= 0 {
+		allErrors = append(allErrors, fmt.Errorf("--audit-webhook-batch-max-size and --audit-webhook-batch-max-wait must be positive"))
+	}
+	return allErrors
+}
+
+func (o *AuditOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.PolicyFile, "audit-policy-file", o.PolicyFile, ""+
+		"Path to the file, in YAML or JSON, holding the audit policy. The policy selects the level, "+
+		"by user, group and resource, requests are audited at. Requests are not audited without it.")
+
+	fs.StringVar(&o.LogOptions.Path, "audit-log-path", o.LogOptions.Path, ""+
+		"If set, all requests selected by the audit policy are logged to this file as JSON lines. "+
+		"'-' means standard out.")
+	fs.IntVar(&o.LogOptions.MaxAge, "audit-log-maxage", o.LogOptions.MaxAge, ""+
+		"The maximum number of days to retain old audit log files.")
+	fs.IntVar(&o.LogOptions.MaxBackups, "audit-log-maxbackup", o.LogOptions.MaxBackups, ""+
+		"The maximum number of old audit log files to retain.")
+	fs.IntVar(&o.LogOptions.MaxSize, "audit-log-maxsize", o.LogOptions.MaxSize, ""+
+		"The maximum size in megabytes of the audit log file before it gets rotated. Zero for no rotation.")
+
+	fs.StringVar(&o.WebhookOptions.URL, "audit-webhook-url", o.WebhookOptions.URL, ""+
+		"If set, audit events are POSTed in batches to this URL.")
+	fs.IntVar(&o.WebhookOptions.BatchMaxSize, "audit-webhook-batch-max-size", o.WebhookOptions.BatchMaxSize, ""+
+		"The maximum number of audit events sent to the webhook in one request.")
+	fs.DurationVar(&o.WebhookOptions.BatchMaxWait, "audit-webhook-batch-max-wait", o.WebhookOptions.BatchMaxWait, ""+
+		"The maximum time an audit event waits for its batch to fill up before it is sent to the webhook.")
+}
+
+func (o *AuditOptions) ApplyTo(c *server.Config) error {
+	if len(o.PolicyFile) == 0 {
+		return nil
+	}
+	p, err := policy.LoadPolicyFromFile(o.PolicyFile)
+	if err != nil {
+		return err
+	}
+
+	var backends []audit.Backend
+	if len(o.LogOptions.Path) > 0 {
+		var w io.Writer = os.Stdout
+		if o.LogOptions.Path != "-" {
+			w, err = auditlog.NewRotatingFile(o.LogOptions.Path, o.LogOptions.MaxSize, o.LogOptions.MaxBackups, o.LogOptions.MaxAge)
+			if err != nil {
+				return fmt.Errorf("unable to open the audit log: %v", err)
+			}
+		}
+		backends = append(backends, auditlog.NewBackend(w))
+	}
+	if len(o.WebhookOptions.URL) > 0 {
+		backend, err := auditwebhook.NewBackend(o.WebhookOptions.URL, o.WebhookOptions.BatchMaxSize, o.WebhookOptions.BatchMaxWait)
+		if err != nil {
+			return err
+		}
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
+		glog.Warningf("No audit sink is configured, requests are not audited")
+		return nil
+	}
+
+	c.AuditPolicyChecker = policy.NewChecker(p)
+	c.AuditBackend = audit.Union(backends...)
+	return nil
+}
@@ -17,11 +17,34 @@ package options
 
 import (
 	"github.com/spf13/pflag"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/dynamiccertificates"
 )
 
 type ClientCertAuthenticationOptions struct {
 	// ClientCA is the certificate bundle for all the signers that you'll recognize for incoming client certificates
 	ClientCA string
+
+	// caContentProvider is shared by the TLS config and the authenticator, so that both see
+	// the rotations of ClientCA.
+	caContentProvider dynamiccertificates.CAContentProvider
+}
+
+// GetClientCAContentProvider returns the provider of the ClientCA bundle, reloaded when the file
+// changes, or nil if ClientCA is not set. The same provider is returned on every call.
+func (s *ClientCertAuthenticationOptions) GetClientCAContentProvider() (dynamiccertificates.CAContentProvider, error) {
+	if s == nil || len(s.ClientCA) == 0 {
+		return nil, nil
+	}
+	if s.caContentProvider != nil {
+		return s.caContentProvider, nil
+	}
+	provider, err := dynamiccertificates.NewDynamicCAContentFromFile("client-ca-bundle", s.ClientCA)
+	if err != nil {
+		return nil, err
+	}
+	s.caContentProvider = provider
+	return provider, nil
 }
 
 func (s *ClientCertAuthenticationOptions) AddFlags(fs *pflag.FlagSet) {
@@ -18,11 +18,15 @@ package options
 import (
 	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 
 	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
+	genericfilters "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/filters"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/flowcontrol"
 
 	// add the generic feature gates
 	//_ "k8s.io/apiserver/pkg/features"
@@ -33,10 +37,17 @@ type ServerRunOptions struct {
 	AdvertiseAddress net.IP
 
 	CorsAllowedOriginList       []string
+	CorsAllowedMethods          []string
+	CorsAllowedHeaders          []string
+	CorsExposedHeaders          []string
 	ExternalHost                string
 	MaxRequestsInFlight         int
 	MaxMutatingRequestsInFlight int
 	MinRequestTimeout           int
+	MaxRequestBodyBytes         int64
+	FlowControlConfigFile       string
+	ShutdownDelayDuration       time.Duration
+	ShutdownTimeout             time.Duration
 	TargetRAMMB                 int
 	WatchCacheSizes             []string
 }
@@ -47,23 +58,57 @@ func NewServerRunOptions() *ServerRunOptions {
 		MaxRequestsInFlight:         defaults.MaxRequestsInFlight,
 		MaxMutatingRequestsInFlight: defaults.MaxMutatingRequestsInFlight,
 		MinRequestTimeout:           defaults.MinRequestTimeout,
+		MaxRequestBodyBytes:         defaults.MaxRequestBodyBytes,
+		ShutdownDelayDuration:       defaults.ShutdownDelayDuration,
+		ShutdownTimeout:             defaults.ShutdownTimeout,
 	}
 }
 
 // ApplyOptions applies the run options to the method receiver and returns self
 func (s *ServerRunOptions) ApplyTo(c *server.Config) error {
 	c.CorsAllowedOriginList = s.CorsAllowedOriginList
+	c.CorsAllowedMethods = s.CorsAllowedMethods
+	c.CorsAllowedHeaders = s.CorsAllowedHeaders
+	c.CorsExposedHeaders = s.CorsExposedHeaders
 	// FIXME (rantuttl): Is this needed
 	//c.ExternalAddress = s.ExternalHost
 	c.MaxRequestsInFlight = s.MaxRequestsInFlight
 	c.MaxMutatingRequestsInFlight = s.MaxMutatingRequestsInFlight
 	c.MinRequestTimeout = s.MinRequestTimeout
+	c.MaxRequestBodyBytes = s.MaxRequestBodyBytes
+	c.ShutdownDelayDuration = s.ShutdownDelayDuration
+	c.ShutdownTimeout = s.ShutdownTimeout
+	if len(s.FlowControlConfigFile) > 0 {
+		fc, err := flowcontrol.NewController(s.FlowControlConfigFile, s.MaxRequestsInFlight+s.MaxMutatingRequestsInFlight)
+		if err != nil {
+			return err
+		}
+		c.FlowControl = fc
+	}
 	// FIXME (rantuttl): Is this needed
 	c.PublicAddress = s.AdvertiseAddress
 
 	return nil
 }
 
+// Validate checks the run options and returns the errors found.
+func (s *ServerRunOptions) Validate() []error {
+	allErrors := []error{}
+	if _, err := genericfilters.CompileOriginRegexps(s.CorsAllowedOriginList); err != nil {
+		allErrors = append(allErrors, fmt.Errorf("--cors-allowed-origins must be a list of regular expressions: %v", err))
+	}
+	if s.ShutdownDelayDuration < 0 || s.ShutdownTimeout < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--shutdown-delay-duration and --shutdown-timeout must not be negative"))
+	}
+	if s.MaxRequestsInFlight < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--max-requests-inflight can not be negative value"))
+	}
+	if s.MaxMutatingRequestsInFlight < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--max-mutating-requests-inflight can not be negative value"))
+	}
+	return allErrors
+}
+
 // DefaultAdvertiseAddress sets the field AdvertiseAddress if unset. The field will be set based on the SecureServingOptions.
 func (s *ServerRunOptions) DefaultAdvertiseAddress(secure *SecureServingOptions) error {
 	if secure == nil {
@@ -96,7 +141,20 @@ func (s *ServerRunOptions) AddUniversalFlags(fs *pflag.FlagSet) {
 
 	fs.StringSliceVar(&s.CorsAllowedOriginList, "cors-allowed-origins", s.CorsAllowedOriginList, ""+
 		"List of allowed origins for CORS, comma separated.  An allowed origin can be a regular "+
-		"expression to support subdomain matching. If this list is empty CORS will not be enabled.")
+		"expression to support subdomain matching, anchor it with ^ and $ to match whole origins. "+
+		"If this list is empty CORS will not be enabled.")
+
+	fs.StringSliceVar(&s.CorsAllowedMethods, "cors-allowed-methods", s.CorsAllowedMethods, ""+
+		"List of methods the allowed CORS origins may use, comma separated. Defaults to "+
+		strings.Join(genericfilters.DefaultCORSAllowedMethods, ",")+".")
+
+	fs.StringSliceVar(&s.CorsAllowedHeaders, "cors-allowed-headers", s.CorsAllowedHeaders, ""+
+		"List of request headers the allowed CORS origins may send, comma separated. Defaults to "+
+		strings.Join(genericfilters.DefaultCORSAllowedHeaders, ",")+".")
+
+	fs.StringSliceVar(&s.CorsExposedHeaders, "cors-exposed-headers", s.CorsExposedHeaders, ""+
+		"List of response headers exposed to the allowed CORS origins, comma separated. Defaults to "+
+		strings.Join(genericfilters.DefaultCORSExposedHeaders, ",")+".")
 
 	fs.IntVar(&s.TargetRAMMB, "target-ram-mb", s.TargetRAMMB,
 		"Memory limit for apiserver in MB (used to configure sizes of caches, etc.)")
@@ -128,6 +186,25 @@ func (s *ServerRunOptions) AddUniversalFlags(fs *pflag.FlagSet) {
 		"handler, which picks a randomized value above this number as the connection timeout, "+
 		"to spread out load.")
 
+	fs.Int64Var(&s.MaxRequestBodyBytes, "max-request-body-bytes", s.MaxRequestBodyBytes, ""+
+		"The largest request body, in bytes, accepted by the resource handlers. Larger requests "+
+		"are rejected with 413 RequestEntityTooLarge. Zero for no limit.")
+
+	fs.StringVar(&s.FlowControlConfigFile, "flow-control-config-file", s.FlowControlConfigFile, ""+
+		"File with the flow control configuration, in YAML or JSON, which classifies requests by "+
+		"user and resource into priority levels sharing the server's concurrency, and queues them "+
+		"fairly across users or tenants. The file is reloaded when it changes. If set, the sum of "+
+		"--max-requests-inflight and --max-mutating-requests-inflight is shared between the priority "+
+		"levels instead of limiting requests by kind.")
+
+	fs.DurationVar(&s.ShutdownDelayDuration, "shutdown-delay-duration", s.ShutdownDelayDuration, ""+
+		"Time to keep serving requests once shutdown starts, with /readyz failing, so that load "+
+		"balancers stop sending requests to the server before it stops accepting connections.")
+
+	fs.DurationVar(&s.ShutdownTimeout, "shutdown-timeout", s.ShutdownTimeout, ""+
+		"Time to wait on shutdown, once the server stops accepting connections, for the requests "+
+		"in flight to finish. Open watches are ended right away.")
+
 	fs.StringSliceVar(&s.WatchCacheSizes, "watch-cache-sizes", s.WatchCacheSizes, ""+
 		"List of watch cache sizes for every resource (pods, nodes, etc.), comma separated. "+
 		"The individual override format: resource#size, where size is a number. It takes effect "+
@@ -31,6 +31,8 @@ import (
 
 	utilnet "github.com/rantuttl/cloudops/apimachinery/pkg/util/net"
 	genericserver "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/dynamiccertificates"
+	utilflag "github.com/rantuttl/cloudops/apiserver/pkg/util/flag"
 	// FIXME (rantuttl)
 	//"k8s.io/client-go/informers"
 	//"k8s.io/client-go/kubernetes"
@@ -44,6 +46,8 @@ type SecureServingOptions struct {
 
 	// ServerCert is the TLS cert info for serving secure traffic
 	ServerCert GeneratableKeyCert
+	// SNICertKeys are named CertKeys for serving secure traffic with SNI support.
+	SNICertKeys []utilflag.NamedCertKey
 }
 
 type CertKey struct {
@@ -119,6 +123,17 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		"certificate authority will used for secure access from Admission "+
 		"Controllers. This must be a valid PEM-encoded CA bundle. Altneratively, the certificate authority "+
 		"can be appended to the certificate provided by --tls-cert-file.")
+
+	fs.Var(utilflag.NewNamedCertKeyArray(&s.SNICertKeys), "tls-sni-cert-key", ""+
+		"A pair of x509 certificate and private key file paths, optionally suffixed with a list of "+
+		"domain patterns which are fully qualified domain names, possibly with prefixed wildcard "+
+		"segments. If no domain patterns are provided, the names of the certificate are "+
+		"extracted. Non-wildcard matches trump over wildcard matches, explicit domain patterns "+
+		"trump over extracted names. For multiple key/certificate pairs, use the "+
+		"--tls-sni-cert-key multiple times. "+
+		"Examples: \"example.crt,example.key\" or \"foo.crt,foo.key:*.foo.com,foo.com\". "+
+		"The certificates and keys, as --tls-cert-file, --tls-private-key-file and --client-ca-file, "+
+		"are reloaded when their files change.")
 }
 
 func (s *SecureServingOptions) AddDeprecatedFlags(fs *pflag.FlagSet) {
@@ -214,11 +229,11 @@ func (s *SecureServingOptions) applyServingInfoTo(c *genericserver.Config) error
 
 	// load main cert
 	if len(serverCertFile) != 0 || len(serverKeyFile) != 0 {
-		tlsCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+		var err error
+		secureServingInfo.Cert, err = dynamiccertificates.NewDynamicServingContentFromFiles("serving-cert", serverCertFile, serverKeyFile)
 		if err != nil {
 			return fmt.Errorf("unable to load server certificate: %v", err)
 		}
-		secureServingInfo.Cert = &tlsCert
 	}
 
 	// optionally load CA cert
@@ -239,26 +254,14 @@ func (s *SecureServingOptions) applyServingInfoTo(c *genericserver.Config) error
 		}
 	}
 
-	// FIXME (rantuttl): Remove if we don't need SNI...what is it? it's not in the SercureServingInfo at this time
 	// load SNI certs
-	/*
-	namedTLSCerts := make([]genericserver.NamedTLSCert, 0, len(s.SNICertKeys))
 	for _, nck := range s.SNICertKeys {
-		tlsCert, err := tls.LoadX509KeyPair(nck.CertFile, nck.KeyFile)
-		namedTLSCerts = append(namedTLSCerts, genericserver.NamedTLSCert{
-			TLSCert: tlsCert,
-			Names:   nck.Names,
-		})
+		sniCert, err := dynamiccertificates.NewDynamicSNIContentFromFiles("sni-serving-cert", nck.CertFile, nck.KeyFile, nck.Names...)
 		if err != nil {
 			return fmt.Errorf("failed to load SNI cert and key: %v", err)
 		}
+		secureServingInfo.SNICerts = append(secureServingInfo.SNICerts, sniCert)
 	}
-	var err error
-	secureServingInfo.SNICerts, err = genericserver.GetNamedCertificateMap(namedTLSCerts)
-	if err != nil {
-		return err
-	}
-	*/
 
 	c.SecureServingInfo = secureServingInfo
 
@@ -20,26 +20,38 @@ import (
 	"net"
 	"net/http"
 	"crypto/tls"
-	"crypto/x509"
 	"strings"
 
+	"github.com/go-openapi/spec"
+
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/version"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	utilwaitgroup "github.com/rantuttl/cloudops/apimachinery/pkg/util/waitgroup"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/routes"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/openapi"
 	//genericapiserver "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/authenticator"
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authorization/authorizer"
+	genericapi "github.com/rantuttl/cloudops/apiserver/pkg/endpoints"
 	genericapifilters "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/discovery"
 	genericfilters "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/filters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/httplog"
 	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
-	certutil "github.com/rantuttl/cloudops/apiserver/pkg/util/cert"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/flowcontrol"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	auditpolicy "github.com/rantuttl/cloudops/apiserver/pkg/audit/policy"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/healthz"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/dynamiccertificates"
 	genericregistry "github.com/rantuttl/cloudops/apiserver/pkg/registry/generic"
 )
 
 const (
 	APIGroupPrefix = "/api"
+	// BulkApplyPath is where multi-document streams of objects are applied, see genericapi.BulkApply.
+	BulkApplyPath = "/bulk"
 )
 
 // Config is a structure used to configure a GenericAPIServer.
@@ -47,14 +59,29 @@ type Config struct {
 	// Serializer is required and provides the interface for serializing and converting objects to and from the wire
 	// The default (api.Codecs) usually works fine.
 	Serializer runtime.NegotiatedSerializer
+	// UniversalDeserializer decodes the objects of every group, in any format of the Serializer.
+	// The bulk apply handler decodes the objects it is given with it.
+	UniversalDeserializer runtime.Decoder
 	SecureServingInfo *SecureServingInfo
 	Authenticator authenticator.Request
 	// Authorizer determines whether the subject is allowed to make the request based only
 	// on the RequestURI
 	Authorizer authorizer.Authorizer
 	CorsAllowedOriginList []string
+	// CorsAllowedMethods, CorsAllowedHeaders and CorsExposedHeaders are sent to the allowed
+	// origins. The filter defaults are used when empty.
+	CorsAllowedMethods []string
+	CorsAllowedHeaders []string
+	CorsExposedHeaders []string
 	BuildHandlerChainFunc func(apiHandler http.Handler, c *Config) (secure http.Handler)
 	EnableSwaggerUI bool
+	// EnableMetrics serves the Prometheus metrics of the server at /metrics.
+	EnableMetrics bool
+	// AccessLogConfig selects the format of the line logged for every request, and which
+	// requests are logged.
+	AccessLogConfig *httplog.Config
+	// OpenAPIConfig will be used in generating OpenAPI spec. This is nil by default. Use DefaultOpenAPIConfig for "working" defaults.
+	OpenAPIConfig *openapi.Config
         // RequestContextMapper maps requests to contexts. Exported so downstream consumers can provider their own mappers
         // TODO confirm that anyone downstream actually uses this and doesn't just need an accessor
         RequestContextMapper apirequest.RequestContextMapper
@@ -67,8 +94,25 @@ type Config struct {
 	// MaxMutatingRequestsInFlight is the maximum number of parallel mutating requests. Every further
 	// request has to wait.
 	MaxMutatingRequestsInFlight int
+	// MaxRequestBodyBytes is the largest request body, in bytes, the resource handlers will read.
+	// Larger bodies are rejected with 413 RequestEntityTooLarge. Zero or less means no limit.
+	MaxRequestBodyBytes int64
+	// HandlerChainWaitGroup tracks the requests in flight, so that they are drained on shutdown.
+	HandlerChainWaitGroup *utilwaitgroup.SafeWaitGroup
+	// ShutdownDelayDuration is how long the server keeps serving, with /readyz failing, once
+	// shutdown starts. It gives load balancers the time to stop sending requests to the server.
+	ShutdownDelayDuration time.Duration
+	// ShutdownTimeout is how long the server waits for the requests in flight to finish once it
+	// has stopped accepting connections.
+	ShutdownTimeout time.Duration
         // Predicate which is true for paths of long-running http requests
         LongRunningFunc apirequest.LongRunningRequestCheck
+	// FlowControl, if set, shares the server's concurrency fairly between users and tenants
+	// and replaces the MaxRequestsInFlight and MaxMutatingRequestsInFlight limits.
+	FlowControl flowcontrol.Interface
+	// AuditBackend, if set, is sent an audit event of every request AuditPolicyChecker selects.
+	AuditBackend audit.Backend
+	AuditPolicyChecker auditpolicy.Checker
 	Version *version.Info
 	PublicAddress net.IP
 
@@ -89,14 +133,18 @@ type SecureServingInfo struct {
 
         // Cert is the main server cert which is used if SNI does not match. Cert must be non-nil and is
         // allowed to be in SNICerts.
-        Cert *tls.Certificate
+        Cert dynamiccertificates.CertKeyContentProvider
+
+        // SNICerts are the certs served for the host names they are selected for, see
+        // GetNamedCertificateMap. They are reloaded, as Cert and ClientCA, when their files change.
+        SNICerts []dynamiccertificates.SNICertKeyContentProvider
 
         // CACert is an optional certificate authority used for the loopback connection of the Admission controllers.
         // If this is nil, the certificate authority is extracted from Cert or a matching SNI certificate.
         CACert *tls.Certificate
 
         // ClientCA is the certificate bundle for all the signers that you'll recognize for incoming client certificates
-        ClientCA *x509.CertPool
+        ClientCA dynamiccertificates.CAContentProvider
 
         // MinTLSVersion optionally overrides the minimum TLS version supported.
         // Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants).
@@ -110,12 +158,39 @@ type SecureServingInfo struct {
 func NewConfig(codecs serializer.CodecFactory) *Config {
 	return &Config{
 		Serializer:			codecs,
+		UniversalDeserializer:		codecs.UniversalDeserializer(),
 		BuildHandlerChainFunc:		DefaultHandlerChainBuilder,
 		EnableSwaggerUI:		false,
+		EnableMetrics:			true,
+		AccessLogConfig:		httplog.NewDefaultConfig(),
 		RequestContextMapper:		apirequest.NewRequestContextMapper(),
 		MinRequestTimeout:		1800,
 		MaxRequestsInFlight:		400,
 		MaxMutatingRequestsInFlight:	200,
+		// 3MB leaves room for a large object plus its managed fields, while keeping a
+		// single request from exhausting the server's memory.
+		MaxRequestBodyBytes:		int64(3 * 1024 * 1024),
+		HandlerChainWaitGroup:		new(utilwaitgroup.SafeWaitGroup),
+		ShutdownDelayDuration:		time.Duration(0),
+		ShutdownTimeout:		60 * time.Second,
+		LongRunningFunc:		genericfilters.BasicLongRunningRequestCheck(sets.NewString("watch"), sets.NewString()),
+	}
+}
+
+// DefaultOpenAPIConfig provides the default OpenAPIConfig used to build the OpenAPI V2 spec
+func DefaultOpenAPIConfig(typer runtime.ObjectTyper) *openapi.Config {
+	return &openapi.Config{
+		Info: &spec.Info{
+			InfoProps: spec.InfoProps{
+				Title:	"CloudOps",
+			},
+		},
+		DefaultResponse: &spec.Response{
+			ResponseProps: spec.ResponseProps{
+				Description: "Default Response.",
+			},
+		},
+		Typer:		typer,
 	}
 }
 
@@ -151,47 +226,84 @@ func (c completedConfig) New(name string, delegate DelegationTarget) (*GenericAP
 		Handler: apiServerHandler,
 		requestContextMapper: c.RequestContextMapper,
 		minRequestTimeout: time.Duration(c.MinRequestTimeout) * time.Second,
+		flowControl: c.FlowControl,
+		auditBackend: c.AuditBackend,
+		handlerChainWaitGroup: c.HandlerChainWaitGroup,
+		shutdownDelayDuration: c.ShutdownDelayDuration,
+		shutdownTimeout: c.ShutdownTimeout,
+		readinessStopCh: make(chan struct{}),
+		listenersStopCh: make(chan struct{}),
+		auditStopCh: make(chan struct{}),
+		preShutdownHooks: map[string]ShutdownHookFunc{},
+		postShutdownHooks: map[string]ShutdownHookFunc{},
+		maxRequestBodyBytes: c.MaxRequestBodyBytes,
+		bulkRegistry: genericapi.NewBulkRegistry(),
+		DiscoveryGroupManager: discovery.NewRootAPIsHandler(APIGroupPrefix, c.Serializer),
+		openAPIConfig: c.OpenAPIConfig,
+		livezChecks: []healthz.HealthChecker{healthz.PingHealthz},
 	}
+	s.readyzChecks = []healthz.HealthChecker{healthz.PingHealthz, shutdownCheck{s.readinessStopCh}}
 
-	installAPIs(s, c.Config)
+	if err := installAPIs(s, c.Config); err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
 
 // install APIs unique to this generic server
-func installAPIs(s *GenericAPIServer, c *Config) {
+func installAPIs(s *GenericAPIServer, c *Config) error {
 	routes.Version{Version: c.Version}.Install(s.Handler.GoRestfulContainer)
+	if c.EnableMetrics {
+		routes.DefaultMetrics{}.Install(s.Handler.NonGoRestfulMux)
+	}
+	s.Handler.GoRestfulContainer.Add(s.DiscoveryGroupManager.WebService())
+	if c.UniversalDeserializer != nil {
+		bulk := genericapi.BulkApply{
+			Registry:		s.bulkRegistry,
+			Serializer:		c.Serializer,
+			Decoder:		c.UniversalDeserializer,
+			Authorizer:		c.Authorizer,
+			Context:		c.RequestContextMapper,
+			MaxRequestBodyBytes:	c.MaxRequestBodyBytes,
+		}
+		if err := bulk.Install(BulkApplyPath, s.Handler.GoRestfulContainer); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 
-func (c *Config) ApplyClientCert(clientCAFile string) (*Config, error) {
-        if c.SecureServingInfo != nil {
-                if len(clientCAFile) > 0 {
-                        clientCAs, err := certutil.CertsFromFile(clientCAFile)
-                        if err != nil {
-                                return nil, fmt.Errorf("unable to load client CA file: %v", err)
-                        }
-                        if c.SecureServingInfo.ClientCA == nil {
-                                c.SecureServingInfo.ClientCA = x509.NewCertPool()
-                        }
-                        for _, cert := range clientCAs {
-                                c.SecureServingInfo.ClientCA.AddCert(cert)
-                        }
-                }
-        }
-
-        return c, nil
+// ApplyClientCert makes the secure server request client certificates, verified against clientCA.
+func (c *Config) ApplyClientCert(clientCA dynamiccertificates.CAContentProvider) *Config {
+	if c.SecureServingInfo != nil && clientCA != nil {
+		c.SecureServingInfo.ClientCA = clientCA
+	}
+	return c
 }
 
 func DefaultHandlerChainBuilder(apiHandler http.Handler, c *Config) http.Handler {
 	handler := apiHandler
 	// FIXME (rantuttl): See ./staging/src/k8s.io/apiserver/pkg/server/config.go
 	handler = genericapifilters.WithAuthorization(apiHandler, c.RequestContextMapper, c.Authorizer, c.Serializer)
+	handler = genericfilters.WithPriorityAndFairness(handler, c.RequestContextMapper, c.LongRunningFunc, c.FlowControl, c.Serializer)
+	handler = genericapifilters.WithAudit(handler, c.RequestContextMapper, c.AuditBackend, c.AuditPolicyChecker, c.LongRunningFunc)
 	handler = genericapifilters.WithAuthentication(handler, c.RequestContextMapper, c.Authenticator, genericapifilters.Unauthorized(c.RequestContextMapper, c.Serializer, c.SupportsBasicAuth))
+	handler = genericfilters.WithCORS(handler, c.CorsAllowedOriginList, c.CorsAllowedMethods, c.CorsAllowedHeaders, c.CorsExposedHeaders, "true")
 	// etc...
 	// build up the chained handlers here (see filters)
 	// NOTE that this looks very similar to BuildInsecureHandlerChain in apiserver/pkg/genericserver/server/insecure_handler.go
+	if c.FlowControl == nil {
+		handler = genericfilters.WithMaxInFlightLimit(handler, c.MaxRequestsInFlight, c.MaxMutatingRequestsInFlight, c.RequestContextMapper, c.LongRunningFunc, c.Serializer)
+	}
+	handler = genericfilters.WithWaitGroup(handler, c.RequestContextMapper, c.HandlerChainWaitGroup, c.Serializer)
+	if c.EnableMetrics {
+		handler = genericapifilters.WithMetrics(handler, c.RequestContextMapper, c.LongRunningFunc)
+	}
 	handler = genericapifilters.WithRequestInfo(handler, NewRequestInfoResolver(c), c.RequestContextMapper)
+	handler = genericapifilters.WithRequestID(handler, c.RequestContextMapper)
+	handler = httplog.Handler(handler, c.RequestContextMapper, c.AccessLogConfig)
 	handler = apirequest.WithRequestContext(handler, c.RequestContextMapper)
 	handler = genericfilters.WithPanicRecovery(handler)
 	return handler
@@ -0,0 +1,109 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+var (
+	// DefaultCORSAllowedMethods are the methods allowed when none are configured.
+	DefaultCORSAllowedMethods = []string{"POST", "GET", "OPTIONS", "PUT", "DELETE", "PATCH"}
+	// DefaultCORSAllowedHeaders are the request headers allowed when none are configured.
+	DefaultCORSAllowedHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-Requested-With", "If-Modified-Since"}
+	// DefaultCORSExposedHeaders are the response headers exposed when none are configured.
+	DefaultCORSExposedHeaders = []string{"Date"}
+)
+
+// WithCORS is a simple CORS implementation that wraps an http Handler.
+// Pass nil for allowedMethods, allowedHeaders and exposedHeaders to use the defaults.
+// If allowedOriginPatterns is empty, no CORS support is installed.
+func WithCORS(handler http.Handler, allowedOriginPatterns []string, allowedMethods []string, allowedHeaders []string, exposedHeaders []string, allowCredentials string) http.Handler {
+	if len(allowedOriginPatterns) == 0 {
+		return handler
+	}
+	allowedOriginPatternsREs := allowedOriginRegexps(allowedOriginPatterns)
+
+	// Set defaults for methods and headers if nothing was passed
+	if len(allowedMethods) == 0 {
+		allowedMethods = DefaultCORSAllowedMethods
+	}
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultCORSAllowedHeaders
+	}
+	if len(exposedHeaders) == 0 {
+		exposedHeaders = DefaultCORSExposedHeaders
+	}
+	methods := strings.Join(allowedMethods, ", ")
+	headers := strings.Join(allowedHeaders, ", ")
+	exposed := strings.Join(exposedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin != "" {
+			// The response depends on the origin, caches must not serve it to other origins.
+			w.Header().Add("Vary", "Origin")
+			allowed := false
+			for _, re := range allowedOriginPatternsREs {
+				if allowed = re.MatchString(origin); allowed {
+					break
+				}
+			}
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+				w.Header().Set("Access-Control-Allow-Credentials", allowCredentials)
+
+				// Stop here if its a preflight OPTIONS request, browsers send it without credentials.
+				if req.Method == "OPTIONS" && req.Header.Get("Access-Control-Request-Method") != "" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+		}
+		// Dispatch to the next handler
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func allowedOriginRegexps(allowedOrigins []string) []*regexp.Regexp {
+	res, err := CompileOriginRegexps(allowedOrigins)
+	if err != nil {
+		glog.Fatalf("Invalid CORS allowed origin, --cors-allowed-origins flag was set to %v - %v", strings.Join(allowedOrigins, ","), err)
+	}
+	return res
+}
+
+// CompileOriginRegexps takes a list of allowed origin patterns and compiles them.
+// An origin is allowed if any pattern matches part of it, so anchor the patterns,
+// e.g. `^https://console\.example\.com$`, to match whole origins.
+func CompileOriginRegexps(allowedOrigins []string) ([]*regexp.Regexp, error) {
+	res := []*regexp.Regexp{}
+	for _, origin := range allowedOrigins {
+		re, err := regexp.Compile(origin)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
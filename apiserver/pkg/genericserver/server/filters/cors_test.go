@@ -0,0 +1,119 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	table := []struct {
+		allowedOrigins []string
+		origin         string
+		allowed        bool
+	}{
+		{[]string{}, "example.com", false},
+		{[]string{`^https://console\.example\.com$`}, "https://console.example.com", true},
+		{[]string{`^https://console\.example\.com$`}, "https://console.example.com.evil.org", false},
+		{[]string{`^https://[a-z]+\.example\.com$`}, "https://admin.example.com", true},
+		{[]string{"not-matching.com", "example.com"}, "example.com", true},
+		{[]string{".*"}, "example.com", true},
+	}
+
+	for _, item := range table {
+		handler := WithCORS(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			item.allowedOrigins, nil, nil, nil, "true",
+		)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		request, err := http.NewRequest("GET", server.URL+"/version", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		request.Header.Set("Origin", item.origin)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		response.Body.Close()
+
+		if item.allowed {
+			if response.Header.Get("Access-Control-Allow-Origin") != item.origin {
+				t.Errorf("%v: expected Access-Control-Allow-Origin %q, got %q", item.allowedOrigins, item.origin, response.Header.Get("Access-Control-Allow-Origin"))
+			}
+			if response.Header.Get("Access-Control-Allow-Credentials") == "" ||
+				response.Header.Get("Access-Control-Allow-Headers") == "" ||
+				response.Header.Get("Access-Control-Allow-Methods") == "" ||
+				response.Header.Get("Access-Control-Expose-Headers") == "" {
+				t.Errorf("%v: expected the CORS headers to be set, got %v", item.allowedOrigins, response.Header)
+			}
+		} else {
+			if response.Header.Get("Access-Control-Allow-Origin") != "" {
+				t.Errorf("%v: expected no CORS headers for %q, got %v", item.allowedOrigins, item.origin, response.Header)
+			}
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	handler := WithCORS(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+		}),
+		[]string{`^https://console\.example\.com$`}, []string{"GET", "POST"}, []string{"Authorization", "Content-Type"}, nil, "true",
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	request, _ := http.NewRequest("OPTIONS", server.URL+"/api/core/v1/accounts", nil)
+	request.Header.Set("Origin", "https://console.example.com")
+	request.Header.Set("Access-Control-Request-Method", "POST")
+	request.Header.Set("Access-Control-Request-Headers", "Authorization")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("expected %d, got %d", http.StatusNoContent, response.StatusCode)
+	}
+	if called {
+		t.Errorf("expected the preflight request not to reach the handler")
+	}
+	if got := response.Header.Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("unexpected allowed methods %q", got)
+	}
+	if got := response.Header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("unexpected allowed headers %q", got)
+	}
+
+	// A preflight from an origin not allowed gets no CORS headers, so the browser fails it.
+	request.Header.Set("Origin", "https://evil.org")
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response.Body.Close()
+	if response.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected no CORS headers, got %v", response.Header)
+	}
+}
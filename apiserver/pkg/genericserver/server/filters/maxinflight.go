@@ -0,0 +1,114 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// retryAfter is the number of seconds a client turned away by the in-flight limit is asked to
+// wait before retrying.
+// TODO: maybe make this dynamic? or user-adjustable?
+const retryAfter = 1
+
+var nonMutatingRequestVerbs = sets.NewString("get", "list", "watch")
+
+// WithMaxInFlightLimit limits the number of requests served at once, read requests and mutating
+// requests each with their own limit, so that a spike of one kind does not starve the other.
+// A request over the limit is turned away with 429 TooManyRequests and a Retry-After header,
+// rather than queued. Long-running requests, e.g. watches, are not counted. A limit of zero
+// disables it.
+func WithMaxInFlightLimit(
+	handler http.Handler,
+	nonMutatingLimit int,
+	mutatingLimit int,
+	requestContextMapper apirequest.RequestContextMapper,
+	longRunningRequestCheck apirequest.LongRunningRequestCheck,
+	s runtime.NegotiatedSerializer,
+) http.Handler {
+	if nonMutatingLimit == 0 && mutatingLimit == 0 {
+		return handler
+	}
+	var nonMutatingChan chan bool
+	var mutatingChan chan bool
+	if nonMutatingLimit != 0 {
+		nonMutatingChan = make(chan bool, nonMutatingLimit)
+	}
+	if mutatingLimit != 0 {
+		mutatingChan = make(chan bool, mutatingLimit)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request, handler chain must be wrong"))
+			return
+		}
+		requestInfo, ok := apirequest.RequestInfoFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no RequestInfo found in context, handler chain must be wrong"))
+			return
+		}
+
+		// Skip tracking long running events.
+		if longRunningRequestCheck != nil && longRunningRequestCheck(req, requestInfo) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		var c chan bool
+		if !nonMutatingRequestVerbs.Has(requestInfo.Verb) {
+			c = mutatingChan
+		} else {
+			c = nonMutatingChan
+		}
+
+		if c == nil {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		select {
+		case c <- true:
+			defer func() { <-c }()
+			handler.ServeHTTP(w, req)
+		default:
+			glog.V(4).Infof("Too many requests in flight, rejecting %v %v", req.Method, req.RequestURI)
+			gv := statusGroupVersion(requestInfo)
+			err := apierrors.NewTooManyRequests("Too many requests, please try again later.", retryAfter)
+			responsewriters.ErrorNegotiated(ctx, err, s, gv, w, req)
+		}
+	})
+}
+
+// statusGroupVersion returns the group version to encode the Status of a rejected request in.
+// Non-resource requests, e.g. /healthz or discovery, have none of their own and get meta/v1.
+func statusGroupVersion(requestInfo *apirequest.RequestInfo) schema.GroupVersion {
+	if !requestInfo.IsResourceRequest {
+		return metav1.SchemeGroupVersion
+	}
+	return schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion}
+}
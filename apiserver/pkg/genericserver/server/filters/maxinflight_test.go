@@ -0,0 +1,139 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	_ "github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core/install"
+	genericapifilters "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+func newInFlightServer(handler http.Handler, nonMutating, mutating int) *httptest.Server {
+	mapper := apirequest.NewRequestContextMapper()
+	longRunning := BasicLongRunningRequestCheck(sets.NewString("watch"), sets.NewString())
+	handler = WithMaxInFlightLimit(handler, nonMutating, mutating, mapper, longRunning, api.Codecs)
+	resolver := &apirequest.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	handler = genericapifilters.WithRequestInfo(handler, resolver, mapper)
+	handler = apirequest.WithRequestContext(handler, mapper)
+	return httptest.NewServer(handler)
+}
+
+// expectStatus decodes the body of resp as a Status and checks its code and group version.
+func expectStatus(t *testing.T, resp *http.Response, code int32, apiVersion string) {
+	status := metav1.Status{}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Errorf("%s: unexpected error decoding the Status: %v", resp.Request.URL.Path, err)
+		return
+	}
+	if status.Kind != "Status" || status.APIVersion != apiVersion {
+		t.Errorf("%s: expected a %s Status, got %s %s", resp.Request.URL.Path, apiVersion, status.APIVersion, status.Kind)
+	}
+	if status.Code != code {
+		t.Errorf("%s: expected code %d in the Status, got %d", resp.Request.URL.Path, code, status.Code)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	block := make(chan struct{})
+	inFlight := sync.WaitGroup{}
+	server := newInFlightServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.Header.Get("X-Block")) > 0 {
+			inFlight.Done()
+			<-block
+		}
+	}), 1, 1)
+	blockingRequest := func(method, url string) {
+		req, _ := http.NewRequest(method, url, nil)
+		req.Header.Set("X-Block", "true")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", method, url, err)
+			return
+		}
+		resp.Body.Close()
+	}
+	defer server.Close()
+
+	// take the only read and the only mutating slots
+	done := sync.WaitGroup{}
+	for _, method := range []string{"GET", "POST"} {
+		inFlight.Add(1)
+		done.Add(1)
+		go func(method string) {
+			defer done.Done()
+			blockingRequest(method, server.URL+"/api/core/v1/accounts")
+		}(method)
+	}
+	inFlight.Wait()
+
+	for _, method := range []string{"GET", "POST", "DELETE"} {
+		req, _ := http.NewRequest(method, server.URL+"/api/core/v1/accounts", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("%s: expected 429, got %d", method, resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") != "1" {
+			t.Errorf("%s: expected a Retry-After of 1, got %q", method, resp.Header.Get("Retry-After"))
+		}
+		expectStatus(t, resp, http.StatusTooManyRequests, "core/v1")
+		resp.Body.Close()
+	}
+
+	// non-resource requests have no group version, their Status is meta/v1
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", resp.StatusCode)
+	}
+	expectStatus(t, resp, http.StatusTooManyRequests, metav1.SchemeGroupVersion.String())
+	resp.Body.Close()
+
+	// watches are long-running, they are not limited
+	inFlight.Add(1)
+	done.Add(1)
+	go func() {
+		defer done.Done()
+		blockingRequest("GET", server.URL+"/api/core/v1/accounts?watch=true")
+	}()
+	inFlight.Wait()
+
+	close(block)
+	done.Wait()
+
+	// the slots are free again
+	resp, err = http.Get(server.URL + "/api/core/v1/accounts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
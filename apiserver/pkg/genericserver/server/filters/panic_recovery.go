@@ -22,7 +22,6 @@ import (
 	"github.com/golang/glog"
 
 	"github.com/rantuttl/cloudops/apimachinery/pkg/util/runtime"
-	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/httplog"
 )
 
 // WithPanicRecovery wraps an http Handler to recover and log panics.
@@ -33,9 +32,6 @@ func WithPanicRecovery(handler http.Handler) http.Handler {
 			glog.Errorf("APIServer panic'd on %v %v: %v\n%s\n", req.Method, req.RequestURI, err, debug.Stack())
 		})
 
-		logger := httplog.NewLogged(req, &w)
-		defer logger.Log()
-
 		// Dispatch to the internal handler
 		handler.ServeHTTP(w, req)
 	})
@@ -0,0 +1,79 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/flowcontrol"
+)
+
+// WithPriorityAndFairness hands requests to the flow controller, which classifies them by user
+// and resource into priority levels and queues them fairly across flows. A request the flow
+// controller rejects is turned away with 429 TooManyRequests. Long-running requests, e.g.
+// watches, are not limited. It must run after authentication, as it classifies by user.
+func WithPriorityAndFairness(
+	handler http.Handler,
+	requestContextMapper apirequest.RequestContextMapper,
+	longRunningRequestCheck apirequest.LongRunningRequestCheck,
+	fc flowcontrol.Interface,
+	s runtime.NegotiatedSerializer,
+) http.Handler {
+	if fc == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request, handler chain must be wrong"))
+			return
+		}
+		requestInfo, ok := apirequest.RequestInfoFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no RequestInfo found in context, handler chain must be wrong"))
+			return
+		}
+		u, ok := apirequest.UserFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no User found in context, handler chain must be wrong"))
+			return
+		}
+
+		// Skip tracking long running events.
+		if longRunningRequestCheck != nil && longRunningRequestCheck(req, requestInfo) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		err := fc.Handle(req.Context(), requestInfo, u, func() {
+			handler.ServeHTTP(w, req)
+		})
+		if err != nil {
+			glog.V(4).Infof("Rejecting %v %v: %v", req.Method, req.RequestURI, err)
+			gv := statusGroupVersion(requestInfo)
+			err := apierrors.NewTooManyRequests("Too many requests, please try again later.", retryAfter)
+			responsewriters.ErrorNegotiated(ctx, err, s, gv, w, req)
+		}
+	})
+}
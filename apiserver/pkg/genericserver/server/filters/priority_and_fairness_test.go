@@ -0,0 +1,76 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package filters
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	genericapifilters "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+)
+
+// rejectingFlowControl turns away every request.
+type rejectingFlowControl struct{}
+
+func (rejectingFlowControl) Handle(ctx context.Context, requestInfo *apirequest.RequestInfo, u user.Info, execute func()) error {
+	return errors.New("queue is full")
+}
+
+func (rejectingFlowControl) Run(stopCh <-chan struct{}) {}
+
+func TestPriorityAndFairnessRejected(t *testing.T) {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("%s: rejected request must not execute", req.URL.Path)
+	})
+	mapper := apirequest.NewRequestContextMapper()
+	longRunning := BasicLongRunningRequestCheck(sets.NewString("watch"), sets.NewString())
+	handler = WithPriorityAndFairness(handler, mapper, longRunning, rejectingFlowControl{}, api.Codecs)
+	withUser := handler
+	handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, _ := mapper.Get(req)
+		mapper.Update(req, apirequest.WithUser(ctx, &user.DefaultInfo{Name: "alice"}))
+		withUser.ServeHTTP(w, req)
+	})
+	resolver := &apirequest.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	handler = genericapifilters.WithRequestInfo(handler, resolver, mapper)
+	handler = apirequest.WithRequestContext(handler, mapper)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for path, apiVersion := range map[string]string{
+		"/api/core/v1/accounts": "core/v1",
+		// non-resource requests have no group version, their Status is meta/v1
+		"/healthz": metav1.SchemeGroupVersion.String(),
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("%s: expected 429, got %d", path, resp.StatusCode)
+		}
+		expectStatus(t, resp, http.StatusTooManyRequests, apiVersion)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,67 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	utilwaitgroup "github.com/rantuttl/cloudops/apimachinery/pkg/util/waitgroup"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/handlers/responsewriters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// WithWaitGroup adds all requests to the wait group, which is used for graceful shutdown:
+// the server waits for them to finish before exiting. Long-running requests, e.g. watches,
+// are ended by the server on shutdown, so they finish promptly too. Requests arriving once
+// the server waits are turned away with 503 ServiceUnavailable.
+func WithWaitGroup(
+	handler http.Handler,
+	requestContextMapper apirequest.RequestContextMapper,
+	wg *utilwaitgroup.SafeWaitGroup,
+	s runtime.NegotiatedSerializer,
+) http.Handler {
+	if wg == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, ok := requestContextMapper.Get(req)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no context found for request, handler chain must be wrong"))
+			return
+		}
+		requestInfo, ok := apirequest.RequestInfoFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no RequestInfo found in context, handler chain must be wrong"))
+			return
+		}
+
+		if err := wg.Add(1); err != nil {
+			// The client should retry against another server.
+			w.Header().Set("Retry-After", "1")
+			gv := statusGroupVersion(requestInfo)
+			err := apierrors.NewServiceUnavailable("apiserver is shutting down")
+			responsewriters.ErrorNegotiated(ctx, err, s, gv, w, req)
+			return
+		}
+		defer wg.Done()
+
+		handler.ServeHTTP(w, req)
+	})
+}
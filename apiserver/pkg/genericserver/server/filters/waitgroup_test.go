@@ -0,0 +1,104 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	utilwaitgroup "github.com/rantuttl/cloudops/apimachinery/pkg/util/waitgroup"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	genericapifilters "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+func TestWithWaitGroup(t *testing.T) {
+	wg := &utilwaitgroup.SafeWaitGroup{}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	mapper := apirequest.NewRequestContextMapper()
+	handler = WithWaitGroup(handler, mapper, wg, api.Codecs)
+	resolver := &apirequest.RequestInfoFactory{APIPrefixes: sets.NewString("api")}
+	handler = genericapifilters.WithRequestInfo(handler, resolver, mapper)
+	handler = apirequest.WithRequestContext(handler, mapper)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// A request in flight is waited for.
+	done := make(chan int)
+	go func() {
+		resp, err := http.Post(server.URL+"/api/core/v1/accounts", "application/json", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	<-started
+
+	waited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waited)
+	}()
+	// Let Wait start.
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-waited:
+		t.Fatalf("expected Wait to block while a request is in flight")
+	default:
+	}
+
+	// A request arriving once the server waits is turned away. Non-resource requests have no
+	// group version, their Status is meta/v1.
+	for path, apiVersion := range map[string]string{
+		"/api/core/v1/accounts": "core/v1",
+		"/healthz":              metav1.SchemeGroupVersion.String(),
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected %d, got %d", path, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Errorf("%s: expected a Retry-After header", path)
+		}
+		expectStatus(t, resp, http.StatusServiceUnavailable, apiVersion)
+		resp.Body.Close()
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected the request in flight to complete with %d, got %d", http.StatusOK, code)
+	}
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Wait to return once the request in flight has completed")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"time"
 	"strings"
+	"sync"
 	"net/http"
 
 	"github.com/golang/glog"
@@ -27,9 +28,17 @@ import (
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/serializer"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/apimachinery"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/apimachinery/registered"
+	utilwaitgroup "github.com/rantuttl/cloudops/apimachinery/pkg/util/waitgroup"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/discovery"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/openapi"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/routes"
 	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 	genericapi "github.com/rantuttl/cloudops/apiserver/pkg/endpoints"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/flowcontrol"
+	"github.com/rantuttl/cloudops/apiserver/pkg/audit"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/healthz"
 )
 
 // FIXME (rantuttl): Stub for now
@@ -58,6 +67,43 @@ type GenericAPIServer struct {
 	Handler *APIServerHandler
 	requestContextMapper apirequest.RequestContextMapper
 	minRequestTimeout time.Duration
+	// maxRequestBodyBytes caps the size of a request body read by the resource handlers.
+	maxRequestBodyBytes int64
+	// bulkRegistry holds the resources of every installed API group version objects can be
+	// applied to in bulk.
+	bulkRegistry *genericapi.BulkRegistry
+	// flowControl, if set, is the flow controller of the handler chain, whose configuration is
+	// reloaded while the server runs.
+	flowControl flowcontrol.Interface
+	// auditBackend, if set, is the audit backend of the handler chain.
+	auditBackend audit.Backend
+
+	// handlerChainWaitGroup tracks the requests in flight, which are drained on shutdown.
+	handlerChainWaitGroup *utilwaitgroup.SafeWaitGroup
+	shutdownDelayDuration time.Duration
+	shutdownTimeout       time.Duration
+	// readinessStopCh is closed when shutdown starts, listenersStopCh when the server stops
+	// accepting connections and open watches end, and auditStopCh once requests are drained.
+	readinessStopCh chan struct{}
+	listenersStopCh chan struct{}
+	auditStopCh     chan struct{}
+	// preShutdownHooks and postShutdownHooks are run on shutdown, by name.
+	shutdownHookLock  sync.Mutex
+	preShutdownHooks  map[string]ShutdownHookFunc
+	postShutdownHooks map[string]ShutdownHookFunc
+
+	// livezChecks are served at /livez, readyzChecks at /readyz, and both at /healthz. They
+	// are installed by PrepareRun, checks must be added before.
+	healthzLock      sync.Mutex
+	livezChecks      []healthz.HealthChecker
+	readyzChecks     []healthz.HealthChecker
+	healthzInstalled bool
+
+	// DiscoveryGroupManager serves /api
+	DiscoveryGroupManager discovery.GroupManager
+
+	// openAPIConfig is used to build the /swagger.json and /openapi/v2 documents. Nil disables them.
+	openAPIConfig *openapi.Config
 }
 
 type DelegationTarget interface {
@@ -105,13 +151,19 @@ type preparedGenericAPIServer struct {
 
 // PrepareRun does post API installation setup steps.
 func (s *GenericAPIServer) PrepareRun() preparedGenericAPIServer {
+	s.installHealthz()
 	// initialize some things on the server
+	if s.openAPIConfig != nil {
+		routes.OpenAPI{
+			Config: s.openAPIConfig,
+		}.Install(s.Handler.GoRestfulContainer, s.Handler.NonGoRestfulMux)
+	}
 
 	return preparedGenericAPIServer{s}
 }
 
-// Run spawns the secure http server. It only returns if stopCh is closed
-// or the secure port cannot be listened on initially.
+// Run spawns the secure http server. It only returns if stopCh is closed, once the
+// server has shut down gracefully, or the secure port cannot be listened on initially.
 func (s preparedGenericAPIServer) Run(stopCh <-chan struct{}) error {
 	err := s.NonBlockingRun(stopCh)
 	if err != nil {
@@ -119,6 +171,7 @@ func (s preparedGenericAPIServer) Run(stopCh <-chan struct{}) error {
 	}
 
 	<-stopCh
+	s.shutdown()
 	return nil
 }
 
@@ -129,9 +182,18 @@ func (s preparedGenericAPIServer) NonBlockingRun(stopCh <-chan struct{}) error {
 	// package server: apiserver/pkg/genericserver/server/config.go:DefaultHandlerChainBuilder
 	internalStopCh := make(chan struct{})
 
+	// Audit the requests from the very first one on, up to the last one drained on shutdown.
+	if s.auditBackend != nil {
+		if err := s.auditBackend.Run(s.auditStopCh); err != nil {
+			return fmt.Errorf("failed to run the audit backend: %v", err)
+		}
+	}
+
+	// The secure server stops accepting connections on shutdown, see shutdown.
 	if s.SecureServingInfo != nil && s.Handler != nil {
-		if err := s.serveSecurely(internalStopCh); err != nil {
+		if err := s.serveSecurely(s.listenersStopCh); err != nil {
 			close(internalStopCh)
+			close(s.auditStopCh)
 			return err
 		}
 	}
@@ -140,6 +202,10 @@ func (s preparedGenericAPIServer) NonBlockingRun(stopCh <-chan struct{}) error {
 		close(internalStopCh)
 	}()
 
+	if s.flowControl != nil {
+		go s.flowControl.Run(internalStopCh)
+	}
+
 	// TODO (rantuttl): If we ever need post-start hooks, this should be where we instantiate them
 	return nil
 }
@@ -179,6 +245,33 @@ func (s *GenericAPIServer) InstallAPIGroup(apiGroupInfo *APIGroupInfo) error {
                 return err
         }
 
+	// setup discovery
+	// Install the version handler.
+	// Add a handler at /api/<groupName> to enumerate all versions supported by this group.
+	apiVersionsForDiscovery := []metav1.GroupVersionForDiscovery{}
+	for _, groupVersion := range apiGroupInfo.GroupMeta.GroupVersions {
+		// Check the config to make sure that we elide versions that don't have any resources
+		if len(apiGroupInfo.VersionedResourcesStorageMap[groupVersion.Version]) == 0 {
+			continue
+		}
+		apiVersionsForDiscovery = append(apiVersionsForDiscovery, metav1.GroupVersionForDiscovery{
+			GroupVersion: groupVersion.String(),
+			Version:      groupVersion.Version,
+		})
+	}
+	preferredVersionForDiscovery := metav1.GroupVersionForDiscovery{
+		GroupVersion: apiGroupInfo.GroupMeta.GroupVersion.String(),
+		Version:      apiGroupInfo.GroupMeta.GroupVersion.Version,
+	}
+	apiGroup := metav1.APIGroup{
+		Name:             apiGroupInfo.GroupMeta.GroupVersion.Group,
+		Versions:         apiVersionsForDiscovery,
+		PreferredVersion: preferredVersionForDiscovery,
+	}
+
+	s.DiscoveryGroupManager.AddGroup(apiGroup)
+	s.Handler.GoRestfulContainer.Add(discovery.NewAPIGroupHandler(APIGroupPrefix, s.Serializer, apiGroup).WebService())
+
 	return nil
 }
 
@@ -202,6 +295,9 @@ func (s *GenericAPIServer) getAPIGroupVersion(apiGroupInfo *APIGroupInfo, groupV
 		Context:		s.RequestContextMapper(),
 		SubresourceGroupVersionKind: apiGroupInfo.SubresourceGroupVersionKind,
 		MinRequestTimeout:	s.minRequestTimeout,
+		MaxRequestBodyBytes:	s.maxRequestBodyBytes,
+		BulkRegistry:		s.bulkRegistry,
+		ShutdownCh:		s.listenersStopCh,
 	}
 }
 
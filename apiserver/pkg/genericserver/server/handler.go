@@ -61,6 +61,7 @@ func NewAPIServerHandler(name string, handlerChainBuilder HandlerChainBuilderFn,
 	return &APIServerHandler{
 		FullHandlerChain:	handlerChainBuilder(director),
 		GoRestfulContainer:	gorestfulContainer,
+		NonGoRestfulMux:	nonGoRestfulMux,
 		Director:		director,
 	}
 }
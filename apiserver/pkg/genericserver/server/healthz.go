@@ -0,0 +1,101 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/healthz"
+)
+
+// AddHealthChecks adds checks to both the liveness and the readiness endpoints. It must be
+// called before PrepareRun.
+func (s *GenericAPIServer) AddHealthChecks(checks ...healthz.HealthChecker) error {
+	if err := s.AddLivezChecks(checks...); err != nil {
+		return err
+	}
+	return s.AddReadyzChecks(checks...)
+}
+
+// AddLivezChecks adds checks to /livez. A failing liveness check tells the server should be
+// restarted. It must be called before PrepareRun.
+func (s *GenericAPIServer) AddLivezChecks(checks ...healthz.HealthChecker) error {
+	s.healthzLock.Lock()
+	defer s.healthzLock.Unlock()
+	if s.healthzInstalled {
+		return fmt.Errorf("unable to add liveness checks: the health endpoints are already installed")
+	}
+	s.livezChecks = append(s.livezChecks, checks...)
+	return nil
+}
+
+// AddReadyzChecks adds checks to /readyz. A failing readiness check tells the server should
+// not be sent requests, e.g. because its backend is unreachable. It must be called before
+// PrepareRun.
+func (s *GenericAPIServer) AddReadyzChecks(checks ...healthz.HealthChecker) error {
+	s.healthzLock.Lock()
+	defer s.healthzLock.Unlock()
+	if s.healthzInstalled {
+		return fmt.Errorf("unable to add readiness checks: the health endpoints are already installed")
+	}
+	s.readyzChecks = append(s.readyzChecks, checks...)
+	return nil
+}
+
+// installHealthz installs /livez, /readyz and, running the checks of both, /healthz.
+func (s *GenericAPIServer) installHealthz() {
+	s.healthzLock.Lock()
+	defer s.healthzLock.Unlock()
+	if s.healthzInstalled {
+		return
+	}
+	s.healthzInstalled = true
+
+	healthzChecks := append([]healthz.HealthChecker{}, s.livezChecks...)
+	names := map[string]bool{}
+	for _, check := range healthzChecks {
+		names[check.Name()] = true
+	}
+	for _, check := range s.readyzChecks {
+		if !names[check.Name()] {
+			healthzChecks = append(healthzChecks, check)
+		}
+	}
+
+	healthz.InstallPathHandler(s.Handler.NonGoRestfulMux, "/healthz", healthzChecks...)
+	healthz.InstallPathHandler(s.Handler.NonGoRestfulMux, "/livez", s.livezChecks...)
+	healthz.InstallPathHandler(s.Handler.NonGoRestfulMux, "/readyz", s.readyzChecks...)
+}
+
+// shutdownCheck fails once the server starts shutting down, so that load balancers stop
+// sending it requests while the ones in flight drain.
+type shutdownCheck struct {
+	stopCh <-chan struct{}
+}
+
+func (shutdownCheck) Name() string {
+	return "shutdown"
+}
+
+func (c shutdownCheck) Check(_ *http.Request) error {
+	select {
+	case <-c.stopCh:
+		return fmt.Errorf("the server is shutting down")
+	default:
+	}
+	return nil
+}
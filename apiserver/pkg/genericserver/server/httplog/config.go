@@ -0,0 +1,154 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package httplog
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// Format is the format of the access log lines.
+type Format string
+
+const (
+	// FormatText is the free-form line, with a stacktrace for the server errors.
+	FormatText Format = "text"
+	// FormatJSON is a JSON object per request.
+	FormatJSON Format = "json"
+	// FormatLogfmt is a line of key=value pairs per request.
+	FormatLogfmt Format = "logfmt"
+)
+
+// Formats are the known formats.
+var Formats = []Format{FormatText, FormatJSON, FormatLogfmt}
+
+// DefaultVerbosity is the glog verbosity requests are logged at, unless a rule says otherwise.
+const DefaultVerbosity glog.Level = 2
+
+// Config selects the format of the access log lines, where they are written and which
+// requests are logged.
+type Config struct {
+	Format Format
+	// Out is where the json and logfmt lines are written, one per line and without a glog
+	// header. Nil means standard out. Text lines are written to glog.
+	Out io.Writer
+	// SampleRate is the fraction, between 0 and 1, of the requests answered with a status below
+	// 400 that are logged. Requests answered with an error status are always logged.
+	SampleRate float64
+	// Verbosity is the glog verbosity text lines are logged at. Json and logfmt lines do not
+	// depend on glog's --v, they are written unless a status rule sets a higher verbosity.
+	Verbosity glog.Level
+	// StatusVerbosity overrides Verbosity by status, see ParseStatusVerbosity. A status code
+	// (e.g. "404") takes precedence over its class (e.g. "4xx").
+	StatusVerbosity map[string]glog.Level
+
+	// lock serializes the lines written to Out.
+	lock sync.Mutex
+}
+
+// NewDefaultConfig returns the config logging every request, as text, at DefaultVerbosity.
+func NewDefaultConfig() *Config {
+	return &Config{
+		Format:     FormatText,
+		SampleRate: 1,
+		Verbosity:  DefaultVerbosity,
+	}
+}
+
+// ParseStatusVerbosity parses rules of the form "<status>=<verbosity>", where status is a
+// code (e.g. "404") or a class (e.g. "5xx").
+func ParseStatusVerbosity(rules []string) (map[string]glog.Level, error) {
+	statusVerbosity := map[string]glog.Level{}
+	for _, rule := range rules {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rule %q, expected <status>=<verbosity>", rule)
+		}
+		status := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !validStatus(status) {
+			return nil, fmt.Errorf("invalid status %q, expected a code between 100 and 599 or a class such as 5xx", parts[0])
+		}
+		verbosity, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verbosity %q of status %s: %v", parts[1], status, err)
+		}
+		statusVerbosity[status] = glog.Level(verbosity)
+	}
+	return statusVerbosity, nil
+}
+
+func validStatus(status string) bool {
+	if len(status) != 3 || status[0] < '1' || status[0] > '5' {
+		return false
+	}
+	if status[1:] == "xx" {
+		return true
+	}
+	for _, c := range status[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes the structured line to Out.
+func (c *Config) write(line string) {
+	out := c.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, err := io.WriteString(out, line+"\n"); err != nil {
+		glog.Errorf("Unable to write the access log line: %v", err)
+	}
+}
+
+// verbosity returns the glog verbosity a request answered with status is logged at.
+func (c *Config) verbosity(status int) glog.Level {
+	if v, ok := c.StatusVerbosity[strconv.Itoa(status)]; ok {
+		return v
+	}
+	if v, ok := c.StatusVerbosity[fmt.Sprintf("%dxx", status/100)]; ok {
+		return v
+	}
+	return c.Verbosity
+}
+
+// logged returns true if the verbosity of status lets a request answered with it be logged.
+// Text lines go to glog, and are gated by its --v. Json and logfmt lines go to Out, and are
+// only dropped by the status rules above Verbosity.
+func (c *Config) logged(status int) bool {
+	if c.Format == FormatText {
+		return bool(glog.V(c.verbosity(status)))
+	}
+	return c.verbosity(status) <= c.Verbosity
+}
+
+// sampled returns true if a request answered with status is to be logged.
+func (c *Config) sampled(status int) bool {
+	if status >= 400 || c.SampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < c.SampleRate
+}
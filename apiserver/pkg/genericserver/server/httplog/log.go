@@ -24,16 +24,36 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
 
 // Handler wraps all HTTP calls to delegate with nice logging.
 // delegate may use LogOf(w).Addf(...) to write additional info to
 // the per-request log message.
 //
-// Intended to wrap calls to your ServeMux.
-func Handler(delegate http.Handler, pred StacktracePred) http.Handler {
+// The user and request info of the structured formats are read from the request's context,
+// so Handler must run inside WithRequestContext. A nil config is NewDefaultConfig().
+func Handler(delegate http.Handler, requestContextMapper request.RequestContextMapper, config *Config) http.Handler {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		defer NewLogged(req, &w).StacktraceWhen(pred).Log()
+		rl := NewLogged(req, &w)
+		rl.config = config
+		rl.requestContextMapper = requestContextMapper
+		if config.Format != FormatText {
+			// Stacktraces and error output are only written in text.
+			rl.StacktraceWhen(func(int) bool { return false })
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				defer panic(r)
+				// The panic is answered with a 500 further up the chain.
+				rl.status, rl.statusRecorded = http.StatusInternalServerError, true
+			}
+			rl.Log()
+		}()
 		delegate.ServeHTTP(w, req)
 	})
 }
@@ -58,6 +78,7 @@ type respLogger struct {
 	statusStack    string
 	addedInfo      string
 	startTime      time.Time
+	bytes          int64
 
 	captureErrorOutput bool
 
@@ -65,6 +86,9 @@ type respLogger struct {
 	w   http.ResponseWriter
 
 	logStacktracePred StacktracePred
+
+	config               *Config
+	requestContextMapper request.RequestContextMapper
 }
 
 // Simple logger that logs immediately when Addf is called
@@ -102,6 +126,7 @@ func NewLogged(req *http.Request, w *http.ResponseWriter) *respLogger {
 		req:               req,
 		w:                 *w,
 		logStacktracePred: DefaultStacktracePred,
+		config:            NewDefaultConfig(),
 	}
 	*w = rl // hijack caller's writer!
 	return rl
@@ -157,13 +182,36 @@ func (rl *respLogger) Addf(format string, data ...interface{}) {
 // Log is intended to be called once at the end of your request handler, via defer
 func (rl *respLogger) Log() {
 	latency := time.Since(rl.startTime)
-	if glog.V(2) {
-		if !rl.hijacked {
-			glog.InfoDepth(1, fmt.Sprintf("%s %s: (%v) %v%v%v [%s %s]", rl.req.Method, rl.req.RequestURI, latency, rl.status, rl.statusStack, rl.addedInfo, rl.req.Header["User-Agent"], rl.req.RemoteAddr))
-		} else {
-			glog.InfoDepth(1, fmt.Sprintf("%s %s: (%v) hijacked [%s %s]", rl.req.Method, rl.req.RequestURI, latency, rl.req.Header["User-Agent"], rl.req.RemoteAddr))
-		}
+	status := rl.status
+	if !rl.statusRecorded && !rl.hijacked {
+		// Nothing was written, the server answers 200.
+		status = http.StatusOK
+	}
+	if !rl.config.logged(status) || !rl.config.sampled(status) {
+		return
+	}
+	if rl.config.Format != FormatText {
+		rl.config.write(rl.line(status, latency))
+		return
+	}
+	glog.InfoDepth(1, rl.line(status, latency))
+}
+
+// line returns the access log line of the request, in the configured format.
+func (rl *respLogger) line(status int, latency time.Duration) string {
+	switch rl.config.Format {
+	case FormatJSON:
+		return formatJSON(rl.fields(status, latency))
+	case FormatLogfmt:
+		return formatLogfmt(rl.fields(status, latency))
+	}
+	// The request ID filter may run after the logger is set up, and sets the ID on the request
+	// headers for it to be found here.
+	requestID := rl.req.Header.Get(request.RequestIDHeader)
+	if !rl.hijacked {
+		return fmt.Sprintf("%s %s: (%v) %v%v%v [%s %s %s]", rl.req.Method, rl.req.RequestURI, latency, status, rl.statusStack, rl.addedInfo, rl.req.Header["User-Agent"], rl.req.RemoteAddr, requestID)
 	}
+	return fmt.Sprintf("%s %s: (%v) hijacked [%s %s %s]", rl.req.Method, rl.req.RequestURI, latency, rl.req.Header["User-Agent"], rl.req.RemoteAddr, requestID)
 }
 
 // Header implements http.ResponseWriter.
@@ -179,7 +227,9 @@ func (rl *respLogger) Write(b []byte) (int, error) {
 	if rl.captureErrorOutput {
 		rl.Addf("logging error output: %q\n", string(b))
 	}
-	return rl.w.Write(b)
+	n, err := rl.w.Write(b)
+	rl.bytes += int64(n)
+	return n, err
 }
 
 // Flush implements http.Flusher even if the underlying http.Writer doesn't implement it.
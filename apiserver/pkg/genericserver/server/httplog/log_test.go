@@ -0,0 +1,213 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+)
+
+// serve runs a GET of an account through Handler with config, and returns the line logged for
+// it. The delegate answers 201 with a 5 bytes body.
+func serve(config *Config) string {
+	mapper := request.NewRequestContextMapper()
+	var rl *respLogger
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rl = w.(*respLogger)
+		ctx, _ := mapper.Get(req)
+		ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "alice"})
+		ctx = request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: "get", Resource: "accounts", Namespace: "default", Name: "acme"})
+		mapper.Update(req, ctx)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	handler = Handler(handler, mapper, config)
+	var line string
+	// The line is built while the request's context is still mapped, as Log does.
+	next := handler
+	handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req)
+		line = rl.line(rl.status, 3*time.Millisecond)
+	})
+	handler = request.WithRequestContext(handler, mapper)
+
+	req := httptest.NewRequest("GET", "/api/core/v1/namespaces/default/accounts/acme", nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	req.Header.Set("User-Agent", "curl/7.88 (linux)")
+	req.Header.Set(request.RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return line
+}
+
+func TestJSONFormat(t *testing.T) {
+	line := serve(&Config{Format: FormatJSON, SampleRate: 1})
+	record := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", line, err)
+	}
+	delete(record, "ts")
+	expected := map[string]interface{}{
+		"requestID": "req-1",
+		"method":    "GET",
+		"uri":       "/api/core/v1/namespaces/default/accounts/acme",
+		"user":      "alice",
+		"verb":      "get",
+		"resource":  "accounts",
+		"namespace": "default",
+		"name":      "acme",
+		"status":    float64(201),
+		"latency":   "3ms",
+		"bytes":     float64(5),
+		"userAgent": "curl/7.88 (linux)",
+		"remoteIP":  "10.0.0.1",
+	}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	line := serve(&Config{Format: FormatLogfmt, SampleRate: 1})
+	if !strings.HasPrefix(line, "ts=") {
+		t.Errorf("expected the line to start with the timestamp, got %q", line)
+	}
+	expected := ` requestID=req-1 method=GET uri=/api/core/v1/namespaces/default/accounts/acme user=alice verb=get resource=accounts namespace=default name=acme status=201 latency=3ms bytes=5 userAgent="curl/7.88 (linux)" remoteIP=10.0.0.1`
+	if !strings.HasSuffix(line, expected) {
+		t.Errorf("expected the line to end with %q, got %q", expected, line)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	line := serve(nil)
+	expected := `GET /api/core/v1/namespaces/default/accounts/acme: (3ms) 201 [[curl/7.88 (linux)] 10.0.0.1:41234 req-1]`
+	if line != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+}
+
+func TestStructuredOutput(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatLogfmt, FormatText} {
+		out := &bytes.Buffer{}
+		handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), nil, &Config{Format: format, Out: out, SampleRate: 1})
+		for i := 0; i < 2; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/api/core/v1/accounts/acme", nil))
+		}
+
+		lines := strings.Split(out.String(), "\n")
+		if format == FormatText {
+			// Text is written to glog.
+			if out.Len() != 0 {
+				t.Errorf("expected nothing to be written to out in text, got %q", out.String())
+			}
+			continue
+		}
+		if len(lines) != 3 || len(lines[2]) != 0 {
+			t.Fatalf("%s: expected a line per request, got %q", format, out.String())
+		}
+		for _, line := range lines[:2] {
+			if !strings.HasPrefix(line, `{"ts":`) && !strings.HasPrefix(line, "ts=") {
+				t.Errorf("%s: expected the line to start with its record, got %q", format, line)
+			}
+			if !strings.Contains(line, "204") {
+				t.Errorf("%s: expected the status in %q", format, line)
+			}
+		}
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	var rl *respLogger
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rl = w.(*respLogger)
+		panic("boom")
+	}), nil, nil)
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	if rl.status != http.StatusInternalServerError {
+		t.Errorf("expected the panic to be logged as a 500, got %d", rl.status)
+	}
+}
+
+func TestStatusVerbosity(t *testing.T) {
+	statusVerbosity, err := ParseStatusVerbosity([]string{"5xx=0", "404=4", " 4XX = 1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config := &Config{Verbosity: DefaultVerbosity, StatusVerbosity: statusVerbosity}
+	for status, expected := range map[int]glog.Level{200: 2, 404: 4, 403: 1, 503: 0, 0: 2} {
+		if v := config.verbosity(status); v != expected {
+			t.Errorf("expected status %d to be logged at %d, got %d", status, expected, v)
+		}
+	}
+
+	for _, rule := range []string{"500", "600=1", "5x=1", "4-1=1", "abc=1", "500=-1", "500=high"} {
+		if _, err := ParseStatusVerbosity([]string{rule}); err == nil {
+			t.Errorf("expected rule %q to be rejected", rule)
+		}
+	}
+}
+
+func TestLogged(t *testing.T) {
+	statusVerbosity, err := ParseStatusVerbosity([]string{"2xx=4", "5xx=0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The tests run at glog's default --v of 0.
+	for format, expected := range map[Format]map[int]bool{
+		FormatText:   {200: false, 404: false, 500: true},
+		FormatJSON:   {200: false, 404: true, 500: true},
+		FormatLogfmt: {200: false, 404: true, 500: true},
+	} {
+		config := &Config{Format: format, Verbosity: DefaultVerbosity, StatusVerbosity: statusVerbosity}
+		for status, logged := range expected {
+			if config.logged(status) != logged {
+				t.Errorf("%s: expected status %d logged to be %v", format, status, logged)
+			}
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	config := &Config{SampleRate: 0}
+	if config.sampled(http.StatusOK) {
+		t.Errorf("expected successful requests not to be logged at a sample rate of 0")
+	}
+	if !config.sampled(http.StatusNotFound) || !config.sampled(http.StatusInternalServerError) {
+		t.Errorf("expected errors to be logged whatever the sample rate")
+	}
+	config.SampleRate = 1
+	if !config.sampled(http.StatusOK) {
+		t.Errorf("expected every request to be logged at a sample rate of 1")
+	}
+}
@@ -0,0 +1,144 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// field is a key and value of a structured access log line. Values are strings, ints and bools.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// fields returns the fields of the access log line of the request, in the order they are
+// written. Empty fields are left out.
+func (rl *respLogger) fields(status int, latency time.Duration) []field {
+	fields := []field{
+		{"ts", rl.startTime.UTC().Format(time.RFC3339Nano)},
+		{"requestID", rl.req.Header.Get(request.RequestIDHeader)},
+		{"method", rl.req.Method},
+		{"uri", rl.req.RequestURI},
+	}
+
+	var user, verb, resource, subresource, namespace, name string
+	if rl.requestContextMapper != nil {
+		if ctx, ok := rl.requestContextMapper.Get(rl.req); ok {
+			if u, ok := request.UserFrom(ctx); ok {
+				user = u.GetName()
+			}
+			if info, ok := request.RequestInfoFrom(ctx); ok {
+				verb, resource, subresource, namespace, name = info.Verb, info.Resource, info.Subresource, info.Namespace, info.Name
+			}
+		}
+	}
+	fields = append(fields,
+		field{"user", user},
+		field{"verb", verb},
+		field{"resource", resource},
+		field{"subresource", subresource},
+		field{"namespace", namespace},
+		field{"name", name},
+	)
+
+	if rl.hijacked {
+		fields = append(fields, field{"hijacked", true})
+	} else {
+		fields = append(fields, field{"status", status})
+	}
+	fields = append(fields,
+		field{"latency", latency.String()},
+		field{"bytes", rl.bytes},
+		field{"userAgent", rl.req.UserAgent()},
+		field{"remoteIP", remoteIP(rl.req.RemoteAddr)},
+		field{"info", strings.TrimPrefix(rl.addedInfo, "\n")},
+	)
+
+	nonEmpty := fields[:0]
+	for _, f := range fields {
+		if s, ok := f.value.(string); ok && len(s) == 0 {
+			continue
+		}
+		nonEmpty = append(nonEmpty, f)
+	}
+	return nonEmpty
+}
+
+// remoteIP returns the IP of the host:port addr.
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// formatJSON returns fields as a JSON object.
+func formatJSON(fields []field) string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		value, _ := json.Marshal(f.value)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatLogfmt returns fields as key=value pairs. Values with spaces, quotes, '=' or
+// non-printable characters are quoted.
+func formatLogfmt(fields []field) string {
+	var b bytes.Buffer
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		switch v := f.value.(type) {
+		case string:
+			if strings.IndexFunc(v, needsQuoting) >= 0 {
+				v = strconv.Quote(v)
+			}
+			b.WriteString(v)
+		case int:
+			b.WriteString(strconv.Itoa(v))
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10))
+		case bool:
+			b.WriteString(strconv.FormatBool(v))
+		}
+	}
+	return b.String()
+}
+
+func needsQuoting(r rune) bool {
+	return r == ' ' || r == '"' || r == '=' || !unicode.IsPrint(r)
+}
@@ -17,12 +17,14 @@ package server
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/golang/glog"
 
 	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
 	genericapifilters "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/filters"
 	genericfilters "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/filters"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/httplog"
 	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
 
@@ -32,21 +34,23 @@ import (
 
 func BuildInsecureHandlerChain(apiHandler http.Handler, c *Config) http.Handler {
 	handler := apiHandler
-	// FIXME (rantuttl)
-	/*
-	if utilfeature.DefaultFeatureGate.Enabled(features.AdvancedAuditing) {
-		handler = genericapifilters.WithAudit(handler, c.RequestContextMapper, c.AuditBackend, c.AuditPolicyChecker, c.LongRunningFunc)
-	} else {
-		handler = genericapifilters.WithLegacyAudit(handler, c.RequestContextMapper, c.LegacyAuditWriter)
-	}
-	*/
+	handler = genericfilters.WithPriorityAndFairness(handler, c.RequestContextMapper, c.LongRunningFunc, c.FlowControl, c.Serializer)
+	handler = genericapifilters.WithAudit(handler, c.RequestContextMapper, c.AuditBackend, c.AuditPolicyChecker, c.LongRunningFunc)
 	handler = genericapifilters.WithAuthentication(handler, c.RequestContextMapper, insecureSuperuser{}, nil)
-	//handler = genericfilters.WithCORS(handler, c.CorsAllowedOriginList, nil, nil, nil, "true")
+	handler = genericfilters.WithCORS(handler, c.CorsAllowedOriginList, c.CorsAllowedMethods, c.CorsAllowedHeaders, c.CorsExposedHeaders, "true")
 	handler = genericfilters.WithPanicRecovery(handler)
 	// MaxInFlight & TimeoutForNonLongRunningRequests must be adjacent handlers
 	//handler = genericfilters.WithTimeoutForNonLongRunningRequests(handler, c.RequestContextMapper, c.LongRunningFunc)
-	//handler = genericfilters.WithMaxInFlightLimit(handler, c.MaxRequestsInFlight, c.MaxMutatingRequestsInFlight, c.RequestContextMapper, c.LongRunningFunc)
+	if c.FlowControl == nil {
+		handler = genericfilters.WithMaxInFlightLimit(handler, c.MaxRequestsInFlight, c.MaxMutatingRequestsInFlight, c.RequestContextMapper, c.LongRunningFunc, c.Serializer)
+	}
+	handler = genericfilters.WithWaitGroup(handler, c.RequestContextMapper, c.HandlerChainWaitGroup, c.Serializer)
+	if c.EnableMetrics {
+		handler = genericapifilters.WithMetrics(handler, c.RequestContextMapper, c.LongRunningFunc)
+	}
 	handler = genericapifilters.WithRequestInfo(handler, NewRequestInfoResolver(c), c.RequestContextMapper)
+	handler = genericapifilters.WithRequestID(handler, c.RequestContextMapper)
+	handler = httplog.Handler(handler, c.RequestContextMapper, c.AccessLogConfig)
 	handler = apirequest.WithRequestContext(handler, c.RequestContextMapper)
 	return handler
 }
@@ -81,13 +85,14 @@ func (s *InsecureServingInfo) NewLoopbackClientConfig(token string) (*rest.Confi
 */
 
 // NonBlockingRun spawns the insecure http server. An error is
-// returned if the ports cannot be listened on.
-func NonBlockingRun(insecureServingInfo *InsecureServingInfo, insecureHandler http.Handler, stopCh <-chan struct{}) error {
+// returned if the ports cannot be listened on. Once stopCh is closed, the server
+// stops accepting connections and waits up to shutdownTimeout for the active ones.
+func NonBlockingRun(insecureServingInfo *InsecureServingInfo, insecureHandler http.Handler, shutdownTimeout time.Duration, stopCh <-chan struct{}) error {
 	// Use an internal stop channel to allow cleanup of the listeners on error.
 	internalStopCh := make(chan struct{})
 
 	if insecureServingInfo != nil && insecureHandler != nil {
-		if err := serveInsecurely(insecureServingInfo, insecureHandler, internalStopCh); err != nil {
+		if err := serveInsecurely(insecureServingInfo, insecureHandler, shutdownTimeout, internalStopCh); err != nil {
 			close(internalStopCh)
 			return err
 		}
@@ -107,7 +112,7 @@ func NonBlockingRun(insecureServingInfo *InsecureServingInfo, insecureHandler ht
 // serveInsecurely run the insecure http server. It fails only if the initial listen
 // call fails. The actual server loop (stoppable by closing stopCh) runs in a go
 // routine, i.e. serveInsecurely does not block.
-func serveInsecurely(insecureServingInfo *InsecureServingInfo, insecureHandler http.Handler, stopCh <-chan struct{}) error {
+func serveInsecurely(insecureServingInfo *InsecureServingInfo, insecureHandler http.Handler, shutdownTimeout time.Duration, stopCh <-chan struct{}) error {
 	insecureServer := &http.Server{
 		Addr:           insecureServingInfo.BindAddress,
 		Handler:        insecureHandler,
@@ -115,7 +120,7 @@ func serveInsecurely(insecureServingInfo *InsecureServingInfo, insecureHandler h
 	}
 	glog.Infof("Serving insecurely on %s", insecureServingInfo.BindAddress)
 	var err error
-	_, err = RunServer(insecureServer, insecureServingInfo.BindNetwork, stopCh)
+	_, err = RunServer(insecureServer, insecureServingInfo.BindNetwork, shutdownTimeout, stopCh)
 	return err
 }
 
@@ -16,6 +16,7 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -39,59 +40,63 @@ const (
 // be loaded or the initial listen call fails. The actual server loop (stoppable by closing
 // stopCh) runs in a go routine, i.e. serveSecurely does not block.
 func (s *GenericAPIServer) serveSecurely(stopCh <-chan struct{}) error {
-	secureServer := &http.Server{
-		Addr:           s.SecureServingInfo.BindAddress,
-		Handler:        s.Handler,
-		MaxHeaderBytes: 1 << 20,
-		TLSConfig: &tls.Config{
-			// FIXME (rantuttl): remove SNI if not needed.
-			//NameToCertificate: s.SecureServingInfo.SNICerts,
-			// Can't use SSLv3 because of POODLE and BEAST
-			// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
-			// Can't use TLSv1.1 because of RC4 cipher usage
-			MinVersion: tls.VersionTLS12,
-			// enable HTTP2 for go's 1.7 HTTP Server
-			NextProtos: []string{"h2", "http/1.1"},
-		},
+	tlsConfig := &tls.Config{
+		// Can't use SSLv3 because of POODLE and BEAST
+		// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
+		// Can't use TLSv1.1 because of RC4 cipher usage
+		MinVersion: tls.VersionTLS12,
+		// enable HTTP2 for go's 1.7 HTTP Server
+		NextProtos: []string{"h2", "http/1.1"},
 	}
 
 	if s.SecureServingInfo.MinTLSVersion > 0 {
-		secureServer.TLSConfig.MinVersion = s.SecureServingInfo.MinTLSVersion
+		tlsConfig.MinVersion = s.SecureServingInfo.MinTLSVersion
 	}
 	if len(s.SecureServingInfo.CipherSuites) > 0 {
-		secureServer.TLSConfig.CipherSuites = s.SecureServingInfo.CipherSuites
+		tlsConfig.CipherSuites = s.SecureServingInfo.CipherSuites
 	}
 
+	// The serving cert, the SNI certs and the client CA are reloaded when their files change,
+	// each new connection is served the TLS config built from the current ones.
+	dynamicCertificateController := newDynamicServingCertificateController(
+		tlsConfig,
+		s.SecureServingInfo.ClientCA,
+		s.SecureServingInfo.Cert,
+		s.SecureServingInfo.SNICerts,
+	)
+	if err := dynamicCertificateController.RunOnce(); err != nil {
+		return err
+	}
+	go dynamicCertificateController.Run(stopCh)
+	if s.SecureServingInfo.ClientCA != nil {
+		go s.SecureServingInfo.ClientCA.Run(stopCh)
+	}
 	if s.SecureServingInfo.Cert != nil {
-		secureServer.TLSConfig.Certificates = []tls.Certificate{*s.SecureServingInfo.Cert}
+		go s.SecureServingInfo.Cert.Run(stopCh)
+	}
+	for _, sniCert := range s.SecureServingInfo.SNICerts {
+		go sniCert.Run(stopCh)
 	}
 
-	// FIXME (rantuttl): remove SNI if not needed.
-	// append all named certs. Otherwise, the go tls stack will think no SNI processing
-	// is necessary because there is only one cert anyway.
-	// Moreover, if ServerCert.CertFile/ServerCert.KeyFile are not set, the first SNI
-	// cert will become the default cert. That's what we expect anyway.
-	//for _, c := range s.SecureServingInfo.SNICerts {
-	//	secureServer.TLSConfig.Certificates = append(secureServer.TLSConfig.Certificates, *c)
-	//}
-
-	if s.SecureServingInfo.ClientCA != nil {
-		// Populate PeerCertificates in requests, but don't reject connections without certificates
-		// This allows certificates to be validated by authenticators, while still allowing other auth types
-		secureServer.TLSConfig.ClientAuth = tls.RequestClientCert
-		// Specify allowed CAs for client certificates
-		secureServer.TLSConfig.ClientCAs = s.SecureServingInfo.ClientCA
+	secureServer := &http.Server{
+		Addr:           s.SecureServingInfo.BindAddress,
+		Handler:        s.Handler,
+		MaxHeaderBytes: 1 << 20,
+		TLSConfig:      tlsConfig.Clone(),
 	}
+	secureServer.TLSConfig.GetConfigForClient = dynamicCertificateController.GetConfigForClient
 
 	glog.Infof("Serving securely on %s", s.SecureServingInfo.BindAddress)
 	var err error
-	s.effectiveSecurePort, err = RunServer(secureServer, s.SecureServingInfo.BindNetwork, stopCh)
+	s.effectiveSecurePort, err = RunServer(secureServer, s.SecureServingInfo.BindNetwork, s.shutdownTimeout, stopCh)
 	return err
 }
 
 // RunServer listens on the given port, then spawns a go-routine continuously serving
-// until the stopCh is closed. The port is returned. This function does not block.
-func RunServer(server *http.Server, network string, stopCh <-chan struct{}) (int, error) {
+// until the stopCh is closed. The server then stops accepting connections, and waits up
+// to shutdownTimeout for the active ones to go idle before closing them. The port is
+// returned. This function does not block.
+func RunServer(server *http.Server, network string, shutdownTimeout time.Duration, stopCh <-chan struct{}) (int, error) {
 	if len(server.Addr) == 0 {
 		return 0, errors.New("address cannot be empty")
 	}
@@ -112,10 +117,15 @@ func RunServer(server *http.Server, network string, stopCh <-chan struct{}) (int
 		return 0, fmt.Errorf("invalid listen address: %q", ln.Addr().String())
 	}
 
-	// Stop the server by closing the listener
+	// Shutdown server gracefully.
 	go func() {
 		<-stopCh
-		ln.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			glog.Warningf("Closing the connections still active on %s: %v", tcpAddr.String(), err)
+			server.Close()
+		}
 	}()
 
 	go func() {
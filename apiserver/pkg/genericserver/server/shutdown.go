@@ -0,0 +1,131 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+
+	utilerrors "github.com/rantuttl/cloudops/apimachinery/pkg/util/errors"
+)
+
+// ShutdownHookFunc is run while the server shuts down. Pre-shutdown hooks run as soon as
+// shutdown starts, while requests are still served. Post-shutdown hooks run once the
+// requests in flight have drained.
+type ShutdownHookFunc func() error
+
+// AddPreShutdownHook adds a hook run when shutdown starts, before the server stops
+// accepting connections. Hook names must be unique.
+func (s *GenericAPIServer) AddPreShutdownHook(name string, hook ShutdownHookFunc) error {
+	return s.addShutdownHook(s.preShutdownHooks, name, hook)
+}
+
+// AddPostShutdownHook adds a hook run once the requests in flight have drained and the
+// audit backend is flushed. Hook names must be unique.
+func (s *GenericAPIServer) AddPostShutdownHook(name string, hook ShutdownHookFunc) error {
+	return s.addShutdownHook(s.postShutdownHooks, name, hook)
+}
+
+func (s *GenericAPIServer) addShutdownHook(hooks map[string]ShutdownHookFunc, name string, hook ShutdownHookFunc) error {
+	if len(name) == 0 {
+		return fmt.Errorf("missing name for the shutdown hook")
+	}
+	if hook == nil {
+		return fmt.Errorf("hook func may not be nil: %q", name)
+	}
+
+	s.shutdownHookLock.Lock()
+	defer s.shutdownHookLock.Unlock()
+	if _, exists := hooks[name]; exists {
+		return fmt.Errorf("unable to add %q because it is already registered", name)
+	}
+	hooks[name] = hook
+	return nil
+}
+
+// runShutdownHooks runs hooks in the order of their names. A failing hook is logged and does
+// not stop the others, the server is going away anyway.
+func (s *GenericAPIServer) runShutdownHooks(kind string, hooks map[string]ShutdownHookFunc) {
+	s.shutdownHookLock.Lock()
+	defer s.shutdownHookLock.Unlock()
+
+	names := []string{}
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, name := range names {
+		glog.V(4).Infof("Running %s hook %q", kind, name)
+		if err := hooks[name](); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %q failed: %v", kind, name, err))
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		glog.Errorf("%v", err)
+	}
+}
+
+// ListenersStopCh is closed once the server stops accepting connections on shutdown. The
+// servers sharing the handler chain, e.g. the insecure one, should stop with it.
+func (s *GenericAPIServer) ListenersStopCh() <-chan struct{} {
+	return s.listenersStopCh
+}
+
+// shutdown shuts the server down gracefully:
+//
+//  1. /readyz starts failing, and the pre-shutdown hooks run,
+//  2. requests are still served for shutdownDelayDuration, for load balancers to notice,
+//  3. the listeners are closed and the open watches are ended with a final event,
+//  4. the requests in flight are waited for, up to shutdownTimeout,
+//  5. the audit backend is flushed, and the post-shutdown hooks run.
+func (s *GenericAPIServer) shutdown() {
+	glog.Infof("Shutting down, /readyz fails from now on")
+	close(s.readinessStopCh)
+	s.runShutdownHooks("pre-shutdown", s.preShutdownHooks)
+
+	if s.shutdownDelayDuration > 0 {
+		glog.Infof("Serving requests for another %v", s.shutdownDelayDuration)
+		time.Sleep(s.shutdownDelayDuration)
+	}
+
+	glog.Infof("No longer accepting connections, waiting up to %v for the requests in flight", s.shutdownTimeout)
+	close(s.listenersStopCh)
+	if s.handlerChainWaitGroup != nil {
+		drained := make(chan struct{})
+		go func() {
+			s.handlerChainWaitGroup.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-time.After(s.shutdownTimeout):
+			glog.Warningf("Requests still in flight after %v, shutting down anyway", s.shutdownTimeout)
+		}
+	}
+
+	// Don't lose the audit events of the last requests.
+	close(s.auditStopCh)
+	if s.auditBackend != nil {
+		s.auditBackend.Shutdown()
+	}
+	s.runShutdownHooks("post-shutdown", s.postShutdownHooks)
+	glog.Infof("Shutdown complete")
+}
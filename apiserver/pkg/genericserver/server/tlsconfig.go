@@ -0,0 +1,164 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/dynamiccertificates"
+)
+
+// dynamicServingCertificateController serves the TLS config of the secure server, rebuilt
+// whenever its serving cert, SNI certs or client CA change. Handshakes started after a rotation
+// use the new certificates, the connections already open are not affected.
+type dynamicServingCertificateController struct {
+	// baseTLSConfig is the static portion of the TLS config, e.g. the versions and ciphers.
+	baseTLSConfig *tls.Config
+
+	clientCA    dynamiccertificates.CAContentProvider
+	servingCert dynamiccertificates.CertKeyContentProvider
+	sniCerts    []dynamiccertificates.SNICertKeyContentProvider
+
+	// currentConfig is the *tls.Config handed to new connections.
+	currentConfig atomic.Value
+	// queue is signaled when a certificate changes.
+	queue chan struct{}
+}
+
+var _ dynamiccertificates.Listener = &dynamicServingCertificateController{}
+
+// newDynamicServingCertificateController returns a controller serving the TLS config built from
+// baseTLSConfig and the current certificates.
+func newDynamicServingCertificateController(
+	baseTLSConfig *tls.Config,
+	clientCA dynamiccertificates.CAContentProvider,
+	servingCert dynamiccertificates.CertKeyContentProvider,
+	sniCerts []dynamiccertificates.SNICertKeyContentProvider,
+) *dynamicServingCertificateController {
+	c := &dynamicServingCertificateController{
+		baseTLSConfig: baseTLSConfig,
+		clientCA:      clientCA,
+		servingCert:   servingCert,
+		sniCerts:      sniCerts,
+		queue:         make(chan struct{}, 1),
+	}
+	if clientCA != nil {
+		clientCA.AddListener(c)
+	}
+	if servingCert != nil {
+		servingCert.AddListener(c)
+	}
+	for _, sniCert := range sniCerts {
+		sniCert.AddListener(c)
+	}
+	return c
+}
+
+// GetConfigForClient is the tls.Config#GetConfigForClient of the secure server.
+func (c *dynamicServingCertificateController) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	config, ok := c.currentConfig.Load().(*tls.Config)
+	if !ok {
+		return nil, fmt.Errorf("dynamiccertificates: configuration not ready")
+	}
+	return config, nil
+}
+
+// Enqueue implements dynamiccertificates.Listener, a certificate changed.
+func (c *dynamicServingCertificateController) Enqueue() {
+	select {
+	case c.queue <- struct{}{}:
+	default:
+		// A sync is already pending.
+	}
+}
+
+// RunOnce builds the TLS config from the current certificates.
+func (c *dynamicServingCertificateController) RunOnce() error {
+	newConfig := c.baseTLSConfig.Clone()
+
+	if c.clientCA != nil {
+		// Populate PeerCertificates in requests, but don't reject connections without certificates
+		// This allows certificates to be validated by authenticators, while still allowing other auth types
+		newConfig.ClientAuth = tls.RequestClientCert
+		// Specify allowed CAs for client certificates
+		newConfig.ClientCAs = c.clientCA.CurrentCABundleContent()
+	}
+
+	if c.servingCert != nil {
+		newConfig.Certificates = []tls.Certificate{*c.servingCert.CurrentCertKeyContent()}
+	}
+
+	if len(c.sniCerts) > 0 {
+		namedCerts := make([]NamedTLSCert, 0, len(c.sniCerts))
+		for _, sniCert := range c.sniCerts {
+			namedCerts = append(namedCerts, NamedTLSCert{
+				TLSCert: *sniCert.CurrentCertKeyContent(),
+				Names:   sniCert.SNINames(),
+			})
+		}
+		byName, err := GetNamedCertificateMap(namedCerts)
+		if err != nil {
+			return fmt.Errorf("unable to build the SNI certificate map: %v", err)
+		}
+		// If ServerCert is not set, the first SNI cert becomes the default cert.
+		if len(newConfig.Certificates) == 0 {
+			newConfig.Certificates = []tls.Certificate{namedCerts[0].TLSCert}
+		}
+		newConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return getNamedCertificate(byName, hello.ServerName), nil
+		}
+	}
+
+	c.currentConfig.Store(newConfig)
+	return nil
+}
+
+// Run rebuilds the TLS config whenever a certificate changes, until stopCh is closed.
+func (c *dynamicServingCertificateController) Run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-c.queue:
+			if err := c.RunOnce(); err != nil {
+				glog.Errorf("Unable to update the serving certificates: %v", err)
+				continue
+			}
+			glog.Infof("Updated the serving certificates")
+		}
+	}
+}
+
+// getNamedCertificate returns the certificate of serverName, or of its wildcard name, or nil
+// for the default certificate to be served.
+func getNamedCertificate(byName map[string]*tls.Certificate, serverName string) *tls.Certificate {
+	name := strings.TrimSuffix(strings.ToLower(serverName), ".")
+	if cert, ok := byName[name]; ok {
+		return cert
+	}
+	// e.g. "*.example.com" for "console.example.com"
+	if i := strings.Index(name, "."); i > 0 {
+		if cert, ok := byName["*"+name[i:]]; ok {
+			return cert
+		}
+	}
+	return nil
+}
@@ -16,6 +16,9 @@
 package master
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/golang/glog"
 
 	corev1 "github.com/rantuttl/cloudops/apiserver/pkg/api/core/v1"
@@ -23,8 +26,12 @@ import (
 	genericregistry "github.com/rantuttl/cloudops/apiserver/pkg/registry/generic"
 	genericapiserver "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
 	serverstorage "github.com/rantuttl/cloudops/apiserver/pkg/server/storage"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/healthz"
 )
 
+// backendPingTimeout bounds how long the readiness check waits for a backend server.
+const backendPingTimeout = 2 * time.Second
+
 type Config struct {
 	GenericConfig *genericapiserver.Config
 	APIResourceConfigSource  serverstorage.APIResourceConfigSource
@@ -63,6 +70,12 @@ func (c completedConfig) New(delegate genericapiserver.DelegationTarget) (*Maste
 		GenericAPIServer: s,
 	}
 
+	if c.StorageFactory != nil {
+		if err := s.AddReadyzChecks(backendHealthCheck(c.StorageFactory)); err != nil {
+			return nil, err
+		}
+	}
+
 	restStorageProviders := []RESTStorageProvider{
 		corerest.RESTStorageProvider{},
 	}
@@ -70,6 +83,13 @@ func (c completedConfig) New(delegate genericapiserver.DelegationTarget) (*Maste
 
 	return m, nil
 }
+// backendHealthCheck fails while none of the backend servers can be reached.
+func backendHealthCheck(storageFactory serverstorage.StorageFactory) healthz.HealthChecker {
+	return healthz.NamedCheck("backend", func(r *http.Request) error {
+		return serverstorage.PingBackends(storageFactory.Backends(), backendPingTimeout)
+	})
+}
+
 // RESTStorageProvider is a factory type for REST storage.
 type RESTStorageProvider interface {
         GroupName() string
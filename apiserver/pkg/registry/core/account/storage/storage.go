@@ -23,8 +23,11 @@ import (
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/generic"
 	genericapirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 	genericregistry "github.com/rantuttl/cloudops/apiserver/pkg/registry/generic/registry"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
 	apierrors "github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
 )
 
@@ -37,16 +40,16 @@ func NewREST(optsGetter generic.RESTOptionsGetter) *REST {
 		NewFunc:		func() runtime.Object { return &core.Account{} },
 		NewListFunc:		func() runtime.Object { return &core.AccountList{} },
 		QualifiedResource:	core.Resource("accounts"),
+		PredicateFunc:		account.MatchAccount,
 		CreateStrategy:		account.Strategy,
 		UpdateStrategy:		account.Strategy,
 		DeleteStrategy:		account.Strategy,
 		ReturnDeletedObject:	true,
 	}
-	// FIXME (rantuttl)
-	//options := &generic.StoreOptions{RESTOptions: optsGetter, AttrFunc: account.GetAttrs}
 	options := &generic.StoreOptions{
 		RESTOptions: optsGetter,
 		Transformer: &accountTransformer{resource: "accounts"},
+		AttrFunc:    account.GetAttrs,
 	}
 	if err := store.CompleteWithOptions(options); err != nil {
 		panic(err)
@@ -54,6 +57,30 @@ func NewREST(optsGetter generic.RESTOptionsGetter) *REST {
 	return &REST{store}
 }
 
+// Implement ShortNamesProvider
+var _ rest.ShortNamesProvider = &REST{}
+
+// ShortNames implements the ShortNamesProvider interface. Returns a list of short names for a resource.
+func (r *REST) ShortNames() []string {
+	return []string{"acct"}
+}
+
+// Implement CategoriesProvider
+var _ rest.CategoriesProvider = &REST{}
+
+// Categories implements the CategoriesProvider interface. Returns a list of categories a resource is part of.
+func (r *REST) Categories() []string {
+	return []string{"all"}
+}
+
+// Implement TableConvertor
+var _ rest.TableConvertor = &REST{}
+
+// ConvertToTable implements the TableConvertor interface.
+func (r *REST) ConvertToTable(ctx genericapirequest.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return r.store.ConvertToTable(ctx, object, tableOptions)
+}
+
 func (r *REST) New() runtime.Object {
 	return r.store.New() // Calls the above NewFunc
 }
@@ -62,10 +89,20 @@ func (r *REST) NewList() runtime.Object {
 	return r.store.NewList() // Calls the above NewListFunc
 }
 
-// TODO (rantuttl): Add other methods List, Update, Delete, etc...
+func (r *REST) List(ctx genericapirequest.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return r.store.List(ctx, options)
+}
+
+func (r *REST) Watch(ctx genericapirequest.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	return r.store.Watch(ctx, options)
+}
+
+func (r *REST) Create(ctx genericapirequest.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	return r.store.Create(ctx, obj, options)
+}
 
-func (r *REST) Create(ctx genericapirequest.Context, obj runtime.Object, includeUninitialized bool) (runtime.Object, error) {
-	return r.store.Create(ctx, obj, includeUninitialized)
+func (r *REST) Update(ctx genericapirequest.Context, name string, objInfo rest.UpdatedObjectInfo, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	return r.store.Update(ctx, name, objInfo, options)
 }
 
 // TODO (rantuttl): Switch to GetterWithOptions interface support. This will allow us to add query parms to
@@ -95,7 +132,7 @@ func (r *REST) Delete(ctx genericapirequest.Context, name string, options *metav
 	if options.Preconditions.UID == nil {
 		options.Preconditions.UID = &account.UID
 	} else if *options.Preconditions.UID != account.UID {
-		apierrors.NewConflict(
+		return nil, false, apierrors.NewConflict(
 			core.Resource("accounts"),
 			name,
 			fmt.Errorf("Precondition failed: UID in precondition: %v, UID in object meta: %v", *options.Preconditions.UID, account.UID),
@@ -36,7 +36,7 @@ func (a *accountTransformer) BackendTransformerInitializer(c backend.Config) err
 	// TODO (rantuttl): When config carries the backend type, switch on it to select the proper transformer
 	// Right now, there's only CAL
 	t := cal.NewCalResourceTransformer(a.resource)
-	verbs := []cal.Verb{cal.CREATE, cal.DELETE, cal.UPDATE, cal.GET}
+	verbs := []cal.Verb{cal.CREATE, cal.DELETE, cal.UPDATE, cal.GET, cal.LIST, cal.WATCH}
 	for _, v := range verbs {
 		gqlBody, err := t.NewGraphQLBody(v)
 		if err != nil {
@@ -111,6 +111,43 @@ func (a *accountTransformer) BackendTransformerInitializer(c backend.Config) err
 	frags["fieldList"] = &cal.Fragment{GqlTypeRef: cal.GQLACCOUNT, FragFields: fragfields}
 	gqlBody.OpBody.FragRefs = frags
 
+	// UPDATE
+	// Updates send the whole object, just like CREATE
+	updateBody := t.GraphQLBodies[cal.UPDATE]
+	updateBody.Parameters = gqlBody.Parameters
+	updateBody.OpBody.Arguments = gqlBody.OpBody.Arguments
+	updateBody.OpBody.Fields = gqlBody.OpBody.Fields
+	updateBody.OpBody.FragRefs = gqlBody.OpBody.FragRefs
+
+	// LIST & WATCH
+	// Label and field selectors are pushed down to CAL as filter arguments
+	for _, v := range []cal.Verb{cal.LIST, cal.WATCH} {
+		gqlBody := t.GraphQLBodies[v]
+		gqlBody.Parameters[cal.LABELSELECTOR] = cal.GqlParameter{GqlType: cal.GQLSTRING, GqlTypeNullable: cal.NULLABLE}
+		gqlBody.Parameters[cal.FIELDSELECTOR] = cal.GqlParameter{GqlType: cal.GQLSTRING, GqlTypeNullable: cal.NULLABLE}
+		gqlBody.OpBody.Arguments[cal.ARGLABELSELECTOR] = cal.LABELSELECTOR
+		gqlBody.OpBody.Arguments[cal.ARGFIELDSELECTOR] = cal.FIELDSELECTOR
+		listFields := []*cal.Field{}
+		for _, f := range []cal.Argument{cal.ARGKIND, cal.ARGAPIVERSION, cal.ARGMETADATA, cal.ARGSPEC, cal.ARGSTATUS} {
+			field := &cal.Field{
+				FieldName:	f,
+			}
+			if f == cal.ARGMETADATA {
+				for _, v := range cal.MetadataMap {
+					field.SubFields = append(field.SubFields, &cal.Field{FieldName: cal.Argument(v)})
+				}
+			}
+			listFields = append(listFields, field)
+		}
+		gqlBody.OpBody.Fields = listFields
+	}
+	// Lists are paged with GraphQL cursors
+	gqlBody = t.GraphQLBodies[cal.LIST]
+	gqlBody.Parameters[cal.FIRST] = cal.GqlParameter{GqlType: cal.GQLINT, GqlTypeNullable: cal.NULLABLE}
+	gqlBody.Parameters[cal.AFTER] = cal.GqlParameter{GqlType: cal.GQLSTRING, GqlTypeNullable: cal.NULLABLE}
+	gqlBody.OpBody.Arguments[cal.ARGFIRST] = cal.FIRST
+	gqlBody.OpBody.Arguments[cal.ARGAFTER] = cal.AFTER
+
 	a.transformer = t
 	return a.transformer.BackendTransformerInitializer(c)
 }
@@ -16,10 +16,19 @@
 package account
 
 import (
+	"fmt"
+	"reflect"
+
 	"github.com/rantuttl/cloudops/apiserver/pkg/api"
 	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/generic"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
 	"github.com/rantuttl/cloudops/apiserver/pkg/storage/names"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/fields"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/labels"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
 	"github.com/rantuttl/cloudops/apiserver/pkg/api/validation"
 	genericapirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/util/validation/field"
@@ -47,7 +56,6 @@ func (accountStrategy) PrepareForCreate(ctx genericapirequest.Context, obj runti
 	account.Status = core.AccountStatus{
 		Phase: core.AccountActive,
 	}
-	account.Generation = 1
 }
 
 func (accountStrategy) Validate(ctx genericapirequest.Context, obj runtime.Object) field.ErrorList {
@@ -58,8 +66,67 @@ func (accountStrategy) Validate(ctx genericapirequest.Context, obj runtime.Objec
 
 func (accountStrategy) Canonicalize(obj runtime.Object) {}
 
-// TODO (rantuttl): Place Update strategy functions here...
-
-// FIXME (rantuttl): Unstub these methods
+// PrepareForUpdate clears fields that are not allowed to be set by end users on update.
 func (accountStrategy) PrepareForUpdate(ctx genericapirequest.Context, obj, old runtime.Object) {
+	newAccount := obj.(*core.Account)
+	oldAccount := old.(*core.Account)
+	newAccount.Status = oldAccount.Status
+
+	// Any changes to the spec increment the generation number.
+	if !reflect.DeepEqual(oldAccount.Spec, newAccount.Spec) {
+		newAccount.Generation = oldAccount.Generation + 1
+	}
+}
+
+// ValidateUpdate is the default update validation for an end user.
+func (accountStrategy) ValidateUpdate(ctx genericapirequest.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidateAccountUpdate(obj.(*core.Account), old.(*core.Account))
+}
+
+var accountColumns = []metav1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Format: "name", Description: "Name of the account."},
+	{Name: "Phase", Type: "string", Description: "Phase is the current lifecycle phase of the account."},
+	{Name: "Age", Type: "string", Description: "Time since the account was created."},
+}
+
+// ConvertToTable renders accounts with their name, phase and age.
+func (accountStrategy) ConvertToTable(ctx genericapirequest.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return rest.MetaToTable(object, accountColumns, func(obj runtime.Object, m metav1.Object) ([]interface{}, error) {
+		account, ok := obj.(*core.Account)
+		if !ok {
+			return nil, fmt.Errorf("not an account")
+		}
+		return []interface{}{
+			account.Name,
+			string(account.Status.Phase),
+			rest.TranslateTimestamp(account.CreationTimestamp),
+		}, nil
+	})
+}
+
+// GetAttrs returns labels and fields of a given object for filtering purposes.
+func GetAttrs(obj runtime.Object) (labels.Set, fields.Set, bool, error) {
+	accountObj, ok := obj.(*core.Account)
+	if !ok {
+		return nil, nil, false, fmt.Errorf("not an account")
+	}
+	return labels.Set(accountObj.Labels), AccountToSelectableFields(accountObj), false, nil
+}
+
+// MatchAccount returns a generic matcher for a given label and field selector.
+func MatchAccount(label labels.Selector, field fields.Selector) storage.SelectionPredicate {
+	return storage.SelectionPredicate{
+		Label:    label,
+		Field:    field,
+		GetAttrs: GetAttrs,
+	}
+}
+
+// AccountToSelectableFields returns a field set that represents the object
+func AccountToSelectableFields(account *core.Account) fields.Set {
+	objectMetaFieldsSet := generic.ObjectMetaFieldsSet(&account.ObjectMeta, false)
+	specificFieldsSet := fields.Set{
+		"status.phase": string(account.Status.Phase),
+	}
+	return generic.MergeFieldsSets(objectMetaFieldsSet, specificFieldsSet)
 }
@@ -0,0 +1,178 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+
+package account
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	_ "github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core/install"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/fields"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/labels"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/types"
+	"github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+)
+
+func TestMatchAccount(t *testing.T) {
+	account := &core.Account{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "acme",
+			Labels: map[string]string{"tier": "gold"},
+		},
+		Status: core.AccountStatus{Phase: core.AccountActive},
+	}
+
+	testcases := map[string]struct {
+		label    string
+		field    string
+		expected bool
+	}{
+		"everything":            {label: "", field: "", expected: true},
+		"label match":           {label: "tier=gold", field: "", expected: true},
+		"label mismatch":        {label: "tier=silver", field: "", expected: false},
+		"phase match":           {label: "", field: "status.phase=Active", expected: true},
+		"phase mismatch":        {label: "", field: "status.phase=Inactive", expected: false},
+		"name match":            {label: "", field: "metadata.name=acme", expected: true},
+		"label and field match": {label: "tier=gold", field: "status.phase=Active", expected: true},
+		"label match only":      {label: "tier=gold", field: "status.phase=Terminating", expected: false},
+	}
+
+	for name, tc := range testcases {
+		label, err := labels.Parse(tc.label)
+		if err != nil {
+			t.Fatalf("%s: unexpected error parsing label selector: %v", name, err)
+		}
+		field, err := fields.ParseSelector(tc.field)
+		if err != nil {
+			t.Fatalf("%s: unexpected error parsing field selector: %v", name, err)
+		}
+		predicate := MatchAccount(label, field)
+		matches, err := predicate.Matches(account)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if matches != tc.expected {
+			t.Errorf("%s: expected match %v, got %v", name, tc.expected, matches)
+		}
+	}
+}
+
+func TestAccountStrategyServerFields(t *testing.T) {
+	ctx := request.NewContext()
+	account := &core.Account{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "acme",
+			UID:             types.UID("client-uid"),
+			ResourceVersion: "7",
+			Generation:      9,
+		},
+	}
+	if err := rest.BeforeCreate(Strategy, ctx, account); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if account.UID == "client-uid" || len(account.UID) == 0 {
+		t.Errorf("expected a server assigned UID, got %q", account.UID)
+	}
+	if account.CreationTimestamp.IsZero() {
+		t.Errorf("expected creationTimestamp to be set")
+	}
+	if account.ResourceVersion != "" || account.Generation != 1 {
+		t.Errorf("expected resourceVersion \"\" and generation 1, got %q and %d", account.ResourceVersion, account.Generation)
+	}
+
+	old := account
+	updated := &core.Account{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "acme",
+			UID:             types.UID("other-uid"),
+			ResourceVersion: "8",
+			Generation:      5,
+		},
+		Status: core.AccountStatus{Phase: core.AccountInactive},
+	}
+	if err := rest.BeforeUpdate(Strategy, ctx, updated, old); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.UID != old.UID || !updated.CreationTimestamp.Equal(old.CreationTimestamp) {
+		t.Errorf("expected UID and creationTimestamp to be carried over, got %q and %v", updated.UID, updated.CreationTimestamp)
+	}
+	if updated.Generation != old.Generation {
+		t.Errorf("expected generation %d without a spec change, got %d", old.Generation, updated.Generation)
+	}
+	if updated.ResourceVersion != "8" {
+		t.Errorf("expected the client resourceVersion to be kept as a precondition, got %q", updated.ResourceVersion)
+	}
+	if updated.Status.Phase != core.AccountActive {
+		t.Errorf("expected status to be reset on update, got %q", updated.Status.Phase)
+	}
+
+	renamed := &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	if err := rest.BeforeUpdate(Strategy, ctx, renamed, old); err == nil {
+		t.Errorf("expected an error renaming an account")
+	}
+}
+
+func TestAccountConvertToTable(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	list := &core.AccountList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "10"},
+		Items: []core.Account{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "acme", CreationTimestamp: created},
+				Status:     core.AccountStatus{Phase: core.AccountActive},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "initech"},
+				Status:     core.AccountStatus{Phase: core.AccountTerminating},
+			},
+		},
+	}
+
+	table, err := Strategy.ConvertToTable(request.NewContext(), list, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(table.ColumnDefinitions) != 3 || table.ResourceVersion != "10" {
+		t.Fatalf("unexpected table: %#v", table)
+	}
+	expected := [][]interface{}{
+		{"acme", "Active", "2h"},
+		{"initech", "Terminating", "<unknown>"},
+	}
+	if len(table.Rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(table.Rows))
+	}
+	for i, row := range table.Rows {
+		if !reflect.DeepEqual(row.Cells, expected[i]) {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], row.Cells)
+		}
+		if row.Object.Object != &list.Items[i] {
+			t.Errorf("row %d: expected the row to carry its account", i)
+		}
+	}
+
+	// a single account is one row
+	table, err = Strategy.ConvertToTable(request.NewContext(), &list.Items[0], nil)
+	if err != nil || len(table.Rows) != 1 {
+		t.Fatalf("expected one row, got %v: %v", table, err)
+	}
+}
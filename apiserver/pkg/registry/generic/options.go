@@ -17,6 +17,7 @@ package generic
 
 import (
 	"github.com/rantuttl/cloudops/apiserver/pkg/backend"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
 )
 
@@ -35,9 +36,9 @@ type RESTOptionsGetter interface {
 type StoreOptions struct {
 	RESTOptions RESTOptionsGetter
 	Transformer backend.BackendTransformer
+	AttrFunc    storage.AttrFunc
 	// FIXME (rantuttl): Decide if we need these
 	//TriggerFunc storage.TriggerPublisherFunc
-	//AttrFunc    storage.AttrFunc
 }
 
 // Implement RESTOptionsGetter so that RESTOptions can directly be used when available (i.e. tests)
@@ -23,13 +23,19 @@ import (
 
 	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
 	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	metainternalversion "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/internalversion"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/api/validation/path"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/fields"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/labels"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
 	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/watch"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
 	"github.com/rantuttl/cloudops/apiserver/pkg/backend"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
 	"github.com/rantuttl/cloudops/apiserver/pkg/registry/generic"
+	"github.com/rantuttl/cloudops/apiserver/pkg/util/dryrun"
 	genericapirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
 )
 
@@ -38,6 +44,14 @@ import (
 // object.
 type ObjectFunc func(obj runtime.Object) error
 
+// maxGenerateNameAttempts bounds how many names Create will try for an object using
+// GenerateName before giving up and asking the client to retry.
+const maxGenerateNameAttempts = 5
+
+// OptimisticLockErrorMsg is the error message returned when an update is rejected because the
+// object was modified since the client read it.
+const OptimisticLockErrorMsg = "the object has been modified; please apply your changes to the latest version and try again"
+
 type Store struct {
 	// NewFunc returns a new instance of the type this registry returns for a
 	// GET of a single object
@@ -95,6 +109,11 @@ type Store struct {
 	// ObjectNameFunc returns the name of an object or an error.
 	ObjectNameFunc func(obj runtime.Object) (string, error)
 
+	// PredicateFunc returns a matcher corresponding to the provided labels
+	// and fields. The SelectionPredicate returned should return true if the
+	// object matches the given field and label selectors.
+	PredicateFunc func(label labels.Selector, field fields.Selector) storage.SelectionPredicate
+
 	// TTLFunc returns the TTL (time to live) that objects should be persisted
 	// with. The existing parameter is the current TTL or the default for this
 	// operation. The update parameter indicates whether this is an operation
@@ -103,6 +122,10 @@ type Store struct {
 	// Objects that are persisted with a TTL are evicted once the TTL expires.
 	TTLFunc func(obj runtime.Object, existing uint64, update bool) (uint64, error)
 
+	// TableConvertor renders objects as a Table. If not set, the CreateStrategy is used when it
+	// implements rest.TableConvertor, otherwise the name and creation time of objects are shown.
+	TableConvertor rest.TableConvertor
+
 	Backend backend.Interface
 }
 
@@ -137,10 +160,9 @@ func (e *Store) CompleteWithOptions(options *generic.StoreOptions) error {
 	if options.RESTOptions == nil {
 		return fmt.Errorf("options for %s must have RESTOptions set", e.QualifiedResource.String())
 	}
-	// FIXME (rantuttl): Once we figure if we need this or not
-	//if options.AttrFunc == nil {
-	//	return fmt.Errorf("options for %s must have AttrFunc set", e.QualifiedResource.String())
-	//}
+	if options.AttrFunc == nil {
+		return fmt.Errorf("options for %s must have AttrFunc set", e.QualifiedResource.String())
+	}
 
 	opts, err := options.RESTOptions.GetRESTOptions(e.QualifiedResource)
 	if err != nil {
@@ -185,6 +207,25 @@ func (e *Store) CompleteWithOptions(options *generic.StoreOptions) error {
 		}
 	}
 
+	// Create a PredicateFunc if none provided, matching on the resource's attributes
+	if e.PredicateFunc == nil {
+		e.PredicateFunc = func(label labels.Selector, field fields.Selector) storage.SelectionPredicate {
+			return storage.SelectionPredicate{
+				Label:    label,
+				Field:    field,
+				GetAttrs: options.AttrFunc,
+			}
+		}
+	}
+
+	if e.TableConvertor == nil {
+		if tc, ok := e.CreateStrategy.(rest.TableConvertor); ok {
+			e.TableConvertor = tc
+		} else {
+			e.TableConvertor = rest.NewDefaultTableConvertor(e.QualifiedResource)
+		}
+	}
+
 	// Create a backend reference for this REST store resource
 	if e.Backend == nil {
 		e.Backend = opts.Decorator(
@@ -244,23 +285,125 @@ func (e *Store) New() runtime.Object {
 	return e.NewFunc()
 }
 
+// ConvertToTable implements rest.TableConvertor.
+func (e *Store) ConvertToTable(ctx genericapirequest.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return e.TableConvertor.ConvertToTable(ctx, object, tableOptions)
+}
+
 // NewList implements rest.Lister.
 func (e *Store) NewList() runtime.Object {
 	return e.NewListFunc()
 }
 
-// Create inserts a new item according to the unique key from the object.
-func (e *Store) Create(ctx genericapirequest.Context, obj runtime.Object, includeUninitialized bool) (runtime.Object, error) {
-	// BeforeCreate will also call CreateStrategy's PrepareForCreate()
-	if err := rest.BeforeCreate(e.CreateStrategy, ctx, obj); err != nil {
+// List returns a list of items matching labels and field according to the
+// store's PredicateFunc.
+func (e *Store) List(ctx genericapirequest.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	label := labels.Everything()
+	if options != nil && options.LabelSelector != nil {
+		label = options.LabelSelector
+	}
+	field := fields.Everything()
+	if options != nil && options.FieldSelector != nil {
+		field = options.FieldSelector
+	}
+	out, err := e.ListPredicate(ctx, e.PredicateFunc(label, field), options)
+	if err != nil {
+		return nil, err
+	}
+	if e.Decorator != nil {
+		if err := e.Decorator(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ListPredicate returns a list of all the items matching the given
+// SelectionPredicate.
+func (e *Store) ListPredicate(ctx genericapirequest.Context, p storage.SelectionPredicate, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	if options == nil {
+		// By default we should serve the request from the backend.
+		options = &metainternalversion.ListOptions{ResourceVersion: ""}
+	}
+	p.IncludeUninitialized = options.IncludeUninitialized
+	p.Limit = options.Limit
+	p.Continue = options.Continue
+	list := e.NewListFunc()
+	if name, ok := p.MatchesSingle(); ok {
+		if key, err := e.KeyFunc(ctx, name); err == nil {
+			obj := e.NewFunc()
+			if err := e.Backend.Get(ctx, key, options.ResourceVersion, obj, true); err != nil {
+				return nil, err
+			}
+			if matches, err := p.Matches(obj); err != nil || !matches {
+				return list, err
+			}
+			return list, meta.SetList(list, []runtime.Object{obj})
+		}
+		// if we cannot extract a key based on the current context, the optimization is skipped
+	}
+
+	if err := e.Backend.List(ctx, e.KeyRootFunc(ctx), options.ResourceVersion, p, list); err != nil {
+		// TODO (rantuttl): Maybe some better error type determinations
 		return nil, err
 	}
-	name, err := e.ObjectNameFunc(obj)
+	return list, nil
+}
+
+// Watch makes a matcher for the given label and field, and calls
+// WatchPredicate. If possible, you should customize PredicateFunc to produce
+// a matcher that matches by key. SelectionPredicate does this for you
+// automatically.
+func (e *Store) Watch(ctx genericapirequest.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	label := labels.Everything()
+	if options != nil && options.LabelSelector != nil {
+		label = options.LabelSelector
+	}
+	field := fields.Everything()
+	if options != nil && options.FieldSelector != nil {
+		field = options.FieldSelector
+	}
+	predicate := e.PredicateFunc(label, field)
+
+	resourceVersion := ""
+	if options != nil {
+		resourceVersion = options.ResourceVersion
+		predicate.IncludeUninitialized = options.IncludeUninitialized
+	}
+	return e.WatchPredicate(ctx, predicate, resourceVersion)
+}
+
+// WatchPredicate starts a watch for the items that matches.
+func (e *Store) WatchPredicate(ctx genericapirequest.Context, p storage.SelectionPredicate, resourceVersion string) (watch.Interface, error) {
+	// TODO (rantuttl): Watch a single key when the predicate selects a single name, once the
+	// backend can do so more cheaply than a filtered watch on the key root.
+	w, err := e.Backend.Watch(ctx, e.KeyRootFunc(ctx), resourceVersion, p)
 	if err != nil {
 		return nil, err
 	}
-	key, err := e.KeyFunc(ctx, name)
+	if e.Decorator != nil {
+		return newDecoratedWatcher(w, e.Decorator), nil
+	}
+	return w, nil
+}
+
+// Create inserts a new item according to the unique key from the object.
+func (e *Store) Create(ctx genericapirequest.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	if options == nil {
+		options = &metav1.CreateOptions{}
+	}
+	objectMeta, err := meta.Accessor(obj)
 	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	// Only names picked by the server may be regenerated; a name the client asked for is final.
+	generateName := ""
+	if len(objectMeta.GetName()) == 0 {
+		generateName = objectMeta.GetGenerateName()
+	}
+
+	// BeforeCreate will also call CreateStrategy's PrepareForCreate()
+	if err := rest.BeforeCreate(e.CreateStrategy, ctx, obj); err != nil {
 		return nil, err
 	}
 	ttl, err := e.calculateTTL(obj, 0, false)
@@ -269,9 +412,32 @@ func (e *Store) Create(ctx genericapirequest.Context, obj runtime.Object, includ
 	}
 
 	out := e.NewFunc()
-	if err := e.Backend.Create(ctx, key, obj, out, ttl); err != nil {
-		// TODO (rantuttl): Maybe some better error type determinations, such as already exists, timeout, etc.
-		return nil, err
+	for attempt := 1; ; attempt++ {
+		name, err := e.ObjectNameFunc(obj)
+		if err != nil {
+			return nil, err
+		}
+		key, err := e.KeyFunc(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		err = e.Backend.Create(ctx, key, obj, out, ttl, dryrun.IsDryRun(options.DryRun))
+		if err == nil {
+			break
+		}
+		if storage.IsNodeExist(err) {
+			err = errors.NewAlreadyExists(e.QualifiedResource, name)
+		}
+		if errors.IsAlreadyExists(err) && len(generateName) > 0 && attempt < maxGenerateNameAttempts {
+			glog.V(4).Infof("Generated name %q for %s already exists, retrying (attempt %d)", name, e.QualifiedResource.String(), attempt)
+			objectMeta.SetName(e.CreateStrategy.GenerateName(generateName))
+			continue
+		}
+		// TODO (rantuttl): Maybe some better error type determinations, such as timeout, etc.
+		if len(generateName) == 0 {
+			return nil, err
+		}
+		return nil, rest.CheckGeneratedNameError(e.CreateStrategy, err, obj)
 	}
 	if e.AfterCreate != nil {
 		if err := e.AfterCreate(out); err != nil {
@@ -282,6 +448,80 @@ func (e *Store) Create(ctx genericapirequest.Context, obj runtime.Object, includ
 	return out, nil
 }
 
+// Update performs an atomic update and set of the object. Returns the result of the update
+// or an error. The object must exist, a NotFound error is returned otherwise, so the returned
+// created flag is always false.
+func (e *Store) Update(ctx genericapirequest.Context, name string, objInfo rest.UpdatedObjectInfo, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	if options == nil {
+		options = &metav1.UpdateOptions{}
+	}
+	key, err := e.KeyFunc(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	existing := e.NewFunc()
+	if err := e.Backend.Get(ctx, key, "", existing, false); err != nil {
+		// TODO (rantuttl): Maybe some better error type determinations
+		if storage.IsNotFound(err) {
+			return nil, false, errors.NewNotFound(e.QualifiedResource, name)
+		}
+		return nil, false, err
+	}
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return nil, false, errors.NewInternalError(err)
+	}
+
+	obj, err := objInfo.UpdatedObject(ctx, existing)
+	if err != nil {
+		return nil, false, err
+	}
+	if preconditions := objInfo.Preconditions(); preconditions != nil && preconditions.UID != nil && *preconditions.UID != existingMeta.GetUID() {
+		return nil, false, errors.NewConflict(e.QualifiedResource, name, fmt.Errorf("Precondition failed: UID in precondition: %v, UID in object meta: %v", *preconditions.UID, existingMeta.GetUID()))
+	}
+	// A resourceVersion sent by the client must be the one of the existing object. The backend
+	// enforces it as well, against the version it holds at the time of the write.
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, false, errors.NewInternalError(err)
+	}
+	if rv, existingRV := objMeta.GetResourceVersion(), existingMeta.GetResourceVersion(); len(rv) > 0 && len(existingRV) > 0 && rv != existingRV {
+		return nil, false, errors.NewConflict(e.QualifiedResource, name, fmt.Errorf(OptimisticLockErrorMsg))
+	}
+
+	// BeforeUpdate will also call UpdateStrategy's PrepareForUpdate()
+	if err := rest.BeforeUpdate(e.UpdateStrategy, ctx, obj, existing); err != nil {
+		return nil, false, err
+	}
+	ttl, err := e.calculateTTL(obj, 0, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	out := e.NewFunc()
+	if err := e.Backend.Update(ctx, key, obj, out, ttl, dryrun.IsDryRun(options.DryRun)); err != nil {
+		switch {
+		case storage.IsNotFound(err):
+			return nil, false, errors.NewNotFound(e.QualifiedResource, name)
+		case storage.IsConflict(err):
+			return nil, false, errors.NewConflict(e.QualifiedResource, name, fmt.Errorf(OptimisticLockErrorMsg))
+		}
+		return nil, false, err
+	}
+	if e.AfterUpdate != nil {
+		if err := e.AfterUpdate(out); err != nil {
+			return nil, false, err
+		}
+	}
+	if e.Decorator != nil {
+		if err := e.Decorator(out); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return out, false, nil
+}
+
 func (e *Store) Get(ctx genericapirequest.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
 	obj := e.NewFunc()
 	key, err := e.KeyFunc(ctx, name)
@@ -324,9 +564,18 @@ func (e *Store) Delete(ctx genericapirequest.Context, name string, options *meta
 		return nil, false, err
 	}
 
+	if dryrun.IsDryRun(options.DryRun) {
+		// Nothing is removed, so return the object as it stands
+		if err := e.Backend.Delete(ctx, key, nil, &preconditions, true); err != nil {
+			return nil, false, err
+		}
+		out, err := e.finalizeDelete(obj, false)
+		return out, true, err
+	}
+
 	glog.V(5).Infof("Deleting \"%s\" from backend.", name)
 	out := e.NewFunc()
-	if err := e.Backend.Delete(ctx, key, out, &preconditions); err != nil {
+	if err := e.Backend.Delete(ctx, key, out, &preconditions, false); err != nil {
 		// TODO (rantuttl): Maybe some better error type determinations
 		return nil, false, err
 	}
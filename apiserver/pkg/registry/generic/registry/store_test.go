@@ -0,0 +1,298 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/api"
+	"github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core"
+	_ "github.com/rantuttl/cloudops/apiserver/pkg/apigroups/core/install"
+	"github.com/rantuttl/cloudops/apiserver/pkg/backend"
+	"github.com/rantuttl/cloudops/apiserver/pkg/backend/cal"
+	genericapirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/core/account"
+	"github.com/rantuttl/cloudops/apiserver/pkg/registry/rest"
+	"github.com/rantuttl/cloudops/apiserver/pkg/storage"
+	"golang.org/x/net/context"
+)
+
+// fakeBackend keeps accounts in memory and records the writes made to it. Dry runs are handed
+// to a CAL backend, which renders them without sending anything. The first conflicts creates
+// fail as if their key already existed.
+type fakeBackend struct {
+	backend.Interface
+	objects   map[string]*core.Account
+	writes    []string
+	creates   []string
+	conflicts int
+}
+
+func newFakeBackend() *fakeBackend {
+	t := cal.NewCalResourceTransformer("accounts")
+	for _, verb := range []cal.Verb{cal.CREATE, cal.UPDATE, cal.DELETE} {
+		t.GraphQLBodies[verb], _ = t.NewGraphQLBody(verb)
+	}
+	codec := api.Codecs.LegacyCodec(schema.GroupVersion{Group: "core", Version: "v1"})
+	return &fakeBackend{
+		Interface: cal.NewCalBackend(codec, api.Scheme, t),
+		objects:   map[string]*core.Account{},
+	}
+}
+
+func (b *fakeBackend) Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error {
+	if dryRun {
+		return b.Interface.Create(ctx, key, obj, out, ttl, dryRun)
+	}
+	b.creates = append(b.creates, key)
+	if _, ok := b.objects[key]; ok || b.conflicts > 0 {
+		b.conflicts--
+		return storage.NewKeyExistsError(key, 0)
+	}
+	return b.write("create", key, obj, out)
+}
+
+func (b *fakeBackend) Get(ctx context.Context, key string, resourceVersion string, objPtr runtime.Object, ignoreNotFound bool) error {
+	existing, ok := b.objects[key]
+	if !ok {
+		return storage.NewKeyNotFoundError(key, 0)
+	}
+	*objPtr.(*core.Account) = *existing
+	return nil
+}
+
+func (b *fakeBackend) Update(ctx context.Context, key string, obj, out runtime.Object, ttl uint64, dryRun bool) error {
+	if dryRun {
+		return b.Interface.Update(ctx, key, obj, out, ttl, dryRun)
+	}
+	return b.write("update", key, obj, out)
+}
+
+func (b *fakeBackend) Delete(ctx context.Context, key string, out runtime.Object, preconditions *metav1.Preconditions, dryRun bool) error {
+	if dryRun {
+		return b.Interface.Delete(ctx, key, out, preconditions, dryRun)
+	}
+	b.writes = append(b.writes, "delete "+key)
+	if out != nil {
+		*out.(*core.Account) = *b.objects[key]
+	}
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *fakeBackend) write(verb, key string, obj, out runtime.Object) error {
+	b.writes = append(b.writes, verb+" "+key)
+	stored := *obj.(*core.Account)
+	b.objects[key] = &stored
+	if out != nil {
+		*out.(*core.Account) = stored
+	}
+	return nil
+}
+
+func newTestStore(b backend.Interface) *Store {
+	return &Store{
+		NewFunc:             func() runtime.Object { return &core.Account{} },
+		NewListFunc:         func() runtime.Object { return &core.AccountList{} },
+		QualifiedResource:   core.Resource("accounts"),
+		CreateStrategy:      account.Strategy,
+		UpdateStrategy:      account.Strategy,
+		DeleteStrategy:      account.Strategy,
+		ReturnDeletedObject: true,
+		KeyRootFunc: func(ctx genericapirequest.Context) string {
+			return "/accounts"
+		},
+		KeyFunc: func(ctx genericapirequest.Context, name string) (string, error) {
+			return NoNamespaceKeyFunc(ctx, "/accounts", name)
+		},
+		ObjectNameFunc: func(obj runtime.Object) (string, error) {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return "", err
+			}
+			return accessor.GetName(), nil
+		},
+		Backend: b,
+	}
+}
+
+// newTestContext returns the context of a request with verb, which the CAL transformer renders.
+func newTestContext(verb string) genericapirequest.Context {
+	return genericapirequest.WithRequestInfo(genericapirequest.NewContext(), &genericapirequest.RequestInfo{IsResourceRequest: true, Verb: verb, Resource: "accounts"})
+}
+
+// expectDryRunResult checks that obj is the account as the server would have written it.
+func expectDryRunResult(t *testing.T, verb string, obj runtime.Object, mutation string) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		t.Fatalf("%s: unexpected result %#v: %v", verb, obj, err)
+	}
+	if len(accessor.GetUID()) == 0 || accessor.GetCreationTimestamp().Time.IsZero() {
+		t.Errorf("%s: expected the server filled UID and creationTimestamp, got %#v", verb, accessor)
+	}
+	if graphQL := accessor.GetAnnotations()[cal.DryRunGraphQLAnnotation]; !strings.Contains(graphQL, "mutation "+mutation) {
+		t.Errorf("%s: expected the rendered GraphQL document in the annotations, got %q", verb, graphQL)
+	}
+}
+
+func TestStoreDryRun(t *testing.T) {
+	b := newFakeBackend()
+	store := newTestStore(b)
+	dryRun := []string{metav1.DryRunAll}
+
+	obj, err := store.Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme"}}, &metav1.CreateOptions{DryRun: dryRun})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectDryRunResult(t, "create", obj, "createAccount")
+	if len(b.writes) != 0 {
+		t.Fatalf("expected the dry run create not to be written, got %v", b.writes)
+	}
+
+	created, err := store.Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.writes = nil
+
+	copied, err := api.Scheme.Copy(created)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := copied.(*core.Account)
+	updated.Labels = map[string]string{"team": "ci"}
+	obj, _, err = store.Update(newTestContext("update"), "acme", rest.DefaultUpdatedObjectInfo(updated, api.Scheme), &metav1.UpdateOptions{DryRun: dryRun})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectDryRunResult(t, "update", obj, "updateAccount")
+	if obj.(*core.Account).Labels["team"] != "ci" {
+		t.Errorf("expected the dry run update to return the updated account, got %#v", obj)
+	}
+
+	obj, _, err = store.Delete(newTestContext("delete"), "acme", &metav1.DeleteOptions{DryRun: dryRun})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.(*core.Account).Name != "acme" {
+		t.Errorf("expected the dry run delete to return the account, got %#v", obj)
+	}
+
+	if len(b.writes) != 0 {
+		t.Errorf("expected the dry runs not to be written, got %v", b.writes)
+	}
+	if stored := b.objects["/accounts/acme"]; stored == nil || len(stored.Labels) != 0 {
+		t.Errorf("expected the stored account to be left alone, got %#v", stored)
+	}
+}
+
+func TestStoreDryRunErrors(t *testing.T) {
+	b := newFakeBackend()
+	store := newTestStore(b)
+	dryRun := []string{metav1.DryRunAll}
+
+	// A dry run goes through validation like any other request.
+	_, err := store.Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "Not_A_Name"}}, &metav1.CreateOptions{DryRun: dryRun})
+	if !errors.IsInvalid(err) {
+		t.Errorf("expected the invalid account to be rejected, got %v", err)
+	}
+	_, _, err = store.Delete(newTestContext("delete"), "missing", &metav1.DeleteOptions{DryRun: dryRun})
+	if err == nil {
+		t.Errorf("expected the dry run delete of a missing account to fail")
+	}
+	if len(b.writes) != 0 {
+		t.Errorf("expected nothing to be written, got %v", b.writes)
+	}
+}
+
+func TestStoreUpdateResourceVersion(t *testing.T) {
+	b := newFakeBackend()
+	b.objects["/accounts/acme"] = &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme", UID: "uid-1", ResourceVersion: "5"}}
+	store := newTestStore(b)
+
+	for _, test := range []struct {
+		resourceVersion string
+		conflict        bool
+	}{
+		{"4", true},
+		{"5", false},
+		{"", false},
+	} {
+		updated := &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme", ResourceVersion: test.resourceVersion}}
+		_, created, err := store.Update(newTestContext("update"), "acme", rest.DefaultUpdatedObjectInfo(updated, api.Scheme), nil)
+		if created {
+			t.Errorf("resourceVersion %q: expected the update not to create the account", test.resourceVersion)
+		}
+		if test.conflict != errors.IsConflict(err) || (!test.conflict && err != nil) {
+			t.Errorf("resourceVersion %q: expected conflict %t, got %v", test.resourceVersion, test.conflict, err)
+		}
+	}
+	if len(b.writes) != 2 {
+		t.Errorf("expected the conflicting update not to be written, got %v", b.writes)
+	}
+
+	_, _, err := store.Update(newTestContext("update"), "missing", rest.DefaultUpdatedObjectInfo(&core.Account{ObjectMeta: metav1.ObjectMeta{Name: "missing"}}, api.Scheme), nil)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected the update of a missing account to fail with NotFound, got %v", err)
+	}
+}
+
+func TestStoreCreateGenerateName(t *testing.T) {
+	for _, conflicts := range []int{0, 2, maxGenerateNameAttempts - 1} {
+		b := newFakeBackend()
+		b.conflicts = conflicts
+		obj, err := newTestStore(b).Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{GenerateName: "acme-"}}, nil)
+		if err != nil {
+			t.Fatalf("%d conflicts: unexpected error: %v", conflicts, err)
+		}
+		if len(b.creates) != conflicts+1 || len(sets.NewString(b.creates...)) != len(b.creates) {
+			t.Errorf("%d conflicts: expected a fresh name for each attempt, got %v", conflicts, b.creates)
+		}
+		for _, key := range b.creates {
+			if !strings.HasPrefix(key, "/accounts/acme-") || len(key) == len("/accounts/acme-") {
+				t.Errorf("%d conflicts: expected a generated name, got key %q", conflicts, key)
+			}
+		}
+		if name := obj.(*core.Account).Name; "/accounts/"+name != b.creates[len(b.creates)-1] {
+			t.Errorf("%d conflicts: expected the account to be created with the last name tried, got %q", conflicts, name)
+		}
+	}
+
+	// The store gives up, and the client is asked to retry later.
+	b := newFakeBackend()
+	b.conflicts = maxGenerateNameAttempts
+	_, err := newTestStore(b).Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{GenerateName: "acme-"}}, nil)
+	if !errors.IsServerTimeout(err) {
+		t.Errorf("expected a server timeout after %d attempts, got %v", maxGenerateNameAttempts, err)
+	}
+	if len(b.creates) != maxGenerateNameAttempts || len(b.writes) != 0 {
+		t.Errorf("expected %d attempts and no write, got %v and %v", maxGenerateNameAttempts, b.creates, b.writes)
+	}
+
+	// A name the client asked for is never regenerated.
+	b = newFakeBackend()
+	b.conflicts = 1
+	_, err = newTestStore(b).Create(newTestContext("create"), &core.Account{ObjectMeta: metav1.ObjectMeta{Name: "acme", GenerateName: "acme-"}}, nil)
+	if !errors.IsAlreadyExists(err) || len(b.creates) != 1 {
+		t.Errorf("expected the explicit name to fail once with AlreadyExists, got %v after %v", err, b.creates)
+	}
+}
@@ -84,7 +84,7 @@ func BeforeCreate(strategy RESTCreateStrategy, ctx genericapirequest.Context, ob
 	objectMeta.SetDeletionGracePeriodSeconds(nil)
 
 	strategy.PrepareForCreate(ctx, obj)
-	// Populate creation timestamp and UID fields of meta
+	// Populate creation timestamp, UID, resource version and generation fields of meta
 	FillObjectMetaSystemFields(ctx, objectMeta)
 	if len(objectMeta.GetGenerateName()) > 0 && len(objectMeta.GetName()) == 0 {
 		objectMeta.SetName(strategy.GenerateName(objectMeta.GetGenerateName()))
@@ -106,3 +106,22 @@ func BeforeCreate(strategy RESTCreateStrategy, ctx genericapirequest.Context, ob
 
 	return nil
 }
+
+// CheckGeneratedNameError checks whether an error that occurred creating a resource is due
+// to generation being unable to pick a valid name.
+func CheckGeneratedNameError(strategy RESTCreateStrategy, err error, obj runtime.Object) error {
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	objectMeta, kind, kerr := objectMetaAndKind(strategy, obj)
+	if kerr != nil {
+		return kerr
+	}
+
+	if len(objectMeta.GetGenerateName()) == 0 {
+		return err
+	}
+
+	return errors.NewServerTimeoutForKind(kind.GroupKind(), "POST", 0)
+}
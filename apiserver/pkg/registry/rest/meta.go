@@ -37,6 +37,23 @@ func FillObjectMetaSystemFields(ctx genericapirequest.Context, meta metav1.Objec
         }
         meta.SetUID(uid)
         meta.SetSelfLink("")
+	// The backend assigns the resource version once the object is persisted; generation counts
+	// spec changes starting from the first one.
+	meta.SetResourceVersion("")
+	meta.SetGeneration(1)
+}
+
+// CopyObjectMetaSystemFields carries the fields managed by the system over from the existing
+// object, discarding whatever the client sent for them. ResourceVersion is left alone, as it
+// is the client's precondition for the update: the Store and the backend reject the update
+// with a Conflict when it does not match the existing object.
+func CopyObjectMetaSystemFields(meta, oldMeta metav1.Object) {
+	meta.SetUID(oldMeta.GetUID())
+	meta.SetCreationTimestamp(oldMeta.GetCreationTimestamp())
+	meta.SetGeneration(oldMeta.GetGeneration())
+	meta.SetDeletionTimestamp(oldMeta.GetDeletionTimestamp())
+	meta.SetDeletionGracePeriodSeconds(oldMeta.GetDeletionGracePeriodSeconds())
+	meta.SetSelfLink("")
 }
 
 // objectMetaAndKind retrieves kind and ObjectMeta from a runtime object, or returns an error.
@@ -0,0 +1,136 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+	http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package rest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/meta"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime/schema"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+	genericapirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+)
+
+// RowFunc returns the cells of the table row for obj, one per column definition.
+type RowFunc func(obj runtime.Object, m metav1.Object) ([]interface{}, error)
+
+// defaultTableConvertor shows the name and creation time of any object.
+type defaultTableConvertor struct {
+	qualifiedResource schema.GroupResource
+}
+
+// NewDefaultTableConvertor returns the TableConvertor used for resources that don't provide their own.
+func NewDefaultTableConvertor(resource schema.GroupResource) TableConvertor {
+	return defaultTableConvertor{qualifiedResource: resource}
+}
+
+var defaultColumns = []metav1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Format: "name", Description: "Name must be unique within a namespace."},
+	{Name: "Created At", Type: "date", Description: "CreationTimestamp is the time the object was created."},
+}
+
+func (c defaultTableConvertor) ConvertToTable(ctx genericapirequest.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	table, err := MetaToTable(object, defaultColumns, func(obj runtime.Object, m metav1.Object) ([]interface{}, error) {
+		return []interface{}{
+			m.GetName(),
+			m.GetCreationTimestamp().Time.UTC().Format(time.RFC3339),
+		}, nil
+	})
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("unable to convert %s to a table: %v", c.qualifiedResource, err))
+	}
+	return table, nil
+}
+
+// MetaToTable builds a Table from an object or a list of objects, with one row per object whose cells
+// are returned by rowFn. Each row carries its object, so the caller can decide how much of it to return.
+func MetaToTable(object runtime.Object, columns []metav1.TableColumnDefinition, rowFn RowFunc) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions:	columns,
+		Rows:			[]metav1.TableRow{},
+	}
+	addRow := func(obj runtime.Object) error {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return fmt.Errorf("objects of type %T have no metadata and can not be shown as a table", obj)
+		}
+		cells, err := rowFn(obj, m)
+		if err != nil {
+			return err
+		}
+		if len(cells) != len(columns) {
+			return fmt.Errorf("expected %d cells for %q, got %d", len(columns), m.GetName(), len(cells))
+		}
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells:	cells,
+			Object:	runtime.RawExtension{Object: obj},
+		})
+		return nil
+	}
+
+	if meta.IsListType(object) {
+		items, err := meta.ExtractList(object)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if err := addRow(item); err != nil {
+				return nil, err
+			}
+		}
+		if l, err := meta.ListAccessor(object); err == nil {
+			table.ResourceVersion = l.GetResourceVersion()
+			table.SelfLink = l.GetSelfLink()
+			if li, ok := l.(metav1.ListInterface); ok {
+				table.Continue = li.GetContinue()
+			}
+		}
+	} else {
+		if err := addRow(object); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// TranslateTimestamp returns the elapsed time since timestamp in human readable form, e.g. "5m" or "3d".
+func TranslateTimestamp(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+	return shortHumanDuration(time.Now().Sub(timestamp.Time))
+}
+
+func shortHumanDuration(d time.Duration) string {
+	// Allow deviation no more than 2 seconds(excluded) to tolerate machine time
+	// inconsistence, it can be considered as almost now.
+	if seconds := int(d.Seconds()); seconds < -1 {
+		return "<invalid>"
+	} else if seconds < 0 {
+		return "0s"
+	} else if seconds < 60 {
+		return fmt.Sprintf("%ds", seconds)
+	} else if minutes := int(d.Minutes()); minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	} else if hours := int(d.Hours()); hours < 24 {
+		return fmt.Sprintf("%dh", hours)
+	} else if hours < 24*365 {
+		return fmt.Sprintf("%dd", hours/24)
+	}
+	return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+}
@@ -26,13 +26,14 @@ import (
 	"github.com/rantuttl/cloudops/apiserver/plugin/pkg/authenticator/password/keystone"
 	"github.com/rantuttl/cloudops/apiserver/plugin/pkg/authenticator/password/passwordfile"
 	"github.com/rantuttl/cloudops/apiserver/plugin/pkg/authenticator/request/basicauth"
-	certutil "github.com/rantuttl/cloudops/apiserver/pkg/util/cert"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/dynamiccertificates"
 )
 
 type AuthenticatorConfig struct {
 	Anonymous			bool
 	BasicAuthFile			string
-	ClientCAFile			string
+	// ClientCAContentProvider, if set, verifies client certificates, see x509.NewDynamic.
+	ClientCAContentProvider		dynamiccertificates.CAContentProvider
 	KeystoneURL			string
 	KeystoneCAFile			string
 }
@@ -63,12 +64,9 @@ func (c AuthenticatorConfig) New() (authenticator.Request, *spec.SecurityDefinit
 		hasBasicAuth = true
 	}
 
-	if len(c.ClientCAFile) > 0 {
+	if c.ClientCAContentProvider != nil {
 		// basic auth via x509 certs
-		certAuth, err := newAuthenticatorFromClientCAFile(c.ClientCAFile)
-		if err != nil {
-			return nil, nil, err
-		}
+		certAuth := x509.NewDynamic(c.ClientCAContentProvider.VerifyOptions, x509.CommonNameUserConversion)
 		authenticators = append(authenticators, certAuth)
 	}
 
@@ -133,14 +131,3 @@ func newAuthenticatorFromKeystoneURL(keystoneURL string, keystoneCAFile string)
 	}
 	return basicauth.New(keystoneAuthenticator), nil
 }
-
-// newAuthenticatorFromClientCAFile returns an authenticator.Request or an error
-func newAuthenticatorFromClientCAFile(clientCAFile string) (authenticator.Request, error) {
-	roots, err := certutil.NewPool(clientCAFile)
-	if err != nil {
-		return nil, err
-	}
-	opts := x509.DefaultVerifyOptions()
-	opts.Roots = roots
-	return x509.New(opts, x509.CommonNameUserConversion), nil
-}
@@ -0,0 +1,139 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package dynamiccertificates
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/wait"
+	certutil "github.com/rantuttl/cloudops/apiserver/pkg/util/cert"
+)
+
+// DynamicFileCAContent provides a CA bundle loaded from a file, reloaded when it changes.
+type DynamicFileCAContent struct {
+	name     string
+	filename string
+
+	lock      sync.RWMutex
+	caBundle  []byte
+	pool      *x509.CertPool
+	listeners []Listener
+}
+
+var _ CAContentProvider = &DynamicFileCAContent{}
+
+// NewDynamicCAContentFromFile returns a provider of the CA bundle in filename. The file is
+// loaded once, an error is returned if it holds no valid certificate.
+func NewDynamicCAContentFromFile(purpose, filename string) (*DynamicFileCAContent, error) {
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("missing filename for ca bundle")
+	}
+	c := &DynamicFileCAContent{
+		name:     fmt.Sprintf("%s::%s", purpose, filename),
+		filename: filename,
+	}
+	if err := c.RunOnce(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Name is just an identifier.
+func (c *DynamicFileCAContent) Name() string {
+	return c.name
+}
+
+// AddListener adds a listener notified when the CA bundle changes.
+func (c *DynamicFileCAContent) AddListener(listener Listener) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// CurrentCABundleContent returns the current CA bundle.
+func (c *DynamicFileCAContent) CurrentCABundleContent() *x509.CertPool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.pool
+}
+
+// VerifyOptions returns options verifying client certificates against the current CA bundle.
+func (c *DynamicFileCAContent) VerifyOptions() (x509.VerifyOptions, bool) {
+	pool := c.CurrentCABundleContent()
+	if pool == nil {
+		return x509.VerifyOptions{}, false
+	}
+	return x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, true
+}
+
+// RunOnce loads the file. The current bundle is kept if the file holds no valid certificate.
+func (c *DynamicFileCAContent) RunOnce() error {
+	caBundle, err := ioutil.ReadFile(c.filename)
+	if err != nil {
+		return fmt.Errorf("failed to read ca bundle %q: %v", c.filename, err)
+	}
+
+	c.lock.RLock()
+	unchanged := bytes.Equal(caBundle, c.caBundle)
+	c.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	certs, err := certutil.ParseCertsPEM(caBundle)
+	if err != nil {
+		return fmt.Errorf("invalid ca bundle %q: %v", c.name, err)
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+
+	c.lock.Lock()
+	reload := c.pool != nil
+	c.caBundle, c.pool = caBundle, pool
+	listeners := c.listeners
+	c.lock.Unlock()
+
+	if reload {
+		glog.Infof("Loaded a new CA bundle for %q", c.name)
+	}
+	for _, listener := range listeners {
+		listener.Enqueue()
+	}
+	return nil
+}
+
+// Run reloads the file whenever it changes, until stopCh is closed.
+func (c *DynamicFileCAContent) Run(stopCh <-chan struct{}) {
+	glog.Infof("Starting %s", c.name)
+	defer glog.Infof("Shutting down %s", c.name)
+
+	wait.Until(func() {
+		if err := c.RunOnce(); err != nil {
+			glog.Errorf("Unable to reload %s: %v", c.name, err)
+		}
+	}, FileRefreshDuration, stopCh)
+}
@@ -0,0 +1,167 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package dynamiccertificates
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	certutil "github.com/rantuttl/cloudops/apiserver/pkg/util/cert"
+)
+
+type fakeListener struct {
+	count int
+}
+
+func (l *fakeListener) Enqueue() {
+	l.count++
+}
+
+// writeCertKey writes a new self-signed cert and key for host, and returns the cert PEM.
+func writeCertKey(t *testing.T, certFile, keyFile, host string) []byte {
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := certutil.WriteCert(certFile, certPEM); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := certutil.WriteKey(keyFile, keyPEM); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return certPEM
+}
+
+// commonName returns the host the self-signed cert was generated for.
+func commonName(t *testing.T, der []byte) string {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The common name is suffixed with the generation time, e.g. "host@1500000000".
+	return strings.SplitN(cert.Subject.CommonName, "@", 2)[0]
+}
+
+func TestDynamicServingContentReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamiccertificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	writeCertKey(t, certFile, keyFile, "old.example.com")
+	content, err := NewDynamicSNIContentFromFiles("test", certFile, keyFile, "*.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listener := &fakeListener{}
+	content.AddListener(listener)
+	if names := content.SNINames(); len(names) != 1 || names[0] != "*.example.com" {
+		t.Errorf("unexpected SNI names %v", names)
+	}
+	if cn := commonName(t, content.CurrentCertKeyContent().Certificate[0]); cn != "old.example.com" {
+		t.Errorf("unexpected cert %q", cn)
+	}
+
+	// Unchanged files are not reloaded.
+	if err := content.RunOnce(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listener.count != 0 {
+		t.Errorf("expected no notification, got %d", listener.count)
+	}
+
+	// A rotated pair is served once loaded.
+	writeCertKey(t, certFile, keyFile, "new.example.com")
+	if err := content.RunOnce(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listener.count != 1 {
+		t.Errorf("expected 1 notification, got %d", listener.count)
+	}
+	if cn := commonName(t, content.CurrentCertKeyContent().Certificate[0]); cn != "new.example.com" {
+		t.Errorf("unexpected cert %q", cn)
+	}
+
+	// A cert written without its key yet is not a valid pair, the current pair is kept.
+	certPEM, _, err := certutil.GenerateSelfSignedCertKey("half.example.com", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := certutil.WriteCert(certFile, certPEM); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := content.RunOnce(); err == nil {
+		t.Errorf("expected an error for a mismatched pair")
+	}
+	if cn := commonName(t, content.CurrentCertKeyContent().Certificate[0]); cn != "new.example.com" {
+		t.Errorf("expected the current cert to be kept, got %q", cn)
+	}
+	if listener.count != 1 {
+		t.Errorf("expected no further notification, got %d", listener.count)
+	}
+}
+
+func TestDynamicCAContentReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamiccertificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.crt")
+
+	oldCA := writeCertKey(t, caFile, filepath.Join(dir, "ca.key"), "old-ca")
+	content, err := NewDynamicCAContentFromFile("test", caFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listener := &fakeListener{}
+	content.AddListener(listener)
+	opts, ok := content.VerifyOptions()
+	if !ok || opts.Roots == nil {
+		t.Fatalf("expected verify options with roots")
+	}
+
+	if err := ioutil.WriteFile(caFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := content.RunOnce(); err == nil {
+		t.Errorf("expected an error for an invalid bundle")
+	}
+	if content.CurrentCABundleContent() != opts.Roots {
+		t.Errorf("expected the current bundle to be kept")
+	}
+
+	// The rotated bundle holds both the old and the new CA.
+	newCA := writeCertKey(t, filepath.Join(dir, "new.crt"), filepath.Join(dir, "new.key"), "new-ca")
+	if err := ioutil.WriteFile(caFile, append(oldCA, newCA...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := content.RunOnce(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listener.count != 1 {
+		t.Errorf("expected 1 notification, got %d", listener.count)
+	}
+	if content.CurrentCABundleContent() == opts.Roots {
+		t.Errorf("expected a new bundle")
+	}
+}
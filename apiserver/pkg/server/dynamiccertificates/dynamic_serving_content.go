@@ -0,0 +1,148 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package dynamiccertificates
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/wait"
+)
+
+// DynamicCertKeyPairContent provides a certificate and key pair loaded from files, reloaded
+// when they change.
+type DynamicCertKeyPairContent struct {
+	name     string
+	certFile string
+	keyFile  string
+	sniNames []string
+
+	lock      sync.RWMutex
+	certPEM   []byte
+	keyPEM    []byte
+	cert      *tls.Certificate
+	listeners []Listener
+}
+
+var _ SNICertKeyContentProvider = &DynamicCertKeyPairContent{}
+
+// NewDynamicServingContentFromFiles returns a provider of the serving certificate and key in
+// the files. The files are loaded once, an error is returned if they are not a valid pair.
+func NewDynamicServingContentFromFiles(purpose, certFile, keyFile string) (*DynamicCertKeyPairContent, error) {
+	if len(certFile) == 0 || len(keyFile) == 0 {
+		return nil, fmt.Errorf("missing filename for serving cert")
+	}
+	c := &DynamicCertKeyPairContent{
+		name:     fmt.Sprintf("%s::%s::%s", purpose, certFile, keyFile),
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := c.RunOnce(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewDynamicSNIContentFromFiles returns a provider of the certificate and key in the files,
+// served for the host names sniNames, or for the names of the certificate if none are given.
+func NewDynamicSNIContentFromFiles(purpose, certFile, keyFile string, sniNames ...string) (*DynamicCertKeyPairContent, error) {
+	c, err := NewDynamicServingContentFromFiles(purpose, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.sniNames = sniNames
+	return c, nil
+}
+
+// Name is just an identifier.
+func (c *DynamicCertKeyPairContent) Name() string {
+	return c.name
+}
+
+// SNINames returns the host names the certificate is served for.
+func (c *DynamicCertKeyPairContent) SNINames() []string {
+	return c.sniNames
+}
+
+// AddListener adds a listener notified when the certificate or key change.
+func (c *DynamicCertKeyPairContent) AddListener(listener Listener) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// CurrentCertKeyContent returns the current certificate and key.
+func (c *DynamicCertKeyPairContent) CurrentCertKeyContent() *tls.Certificate {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.cert
+}
+
+// RunOnce loads the files. The current pair is kept if they are not a valid pair, e.g.
+// when the certificate has been written but not yet its key.
+func (c *DynamicCertKeyPairContent) RunOnce() error {
+	certPEM, err := ioutil.ReadFile(c.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate %q: %v", c.certFile, err)
+	}
+	keyPEM, err := ioutil.ReadFile(c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key %q: %v", c.keyFile, err)
+	}
+
+	c.lock.RLock()
+	unchanged := bytes.Equal(certPEM, c.certPEM) && bytes.Equal(keyPEM, c.keyPEM)
+	c.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid serving cert keypair %q: %v", c.name, err)
+	}
+
+	c.lock.Lock()
+	reload := c.cert != nil
+	c.certPEM, c.keyPEM, c.cert = certPEM, keyPEM, &cert
+	listeners := c.listeners
+	c.lock.Unlock()
+
+	if reload {
+		glog.Infof("Loaded a new cert/key pair for %q", c.name)
+	}
+	for _, listener := range listeners {
+		listener.Enqueue()
+	}
+	return nil
+}
+
+// Run reloads the files whenever they change, until stopCh is closed.
+func (c *DynamicCertKeyPairContent) Run(stopCh <-chan struct{}) {
+	glog.Infof("Starting %s", c.name)
+	defer glog.Infof("Shutting down %s", c.name)
+
+	wait.Until(func() {
+		if err := c.RunOnce(); err != nil {
+			glog.Errorf("Unable to reload %s: %v", c.name, err)
+		}
+	}, FileRefreshDuration, stopCh)
+}
@@ -0,0 +1,78 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package dynamiccertificates provides the serving certificates and client CA bundle of the
+// secure server, loaded from files which are watched so that rotated certificates are served
+// without a restart.
+package dynamiccertificates
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// FileRefreshDuration is how often the certificate files are checked for changes.
+const FileRefreshDuration = 1 * time.Minute
+
+// Listener is notified when the content of a provider changes.
+type Listener interface {
+	// Enqueue is called when the content changes. It must not block.
+	Enqueue()
+}
+
+// Notifier lets listeners register for content changes.
+type Notifier interface {
+	AddListener(listener Listener)
+}
+
+// ControllerRunner watches the files of a provider.
+type ControllerRunner interface {
+	// RunOnce reloads the files once, it returns an error if they cannot be loaded.
+	RunOnce() error
+	// Run reloads the files whenever they change, until stopCh is closed.
+	Run(stopCh <-chan struct{})
+}
+
+// CertKeyContentProvider provides a serving certificate and its key.
+type CertKeyContentProvider interface {
+	Notifier
+	ControllerRunner
+	// Name is just an identifier, e.g. for logs.
+	Name() string
+	// CurrentCertKeyContent returns the current certificate and key.
+	CurrentCertKeyContent() *tls.Certificate
+}
+
+// SNICertKeyContentProvider provides a serving certificate for the host names it is selected for.
+type SNICertKeyContentProvider interface {
+	CertKeyContentProvider
+	// SNINames are the host names the certificate is served for. If empty, the names are
+	// those of the certificate.
+	SNINames() []string
+}
+
+// CAContentProvider provides a CA bundle verifying client certificates.
+type CAContentProvider interface {
+	Notifier
+	ControllerRunner
+	// Name is just an identifier, e.g. for logs.
+	Name() string
+	// CurrentCABundleContent returns the current CA bundle as a pool.
+	CurrentCABundleContent() *x509.CertPool
+	// VerifyOptions returns options verifying client certificates against the current CA
+	// bundle, and false if there is no bundle.
+	VerifyOptions() (x509.VerifyOptions, bool)
+}
@@ -0,0 +1,67 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package healthz
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstallPathHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	failing := NamedCheck("backend", func(r *http.Request) error {
+		return errors.New("connection refused")
+	})
+	InstallPathHandler(mux, "/livez", PingHealthz)
+	InstallPathHandler(mux, "/readyz", PingHealthz, failing)
+
+	testCases := []struct {
+		path     string
+		code     int
+		body     string
+		contains []string
+	}{
+		{path: "/livez", code: http.StatusOK, body: "ok"},
+		{path: "/livez?verbose", code: http.StatusOK, contains: []string{"[+]ping ok", "livez check passed"}},
+		{path: "/livez/ping", code: http.StatusOK, body: "ok"},
+		{path: "/readyz", code: http.StatusInternalServerError, contains: []string{"[+]ping ok", "[-]backend failed: reason withheld", "readyz check failed"}},
+		{path: "/readyz/backend", code: http.StatusInternalServerError, contains: []string{"connection refused"}},
+		{path: "/readyz?exclude=backend&verbose", code: http.StatusOK, contains: []string{"[+]backend excluded: ok", "readyz check passed"}},
+		{path: "/readyz?exclude=backend&exclude=missing", code: http.StatusOK, body: "ok"},
+		{path: "/readyz?exclude=backend&exclude=missing&verbose", code: http.StatusOK, contains: []string{`no matches for "missing"`}},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "http://example.com"+tc.path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		body, _ := ioutil.ReadAll(w.Body)
+		if w.Code != tc.code {
+			t.Errorf("%s: expected code %d, got %d: %s", tc.path, tc.code, w.Code, body)
+		}
+		if len(tc.body) > 0 && string(body) != tc.body {
+			t.Errorf("%s: expected body %q, got %q", tc.path, tc.body, body)
+		}
+		for _, s := range tc.contains {
+			if !strings.Contains(string(body), s) {
+				t.Errorf("%s: expected body to contain %q, got %q", tc.path, s, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,443 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package openapi
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/go-openapi/spec"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/runtime"
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+)
+
+const (
+	// ExtensionGroupVersionKind is the vendor extension attached to the definition of every
+	// type known to the scheme, listing the group/version/kinds it is served as.
+	ExtensionGroupVersionKind = "x-cloudops-group-version-kind"
+
+	// OpenAPIVersion is the version of the OpenAPI specification the document conforms to.
+	OpenAPIVersion = "2.0"
+)
+
+// Config is the set of information needed to build an OpenAPI document from the
+// go-restful web services installed on the server.
+type Config struct {
+	// Info is the general information about the API.
+	Info *spec.Info
+
+	// SecurityDefinitions are the security schemes the server accepts. Every operation
+	// in the document requires one of them.
+	SecurityDefinitions *spec.SecurityDefinitions
+
+	// DefaultResponse is added to every operation, if set.
+	DefaultResponse *spec.Response
+
+	// Typer is used to find the group/version/kinds of the models in the document. Optional.
+	Typer runtime.ObjectTyper
+
+	// IgnorePrefixes are web service root paths that are left out of the document.
+	IgnorePrefixes []string
+}
+
+var (
+	timeType		= reflect.TypeOf(metav1.Time{})
+	rawExtensionType	= reflect.TypeOf(runtime.RawExtension{})
+	byteSliceType		= reflect.TypeOf([]byte{})
+)
+
+// builder walks the web services and accumulates the paths and model definitions.
+type builder struct {
+	config		*Config
+	definitions	spec.Definitions
+	// names maps each Go type to its definition name.
+	names		map[reflect.Type]string
+}
+
+// BuildOpenAPISpec builds an OpenAPI v2 document from the routes of the given web services.
+func BuildOpenAPISpec(webServices []*restful.WebService, config *Config) (*spec.Swagger, error) {
+	if config == nil || config.Info == nil {
+		return nil, fmt.Errorf("openapi config with Info is required")
+	}
+	b := &builder{
+		config:		config,
+		definitions:	spec.Definitions{},
+		names:		map[reflect.Type]string{},
+	}
+
+	paths := map[string]spec.PathItem{}
+	for _, ws := range webServices {
+		if b.ignored(ws.RootPath()) {
+			continue
+		}
+		for _, route := range ws.Routes() {
+			p := trimPathRegex(route.Path)
+			item := paths[p]
+			op, err := b.buildOperation(ws, route)
+			if err != nil {
+				return nil, err
+			}
+			switch strings.ToUpper(route.Method) {
+			case "GET":
+				item.Get = op
+			case "PUT":
+				item.Put = op
+			case "POST":
+				item.Post = op
+			case "DELETE":
+				item.Delete = op
+			case "PATCH":
+				item.Patch = op
+			case "HEAD":
+				item.Head = op
+			case "OPTIONS":
+				item.Options = op
+			default:
+				return nil, fmt.Errorf("unsupported method %q for route %s", route.Method, route.Path)
+			}
+			paths[p] = item
+		}
+	}
+
+	swagger := &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger:	OpenAPIVersion,
+			Info:		config.Info,
+			Paths:		&spec.Paths{Paths: paths},
+			Definitions:	b.definitions,
+		},
+	}
+	if config.SecurityDefinitions != nil && len(*config.SecurityDefinitions) > 0 {
+		swagger.SecurityDefinitions = *config.SecurityDefinitions
+		// any one of the schemes is sufficient
+		keys := make([]string, 0, len(*config.SecurityDefinitions))
+		for k := range *config.SecurityDefinitions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			swagger.Security = append(swagger.Security, map[string][]string{k: {}})
+		}
+	}
+	return swagger, nil
+}
+
+func (b *builder) ignored(rootPath string) bool {
+	for _, prefix := range b.config.IgnorePrefixes {
+		if strings.HasPrefix(rootPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *builder) buildOperation(ws *restful.WebService, route restful.Route) (*spec.Operation, error) {
+	op := &spec.Operation{
+		OperationProps: spec.OperationProps{
+			ID:		route.Operation,
+			Description:	route.Doc,
+			Consumes:	route.Consumes,
+			Produces:	route.Produces,
+			Tags:		[]string{tagForRootPath(ws.RootPath())},
+			Responses: &spec.Responses{
+				ResponsesProps: spec.ResponsesProps{
+					StatusCodeResponses: map[int]spec.Response{},
+				},
+			},
+		},
+	}
+
+	for _, param := range route.ParameterDocs {
+		data := param.Data()
+		if data.Kind == restful.BodyParameterKind {
+			// the installer records the request model on every route; only methods with a body read it
+			if !hasBody(route.Method) || route.ReadSample == nil {
+				continue
+			}
+			schema := b.schemaFor(reflect.TypeOf(route.ReadSample))
+			op.Parameters = append(op.Parameters, spec.Parameter{
+				ParamProps: spec.ParamProps{
+					Name:		data.Name,
+					In:		"body",
+					// delete options are optional
+					Required:	strings.ToUpper(route.Method) != "DELETE",
+					Schema:		&schema,
+				},
+			})
+			continue
+		}
+		p, err := b.buildParameter(data)
+		if err != nil {
+			return nil, err
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	for code, resp := range route.ResponseErrors {
+		r := spec.Response{ResponseProps: spec.ResponseProps{Description: resp.Message}}
+		if resp.Model != nil {
+			schema := b.schemaFor(reflect.TypeOf(resp.Model))
+			r.Schema = &schema
+		}
+		op.Responses.StatusCodeResponses[code] = r
+	}
+	if _, ok := op.Responses.StatusCodeResponses[200]; !ok && route.WriteSample != nil {
+		schema := b.schemaFor(reflect.TypeOf(route.WriteSample))
+		op.Responses.StatusCodeResponses[200] = spec.Response{
+			ResponseProps: spec.ResponseProps{Description: "OK", Schema: &schema},
+		}
+	}
+	if b.config.DefaultResponse != nil {
+		op.Responses.Default = b.config.DefaultResponse
+	}
+	if len(op.Responses.StatusCodeResponses) == 0 && op.Responses.Default == nil {
+		return nil, fmt.Errorf("route %s %s has no responses", route.Method, route.Path)
+	}
+	return op, nil
+}
+
+func (b *builder) buildParameter(data restful.ParameterData) (spec.Parameter, error) {
+	p := spec.Parameter{
+		ParamProps: spec.ParamProps{
+			Name:		data.Name,
+			Description:	data.Description,
+			Required:	data.Required,
+		},
+	}
+	switch data.Kind {
+	case restful.PathParameterKind:
+		p.In = "path"
+		// path parameters are always required
+		p.Required = true
+	case restful.QueryParameterKind:
+		p.In = "query"
+	case restful.HeaderParameterKind:
+		p.In = "header"
+	case restful.FormParameterKind:
+		p.In = "formData"
+	default:
+		return p, fmt.Errorf("unknown parameter kind %d for %q", data.Kind, data.Name)
+	}
+	p.Type = simpleType(data.DataType)
+	p.Format = data.DataFormat
+	return p, nil
+}
+
+// simpleType maps the data types the installer assigns to query parameters onto
+// the OpenAPI primitive types. Anything unrecognized is passed as a string.
+func simpleType(dataType string) string {
+	switch dataType {
+	case "integer", "boolean", "number", "string":
+		return dataType
+	default:
+		return "string"
+	}
+}
+
+// schemaFor returns the schema for t, adding definitions for any structs it references.
+func (b *builder) schemaFor(t reflect.Type) spec.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return *spec.DateTimeProperty()
+	case rawExtensionType:
+		return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}
+	case byteSliceType:
+		return *spec.StrFmtProperty("byte")
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return *spec.StringProperty()
+	case reflect.Bool:
+		return *spec.BoolProperty()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return *spec.Int32Property()
+	case reflect.Int64, reflect.Uint64:
+		return *spec.Int64Property()
+	case reflect.Float32:
+		return *spec.Float32Property()
+	case reflect.Float64:
+		return *spec.Float64Property()
+	case reflect.Slice, reflect.Array:
+		items := b.schemaFor(t.Elem())
+		return *spec.ArrayProperty(&items)
+	case reflect.Map:
+		values := b.schemaFor(t.Elem())
+		return *spec.MapProperty(&values)
+	case reflect.Struct:
+		return *spec.RefSchema("#/definitions/" + b.define(t))
+	default:
+		// interfaces and anything else we can't describe
+		return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}
+	}
+}
+
+// define adds the definition of struct type t, if needed, and returns its name.
+func (b *builder) define(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := definitionName(t)
+	for i := 2; ; i++ {
+		if _, taken := b.definitions[name]; !taken {
+			break
+		}
+		name = fmt.Sprintf("%s%d", definitionName(t), i)
+	}
+	b.names[t] = name
+	// reserve the name before walking the fields, so recursive types terminate
+	b.definitions[name] = spec.Schema{}
+
+	schema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}
+	b.addFields(&schema, t)
+	if gvks := b.groupVersionKinds(t); len(gvks) > 0 {
+		schema.AddExtension(ExtensionGroupVersionKind, gvks)
+	}
+	b.definitions[name] = schema
+	return name
+}
+
+// addFields adds the JSON visible fields of struct type t to schema, flattening inlined structs.
+func (b *builder) addFields(schema *spec.Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			// unexported
+			continue
+		}
+		name, omitEmpty, inline := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(schema, ft)
+				continue
+			}
+		}
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		schema.SetProperty(name, b.schemaFor(field.Type))
+		if !omitEmpty && field.Type.Kind() != reflect.Ptr {
+			schema.AddRequired(name)
+		}
+	}
+}
+
+// parseJSONTag returns the serialized name of field and whether it is omitted when
+// empty or inlined into its parent, following the rules of encoding/json.
+func parseJSONTag(field reflect.StructField) (name string, omitEmpty, inline bool) {
+	tag := field.Tag.Get("json")
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			omitEmpty = true
+		case "inline":
+			inline = true
+		}
+	}
+	if len(name) == 0 {
+		if field.Anonymous {
+			inline = true
+		}
+		name = field.Name
+	}
+	return name, omitEmpty, inline
+}
+
+// groupVersionKinds returns the group/version/kinds the scheme serves struct type t as.
+func (b *builder) groupVersionKinds(t reflect.Type) []map[string]string {
+	if b.config.Typer == nil {
+		return nil
+	}
+	obj, ok := reflect.New(t).Interface().(runtime.Object)
+	if !ok {
+		return nil
+	}
+	kinds, _, err := b.config.Typer.ObjectKinds(obj)
+	if err != nil {
+		return nil
+	}
+	var gvks []map[string]string
+	for _, gvk := range kinds {
+		gvks = append(gvks, map[string]string{
+			"group":	gvk.Group,
+			"version":	gvk.Version,
+			"kind":		gvk.Kind,
+		})
+	}
+	return gvks
+}
+
+// definitionName names a struct type after its package and version, e.g. "core.v1.Account"
+// or "meta.v1.ObjectMeta".
+func definitionName(t reflect.Type) string {
+	dir, pkg := path.Split(t.PkgPath())
+	parent := path.Base(dir)
+	if len(pkg) == 0 {
+		return t.Name()
+	}
+	if parent == "pkg" || parent == "." || parent == "/" {
+		return pkg + "." + t.Name()
+	}
+	return parent + "." + pkg + "." + t.Name()
+}
+
+// tagForRootPath groups operations by web service, e.g. "/api/core/v1" becomes "core_v1".
+func tagForRootPath(rootPath string) string {
+	p := strings.Trim(strings.TrimPrefix(rootPath, "/api"), "/")
+	if len(p) == 0 {
+		p = strings.Trim(rootPath, "/")
+	}
+	return strings.Replace(p, "/", "_", -1)
+}
+
+// trimPathRegex removes go-restful regular expressions from path parameters, e.g.
+// "{path:*}" becomes "{path}".
+func trimPathRegex(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if idx := strings.Index(part, ":"); idx > 0 {
+				parts[i] = part[:idx] + "}"
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func hasBody(method string) bool {
+	switch strings.ToUpper(method) {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package openapi
+
+import (
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/go-openapi/spec"
+
+	metav1 "github.com/rantuttl/cloudops/apimachinery/pkg/apigroups/meta/v1"
+)
+
+type testSpec struct {
+	Replicas	int32		`json:"replicas"`
+	Labels		map[string]string `json:"labels,omitempty"`
+	Created		metav1.Time	`json:"created,omitempty"`
+	Ignored		string		`json:"-"`
+}
+
+type testObject struct {
+	metav1.TypeMeta		`json:",inline"`
+	metav1.ObjectMeta	`json:"metadata,omitempty"`
+	Spec			testSpec	`json:"spec"`
+	Next			*testObject	`json:"next,omitempty"`
+}
+
+func testWebService() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path("/api/test/v1")
+	noop := func(*restful.Request, *restful.Response) {}
+	ws.Route(ws.GET("/objects/{name}").To(noop).
+		Operation("readObject").
+		Param(ws.PathParameter("name", "name of the object")).
+		Param(ws.QueryParameter("pretty", "pretty print").DataType("boolean")).
+		Returns(200, "OK", testObject{}).
+		Reads(testObject{}).
+		Writes(testObject{}))
+	ws.Route(ws.POST("/objects").To(noop).
+		Operation("createObject").
+		Returns(200, "OK", testObject{}).
+		Reads(testObject{}).
+		Writes(testObject{}))
+	return ws
+}
+
+func TestBuildOpenAPISpec(t *testing.T) {
+	config := &Config{
+		Info: &spec.Info{InfoProps: spec.InfoProps{Title: "test", Version: "v1"}},
+		SecurityDefinitions: &spec.SecurityDefinitions{
+			"HTTPBasic": &spec.SecurityScheme{SecuritySchemeProps: spec.SecuritySchemeProps{Type: "basic"}},
+		},
+	}
+	swagger, err := BuildOpenAPISpec([]*restful.WebService{testWebService()}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	get := swagger.Paths.Paths["/api/test/v1/objects/{name}"].Get
+	if get == nil || get.ID != "readObject" {
+		t.Fatalf("expected readObject operation, got %#v", get)
+	}
+	// GET routes record a request model, but must not get a body parameter
+	if len(get.Parameters) != 2 {
+		t.Errorf("expected 2 parameters, got %#v", get.Parameters)
+	}
+	for _, p := range get.Parameters {
+		switch p.Name {
+		case "name":
+			if p.In != "path" || !p.Required {
+				t.Errorf("unexpected path parameter: %#v", p)
+			}
+		case "pretty":
+			if p.In != "query" || p.Type != "boolean" {
+				t.Errorf("unexpected query parameter: %#v", p)
+			}
+		}
+	}
+
+	post := swagger.Paths.Paths["/api/test/v1/objects"].Post
+	if post == nil || len(post.Parameters) != 1 || post.Parameters[0].In != "body" {
+		t.Fatalf("expected a single body parameter, got %#v", post)
+	}
+	if ref := post.Parameters[0].Schema.Ref.String(); ref != "#/definitions/server.openapi.testObject" {
+		t.Errorf("unexpected body ref %q", ref)
+	}
+
+	obj, ok := swagger.Definitions["server.openapi.testObject"]
+	if !ok {
+		t.Fatalf("missing definition for testObject")
+	}
+	for _, name := range []string{"kind", "apiVersion", "metadata", "spec", "next"} {
+		if _, ok := obj.Properties[name]; !ok {
+			t.Errorf("missing property %q", name)
+		}
+	}
+	if len(obj.Required) != 1 || obj.Required[0] != "spec" {
+		t.Errorf("expected only spec to be required, got %v", obj.Required)
+	}
+	s := swagger.Definitions["server.openapi.testSpec"]
+	if _, ok := s.Properties["Ignored"]; ok {
+		t.Errorf("expected ignored field to be skipped")
+	}
+	if created := s.Properties["created"]; created.Format != "date-time" {
+		t.Errorf("expected metav1.Time to be a date-time, got %#v", created)
+	}
+
+	if len(swagger.Security) != 1 || swagger.SecurityDefinitions["HTTPBasic"] == nil {
+		t.Errorf("expected security definitions to be applied, got %#v", swagger.Security)
+	}
+}
+
+func TestTrimPathRegex(t *testing.T) {
+	if p := trimPathRegex("/api/{path:*}"); p != "/api/{path}" {
+		t.Errorf("unexpected path %q", p)
+	}
+}
@@ -126,7 +126,7 @@ func (s *BuiltInAuthenticationOptions) AddDeprecatedFlags(fs *pflag.FlagSet) {
         //fs.MarkDeprecated("port", "see --insecure-port instead.")
 }
 
-func (s *BuiltInAuthenticationOptions) ToAuthenticationConfig() authentication.AuthenticatorConfig {
+func (s *BuiltInAuthenticationOptions) ToAuthenticationConfig() (authentication.AuthenticatorConfig, error) {
 	ret := authentication.AuthenticatorConfig{}
 
 	if s.Anonymous != nil {
@@ -134,7 +134,11 @@ func (s *BuiltInAuthenticationOptions) ToAuthenticationConfig() authentication.A
 	}
 
 	if s.ClientCert != nil {
-		ret.ClientCAFile = s.ClientCert.ClientCA
+		var err error
+		ret.ClientCAContentProvider, err = s.ClientCert.GetClientCAContentProvider()
+		if err != nil {
+			return ret, fmt.Errorf("unable to load client CA file: %v", err)
+		}
 	}
 
 	if s.Keystone != nil {
@@ -146,7 +150,7 @@ func (s *BuiltInAuthenticationOptions) ToAuthenticationConfig() authentication.A
 		ret.BasicAuthFile = s.PasswordFile.BasicAuthFile
 	}
 
-	return ret
+	return ret, nil
 }
 
 func (o *BuiltInAuthenticationOptions) ApplyTo(c *genericserver.Config) error {
@@ -154,12 +158,12 @@ func (o *BuiltInAuthenticationOptions) ApplyTo(c *genericserver.Config) error {
 		return nil
 	}
 
-	var err error
 	if o.ClientCert != nil {
-		c, err = c.ApplyClientCert(o.ClientCert.ClientCA)
+		clientCA, err := o.ClientCert.GetClientCAContentProvider()
 		if err != nil {
 			return fmt.Errorf("unable to load client CA file: %v", err)
 		}
+		c.ApplyClientCert(clientCA)
 	}
 
 	c.SupportsBasicAuth = o.PasswordFile != nil && len(o.PasswordFile.BasicAuthFile) > 0
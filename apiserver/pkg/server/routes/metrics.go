@@ -0,0 +1,32 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package routes
+
+import (
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	apimetrics "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/metrics"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/mux"
+)
+
+// DefaultMetrics installs the default prometheus metrics handler
+type DefaultMetrics struct{}
+
+// Install adds the DefaultMetrics handler, serving `/metrics` in the Prometheus text format.
+func (m DefaultMetrics) Install(c *mux.PathRecorderMux) {
+	apimetrics.Register()
+	c.Handle("/metrics", promhttp.Handler())
+}
@@ -0,0 +1,52 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/openapi"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/mux"
+)
+
+// OpenAPI installs the OpenAPI v2 document of the server's web services.
+type OpenAPI struct {
+	Config *openapi.Config
+}
+
+// Install registers the `/swagger.json` and `/openapi/v2` handlers. The document is built
+// once from the web services registered on c, so it must be called after all APIs are installed.
+func (oa OpenAPI) Install(c *restful.Container, mux *mux.PathRecorderMux) {
+	swagger, err := openapi.BuildOpenAPISpec(c.RegisteredWebServices(), oa.Config)
+	if err != nil {
+		glog.Fatalf("Failed to build open api spec for root: %v", err)
+	}
+	specBytes, err := json.MarshalIndent(swagger, " ", " ")
+	if err != nil {
+		glog.Fatalf("Failed to marshal open api spec: %v", err)
+	}
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(specBytes)
+	}
+	mux.HandleFunc("/swagger.json", handler)
+	mux.HandleFunc("/openapi/v2", handler)
+}
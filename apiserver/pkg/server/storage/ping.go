@@ -0,0 +1,67 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package storage
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	utilerrors "github.com/rantuttl/cloudops/apimachinery/pkg/util/errors"
+)
+
+// Ping connects to the backend server, with a TLS handshake for https servers, within timeout.
+func (b Backend) Ping(timeout time.Duration) error {
+	u, err := url.Parse(b.Server)
+	if err != nil {
+		return err
+	}
+	if len(u.Host) == 0 {
+		return fmt.Errorf("backend server %q has no host", b.Server)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if u.Scheme == "https" {
+		conn, err := tls.DialWithDialer(dialer, "tcp", u.Host, b.TLSConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	conn, err := dialer.Dial("tcp", u.Host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// PingBackends returns nil if any of backends answers a Ping, which is enough for requests to
+// be served.
+func PingBackends(backends []Backend, timeout time.Duration) error {
+	if len(backends) == 0 {
+		return fmt.Errorf("no backend servers are configured")
+	}
+	var errs []error
+	for _, backend := range backends {
+		err := backend.Ping(timeout)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %v", backend.Server, err))
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,57 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package storage
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingBackends(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	// A port nothing listens on.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := Backend{Server: "http://" + closed.Addr().String()}
+	closed.Close()
+	up := Backend{Server: "http://" + listener.Addr().String()}
+
+	if err := PingBackends([]Backend{down, up}, time.Second); err != nil {
+		t.Errorf("expected a reachable backend to be enough, got %v", err)
+	}
+	if err := PingBackends([]Backend{down}, time.Second); err == nil {
+		t.Errorf("expected an error for an unreachable backend")
+	}
+	if err := PingBackends(nil, time.Second); err == nil {
+		t.Errorf("expected an error without backends")
+	}
+}
@@ -17,6 +17,13 @@ package storage
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/golang/glog"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	"github.com/rantuttl/cloudops/apiserver/pkg/backend"
 )
 
 // Backend describes the storage servers, the information here should be enough
@@ -36,12 +43,53 @@ type StorageFactory interface {
 // 2. Resource encodings for storage: group,version,kind to store as
 // 3. Cohabitating default: some resources like hpa are exposed through multiple APIs.  They must agree on 1 and 2
 type DefaultStorageFactory struct {
+	// BackendConfig describes how to connect to the backend servers.
+	BackendConfig backend.Config
+
 	// APIResourceConfigSource indicates whether the *storage* is enabled, NOT the API
 	APIResourceConfigSource APIResourceConfigSource
 }
 
+func NewDefaultStorageFactory(config backend.Config, resourceConfig APIResourceConfigSource) *DefaultStorageFactory {
+	return &DefaultStorageFactory{
+		BackendConfig:           config,
+		APIResourceConfigSource: resourceConfig,
+	}
+}
+
+// Backends returns all backends for all registered storage destinations.
+// Used for getting all instances for health validations.
 func (s *DefaultStorageFactory) Backends() []Backend {
+	servers := sets.NewString(s.BackendConfig.ServerList...)
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	if len(s.BackendConfig.CertFile) > 0 && len(s.BackendConfig.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(s.BackendConfig.CertFile, s.BackendConfig.KeyFile)
+		if err != nil {
+			glog.Errorf("failed to load key pair while getting backends: %s", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+	if len(s.BackendConfig.CAFile) > 0 {
+		if caCert, err := ioutil.ReadFile(s.BackendConfig.CAFile); err != nil {
+			glog.Errorf("failed to read ca file while getting backends: %s", err)
+		} else {
+			caPool := x509.NewCertPool()
+			caPool.AppendCertsFromPEM(caCert)
+			tlsConfig.RootCAs = caPool
+			tlsConfig.InsecureSkipVerify = false
+		}
+	}
 
 	backends := []Backend{}
+	for server := range servers {
+		backends = append(backends, Backend{
+			Server:    server,
+			TLSConfig: tlsConfig,
+		})
+	}
 	return backends
 }
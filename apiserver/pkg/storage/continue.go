@@ -0,0 +1,96 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+)
+
+// ContinueTokenTTL is how long a continue token remains usable after it was issued.
+// Backends only keep list cursors around for a limited time, so older tokens are
+// rejected with a 410 rather than risking an inconsistent page.
+var ContinueTokenTTL = 5 * time.Minute
+
+// continueTokenVersion identifies the encoding of continueToken. Bump it when the
+// token format changes so that tokens issued by an older server are rejected.
+const continueTokenVersion = "meta/v1"
+
+// continueToken is the structure encoded, opaquely, into the continue field of a list.
+type continueToken struct {
+	APIVersion      string `json:"v"`
+	ResourceVersion string `json:"rv"`
+	StartKey        string `json:"start"`
+	IssuedAt        int64  `json:"ts"`
+}
+
+// EncodeContinue returns a continue token that resumes a list of keyPrefix after key, at
+// the given resource version.
+func EncodeContinue(key, keyPrefix, resourceVersion string) (string, error) {
+	nextKey := strings.TrimPrefix(key, keyPrefix)
+	if nextKey == key {
+		return "", fmt.Errorf("unable to encode next field: the key and key prefix do not match")
+	}
+	out, err := json.Marshal(&continueToken{
+		APIVersion:      continueTokenVersion,
+		ResourceVersion: resourceVersion,
+		StartKey:        nextKey,
+		IssuedAt:        time.Now().Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// DecodeContinue transforms an encoded continue token into the key to resume the list of
+// keyPrefix after and the resource version the list was started at. Malformed tokens are
+// a bad request; tokens older than ContinueTokenTTL have expired.
+func DecodeContinue(continueValue, keyPrefix string) (fromKey string, resourceVersion string, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(continueValue)
+	if err != nil {
+		return "", "", errors.NewBadRequest(fmt.Sprintf("continue key is not valid: %v", err))
+	}
+	var c continueToken
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", "", errors.NewBadRequest(fmt.Sprintf("continue key is not valid: %v", err))
+	}
+	if c.APIVersion != continueTokenVersion {
+		return "", "", errors.NewBadRequest(fmt.Sprintf("continue key is not valid: server does not recognize this encoding (%s)", c.APIVersion))
+	}
+	// defend against path traversal attacks by clients - path.Clean will ensure that startKey cannot
+	// be at a higher level of the hierarchy, and so when we append the key prefix we will end up with
+	// continue start key that is fully qualified and cannot range over anything less specific than
+	// keyPrefix.
+	key := c.StartKey
+	if !strings.HasPrefix(key, "/") {
+		key = "/" + key
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || len(c.StartKey) == 0 {
+		return "", "", errors.NewBadRequest(fmt.Sprintf("continue key is not valid: %s", c.StartKey))
+	}
+	if time.Since(time.Unix(c.IssuedAt, 0)) > ContinueTokenTTL {
+		return "", "", errors.NewResourceExpired("The provided continue parameter is too old to display a consistent list result. You must start a new list without the continue parameter.")
+	}
+	return keyPrefix + cleaned[1:], c.ResourceVersion, nil
+}
@@ -0,0 +1,72 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+
+package storage
+
+import (
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/api/errors"
+)
+
+func TestContinueRoundTrip(t *testing.T) {
+	token, err := EncodeContinue("/core/accounts/acme", "/core/accounts/", "42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, rv, err := DecodeContinue(token, "/core/accounts/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "/core/accounts/acme" || rv != "42" {
+		t.Errorf("expected key %q and resource version %q, got %q and %q", "/core/accounts/acme", "42", key, rv)
+	}
+}
+
+func TestDecodeContinueInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	testcases := map[string]string{
+		"not base64":     "%%%",
+		"not json":       encode("acme"),
+		"wrong version":  encode(`{"v":"meta/v0","rv":"1","start":"acme","ts":` + now() + `}`),
+		"path traversal": encode(`{"v":"meta/v1","rv":"1","start":"../users/acme","ts":` + now() + `}`),
+		"empty key":      encode(`{"v":"meta/v1","rv":"1","start":"","ts":` + now() + `}`),
+	}
+	for name, token := range testcases {
+		if _, _, err := DecodeContinue(token, "/core/accounts/"); !errors.IsBadRequest(err) {
+			t.Errorf("%s: expected a bad request error, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeContinueExpired(t *testing.T) {
+	defer func(ttl time.Duration) { ContinueTokenTTL = ttl }(ContinueTokenTTL)
+	token, err := EncodeContinue("/core/accounts/acme", "/core/accounts/", "42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ContinueTokenTTL = -time.Minute
+	if _, _, err := DecodeContinue(token, "/core/accounts/"); !errors.IsResourceExpired(err) {
+		t.Errorf("expected a resource expired error, got %v", err)
+	}
+}
+
+func now() string {
+	return strconv.FormatInt(time.Now().Unix(), 10)
+}
@@ -0,0 +1,235 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/sets"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+)
+
+const (
+	defaultQueues           = 64
+	defaultQueueLengthLimit = 50
+	defaultQueueWaitSeconds = 15
+	catchAllShares          = 5
+)
+
+// LoadConfiguration reads, defaults and validates the flow control configuration in file,
+// which may be either YAML or JSON.
+func LoadConfiguration(file string) (*Configuration, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := &Configuration{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to parse flow control configuration %q: %v", file, err)
+	}
+	setDefaults(config)
+	if err := validate(config); err != nil {
+		return nil, fmt.Errorf("invalid flow control configuration %q: %v", file, err)
+	}
+	return config, nil
+}
+
+func setDefaults(config *Configuration) {
+	for i := range config.PriorityLevels {
+		level := &config.PriorityLevels[i]
+		if level.Queues == 0 {
+			level.Queues = defaultQueues
+		}
+		if level.QueueLengthLimit == 0 {
+			level.QueueLengthLimit = defaultQueueLengthLimit
+		}
+		if level.QueueWaitSeconds == 0 {
+			level.QueueWaitSeconds = defaultQueueWaitSeconds
+		}
+	}
+
+	hasCatchAllLevel := false
+	for _, level := range config.PriorityLevels {
+		if level.Name == CatchAll {
+			hasCatchAllLevel = true
+		}
+	}
+	if !hasCatchAllLevel {
+		config.PriorityLevels = append(config.PriorityLevels, PriorityLevelConfiguration{
+			Name:              CatchAll,
+			ConcurrencyShares: catchAllShares,
+			Queues:            defaultQueues,
+			QueueLengthLimit:  defaultQueueLengthLimit,
+			QueueWaitSeconds:  defaultQueueWaitSeconds,
+		})
+	}
+	hasCatchAllSchema := false
+	for _, schema := range config.FlowSchemas {
+		if schema.Name == CatchAll {
+			hasCatchAllSchema = true
+		}
+	}
+	if !hasCatchAllSchema {
+		// Matches everything, after every other schema.
+		config.FlowSchemas = append(config.FlowSchemas, FlowSchema{
+			Name:                CatchAll,
+			PriorityLevel:       CatchAll,
+			MatchingPrecedence:  int(^uint(0) >> 1),
+			DistinguisherMethod: FlowDistinguisherMethodByUser,
+			Rules:               []PolicyRule{{}},
+		})
+	}
+}
+
+func validate(config *Configuration) error {
+	levels := sets.NewString()
+	for _, level := range config.PriorityLevels {
+		if len(level.Name) == 0 {
+			return fmt.Errorf("priority level name must not be empty")
+		}
+		if levels.Has(level.Name) {
+			return fmt.Errorf("duplicate priority level %q", level.Name)
+		}
+		levels.Insert(level.Name)
+		if !level.Exempt && level.ConcurrencyShares <= 0 {
+			return fmt.Errorf("priority level %q: concurrencyShares must be positive", level.Name)
+		}
+		if level.Queues < 0 || level.QueueLengthLimit < 0 || level.QueueWaitSeconds < 0 {
+			return fmt.Errorf("priority level %q: queues, queueLengthLimit and queueWaitSeconds must not be negative", level.Name)
+		}
+	}
+
+	schemas := sets.NewString()
+	for _, schema := range config.FlowSchemas {
+		if len(schema.Name) == 0 {
+			return fmt.Errorf("flow schema name must not be empty")
+		}
+		if schemas.Has(schema.Name) {
+			return fmt.Errorf("duplicate flow schema %q", schema.Name)
+		}
+		schemas.Insert(schema.Name)
+		if !levels.Has(schema.PriorityLevel) {
+			return fmt.Errorf("flow schema %q: unknown priority level %q", schema.Name, schema.PriorityLevel)
+		}
+		if schema.MatchingPrecedence <= 0 {
+			return fmt.Errorf("flow schema %q: matchingPrecedence must be positive", schema.Name)
+		}
+		switch schema.DistinguisherMethod {
+		case FlowDistinguisherMethodByUser, FlowDistinguisherMethodByTenant, FlowDistinguisherMethodNone:
+		default:
+			return fmt.Errorf("flow schema %q: unknown distinguisherMethod %q", schema.Name, schema.DistinguisherMethod)
+		}
+	}
+	return nil
+}
+
+// sortFlowSchemas returns the schemas in the order they are matched against requests.
+func sortFlowSchemas(schemas []FlowSchema) []FlowSchema {
+	sorted := make([]FlowSchema, len(schemas))
+	copy(sorted, schemas)
+	sort.Stable(byMatchingPrecedence(sorted))
+	return sorted
+}
+
+// byMatchingPrecedence orders flow schemas by matching precedence, then by name.
+type byMatchingPrecedence []FlowSchema
+
+func (s byMatchingPrecedence) Len() int      { return len(s) }
+func (s byMatchingPrecedence) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byMatchingPrecedence) Less(i, j int) bool {
+	if s[i].MatchingPrecedence != s[j].MatchingPrecedence {
+		return s[i].MatchingPrecedence < s[j].MatchingPrecedence
+	}
+	return s[i].Name < s[j].Name
+}
+
+// matches tells whether any of the schema's rules matches the request.
+func (s *FlowSchema) matches(requestInfo *apirequest.RequestInfo, u user.Info) bool {
+	for i := range s.Rules {
+		if s.Rules[i].matches(requestInfo, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// flowDistinguisher returns the value telling apart the flows of the schema.
+func (s *FlowSchema) flowDistinguisher(u user.Info) string {
+	switch s.DistinguisherMethod {
+	case FlowDistinguisherMethodByUser:
+		return u.GetName()
+	case FlowDistinguisherMethodByTenant:
+		if tenants := u.GetExtra()[TenantExtraKey]; len(tenants) > 0 {
+			return tenants[0]
+		}
+		return u.GetName()
+	}
+	return ""
+}
+
+func (r *PolicyRule) matches(requestInfo *apirequest.RequestInfo, u user.Info) bool {
+	if !r.matchesSubject(u) || !matchesAny(r.Verbs, requestInfo.Verb) {
+		return false
+	}
+	if len(r.APIGroups) == 0 && len(r.Resources) == 0 {
+		return true
+	}
+	// A rule naming API groups or resources only matches resource requests.
+	return requestInfo.IsResourceRequest &&
+		matchesAny(r.APIGroups, requestInfo.APIGroup) &&
+		matchesAny(r.Resources, requestInfo.Resource)
+}
+
+func (r *PolicyRule) matchesSubject(u user.Info) bool {
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	for _, name := range r.Users {
+		if name == "*" || name == u.GetName() {
+			return true
+		}
+	}
+	for _, group := range r.Groups {
+		if group == "*" {
+			return true
+		}
+		for _, userGroup := range u.GetGroups() {
+			if group == userGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAny tells whether value is in values. An empty list, or one holding "*", matches
+// any value.
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+
+	"github.com/rantuttl/cloudops/apimachinery/pkg/util/wait"
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+)
+
+// configSyncPeriod is how often the configuration file is checked for changes.
+const configSyncPeriod = 10 * time.Second
+
+// Interface is the flow controller the handler chain hands requests to.
+type Interface interface {
+	// Handle classifies the request, waits for its turn at its priority level and then runs
+	// execute. It returns an error, without running execute, when the request is rejected
+	// because its queue is full or it waited too long.
+	Handle(ctx context.Context, requestInfo *apirequest.RequestInfo, u user.Info, execute func()) error
+
+	// Run reloads the configuration whenever its file changes, until stopCh is closed.
+	Run(stopCh <-chan struct{})
+}
+
+type controller struct {
+	configFile string
+	// serverConcurrencyLimit is the number of requests the server executes at once, shared by
+	// the non-exempt priority levels.
+	serverConcurrencyLimit int
+
+	lock    sync.RWMutex
+	modTime time.Time
+	schemas []FlowSchema
+	levels  map[string]*priorityLevel
+}
+
+// NewController returns a flow controller configured from configFile, sharing
+// serverConcurrencyLimit between its priority levels.
+func NewController(configFile string, serverConcurrencyLimit int) (Interface, error) {
+	if serverConcurrencyLimit <= 0 {
+		return nil, fmt.Errorf("flow control needs a positive concurrency limit, got %d", serverConcurrencyLimit)
+	}
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := LoadConfiguration(configFile)
+	if err != nil {
+		return nil, err
+	}
+	c := &controller{
+		configFile:             configFile,
+		serverConcurrencyLimit: serverConcurrencyLimit,
+		levels:                 map[string]*priorityLevel{},
+	}
+	c.apply(config, info.ModTime())
+	return c, nil
+}
+
+func (c *controller) Run(stopCh <-chan struct{}) {
+	wait.Until(c.syncConfiguration, configSyncPeriod, stopCh)
+}
+
+// syncConfiguration reloads the configuration file when it changed. An invalid configuration
+// is logged and the current one kept.
+func (c *controller) syncConfiguration() {
+	info, err := os.Stat(c.configFile)
+	if err != nil {
+		glog.Errorf("Unable to check flow control configuration %q: %v", c.configFile, err)
+		return
+	}
+	c.lock.RLock()
+	unchanged := info.ModTime().Equal(c.modTime)
+	c.lock.RUnlock()
+	if unchanged {
+		return
+	}
+
+	config, err := LoadConfiguration(c.configFile)
+	if err != nil {
+		glog.Errorf("Keeping the current flow control configuration: %v", err)
+		// Don't retry until the file changes again.
+		c.lock.Lock()
+		c.modTime = info.ModTime()
+		c.lock.Unlock()
+		return
+	}
+	c.apply(config, info.ModTime())
+	glog.Infof("Reloaded flow control configuration from %q", c.configFile)
+}
+
+// apply installs config. Priority levels keeping their name keep their queued and executing
+// requests; the requests of removed levels still complete through them.
+func (c *controller) apply(config *Configuration, modTime time.Time) {
+	totalShares := 0
+	for _, cfg := range config.PriorityLevels {
+		if !cfg.Exempt {
+			totalShares += cfg.ConcurrencyShares
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	levels := make(map[string]*priorityLevel, len(config.PriorityLevels))
+	for _, cfg := range config.PriorityLevels {
+		limit := 0
+		if !cfg.Exempt {
+			limit = int(math.Ceil(float64(c.serverConcurrencyLimit*cfg.ConcurrencyShares) / float64(totalShares)))
+		}
+		level, ok := c.levels[cfg.Name]
+		if !ok {
+			level = &priorityLevel{name: cfg.Name}
+		}
+		level.configure(cfg, limit)
+		levels[cfg.Name] = level
+	}
+	c.levels = levels
+	c.schemas = sortFlowSchemas(config.FlowSchemas)
+	c.modTime = modTime
+}
+
+func (c *controller) Handle(ctx context.Context, requestInfo *apirequest.RequestInfo, u user.Info, execute func()) error {
+	schema, level := c.classify(requestInfo, u)
+	flow := schema.Name + "/" + schema.flowDistinguisher(u)
+	glog.V(6).Infof("Request %s %s of %q matched flow schema %q, priority level %q", requestInfo.Verb, requestInfo.Path, u.GetName(), schema.Name, level.name)
+	if err := level.wait(ctx, flow); err != nil {
+		return err
+	}
+	defer level.finish()
+	execute()
+	return nil
+}
+
+// classify returns the first flow schema matching the request, and its priority level.
+func (c *controller) classify(requestInfo *apirequest.RequestInfo, u user.Info) (FlowSchema, *priorityLevel) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, schema := range c.schemas {
+		if schema.matches(requestInfo, u) {
+			return schema, c.levels[schema.PriorityLevel]
+		}
+	}
+	// Not reached, the catch-all schema matches every request.
+	return FlowSchema{Name: CatchAll}, c.levels[CatchAll]
+}
@@ -0,0 +1,305 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package flowcontrol
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	apirequest "github.com/rantuttl/cloudops/apiserver/pkg/endpoints/request"
+	"github.com/rantuttl/cloudops/apiserver/pkg/server/authentication/user"
+)
+
+const testConfig = `
+priorityLevels:
+- name: exempt
+  exempt: true
+- name: workload
+  concurrencyShares: 1
+  queues: 64
+  queueLengthLimit: 10
+flowSchemas:
+- name: masters
+  priorityLevel: exempt
+  matchingPrecedence: 1
+  rules:
+  - groups: ["system:masters"]
+- name: tenants
+  priorityLevel: workload
+  matchingPrecedence: 100
+  distinguisherMethod: ByTenant
+  rules:
+  - verbs: ["*"]
+    apiGroups: [""]
+    resources: ["accounts"]
+`
+
+func writeConfig(t *testing.T, dir, config string) string {
+	file := filepath.Join(dir, "flowcontrol.yaml")
+	if err := ioutil.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func newTestController(t *testing.T, config string, limit int) (*controller, string, func()) {
+	dir, err := ioutil.TempDir("", "flowcontrol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := writeConfig(t, dir, config)
+	fc, err := NewController(file, limit)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fc.(*controller), file, func() { os.RemoveAll(dir) }
+}
+
+func tenantUser(name, tenant string) user.Info {
+	return &user.DefaultInfo{Name: name, Extra: map[string][]string{TenantExtraKey: {tenant}}}
+}
+
+var accountsRequest = &apirequest.RequestInfo{IsResourceRequest: true, Verb: "list", APIVersion: "v1", Resource: "accounts"}
+
+func TestLoadConfigurationErrors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown level":     "flowSchemas:\n- name: a\n  priorityLevel: missing\n  matchingPrecedence: 1\n",
+		"no shares":         "priorityLevels:\n- name: a\n",
+		"duplicate level":   "priorityLevels:\n- name: a\n  concurrencyShares: 1\n- name: a\n  concurrencyShares: 1\n",
+		"no precedence":     "flowSchemas:\n- name: a\n  priorityLevel: catch-all\n",
+		"bad distinguisher": "flowSchemas:\n- name: a\n  priorityLevel: catch-all\n  matchingPrecedence: 1\n  distinguisherMethod: ByColor\n",
+		"not yaml":          "priorityLevels: [",
+	}
+	dir, err := ioutil.TempDir("", "flowcontrol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, config := range testCases {
+		if _, err := LoadConfiguration(writeConfig(t, dir, config)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c, _, cleanup := newTestController(t, testConfig, 10)
+	defer cleanup()
+
+	testCases := []struct {
+		requestInfo *apirequest.RequestInfo
+		user        user.Info
+		schema      string
+		level       string
+		flow        string
+	}{
+		{
+			requestInfo: accountsRequest,
+			user:        &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			schema:      "masters",
+			level:       "exempt",
+			flow:        "",
+		},
+		{
+			requestInfo: accountsRequest,
+			user:        tenantUser("sync-job", "acme"),
+			schema:      "tenants",
+			level:       "workload",
+			flow:        "acme",
+		},
+		{
+			requestInfo: accountsRequest,
+			user:        &user.DefaultInfo{Name: "bob"},
+			schema:      "tenants",
+			level:       "workload",
+			flow:        "bob",
+		},
+		{
+			requestInfo: &apirequest.RequestInfo{Verb: "get", Path: "/version"},
+			user:        tenantUser("sync-job", "acme"),
+			schema:      CatchAll,
+			level:       CatchAll,
+			flow:        "sync-job",
+		},
+	}
+	for i, tc := range testCases {
+		schema, level := c.classify(tc.requestInfo, tc.user)
+		if schema.Name != tc.schema || level.name != tc.level {
+			t.Errorf("%d: expected %s/%s, got %s/%s", i, tc.schema, tc.level, schema.Name, level.name)
+		}
+		if flow := schema.flowDistinguisher(tc.user); flow != tc.flow {
+			t.Errorf("%d: expected flow %q, got %q", i, tc.flow, flow)
+		}
+	}
+
+	// The server's concurrency is shared by the non-exempt levels.
+	if limit := c.levels["workload"].concurrencyLimit; limit != 2 {
+		t.Errorf("expected a limit of 2 for 1 of 6 shares, got %d", limit)
+	}
+	if limit := c.levels[CatchAll].concurrencyLimit; limit != 9 {
+		t.Errorf("expected a limit of 9 for 5 of 6 shares, got %d", limit)
+	}
+}
+
+// waitForQueued waits until n requests wait at the level.
+func waitForQueued(t *testing.T, l *priorityLevel, n int) {
+	for i := 0; i < 1000; i++ {
+		l.lock.Lock()
+		waiting := l.waiting
+		l.lock.Unlock()
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d requests waiting", n)
+}
+
+func TestFairQueuing(t *testing.T) {
+	c, _, cleanup := newTestController(t, testConfig, 1)
+	defer cleanup()
+	level := c.levels["workload"]
+	if level.concurrencyLimit != 1 {
+		t.Fatalf("expected a limit of 1, got %d", level.concurrencyLimit)
+	}
+
+	// Hold the only seat while the queues fill up.
+	block := make(chan struct{})
+	running := make(chan struct{})
+	go c.Handle(context.TODO(), accountsRequest, tenantUser("holder", "other"), func() {
+		close(running)
+		<-block
+	})
+	<-running
+
+	var lock sync.Mutex
+	var order []string
+	var done sync.WaitGroup
+	handle := func(tenant string) {
+		defer done.Done()
+		err := c.Handle(context.TODO(), accountsRequest, tenantUser("someone", tenant), func() {
+			lock.Lock()
+			defer lock.Unlock()
+			order = append(order, tenant)
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	// The noisy tenant queues first.
+	for i := 0; i < 5; i++ {
+		done.Add(1)
+		go handle("noisy")
+		waitForQueued(t, level, i+1)
+	}
+	done.Add(1)
+	go handle("quiet")
+	waitForQueued(t, level, 6)
+
+	close(block)
+	done.Wait()
+	if len(order) != 6 {
+		t.Fatalf("expected 6 requests served, got %v", order)
+	}
+	if order[0] != "quiet" && order[1] != "quiet" {
+		t.Errorf("expected the quiet tenant to be served before the noisy tenant's backlog, got %v", order)
+	}
+}
+
+func TestRejected(t *testing.T) {
+	config := `
+priorityLevels:
+- name: catch-all
+  concurrencyShares: 1
+  queues: 1
+  queueLengthLimit: 1
+`
+	c, _, cleanup := newTestController(t, config, 1)
+	defer cleanup()
+	level := c.levels[CatchAll]
+	u := &user.DefaultInfo{Name: "bob"}
+
+	block := make(chan struct{})
+	running := make(chan struct{})
+	go c.Handle(context.TODO(), accountsRequest, u, func() {
+		close(running)
+		<-block
+	})
+	<-running
+	defer close(block)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	errCh := make(chan error)
+	go func() {
+		errCh <- c.Handle(ctx, accountsRequest, u, func() { t.Errorf("cancelled request must not execute") })
+	}()
+	waitForQueued(t, level, 1)
+
+	if err := c.Handle(context.TODO(), accountsRequest, u, func() { t.Errorf("rejected request must not execute") }); err == nil {
+		t.Errorf("expected the request to be rejected by a full queue")
+	}
+
+	cancel()
+	if err := <-errCh; err == nil {
+		t.Errorf("expected the cancelled request to be rejected")
+	}
+	waitForQueued(t, level, 0)
+}
+
+func TestSyncConfiguration(t *testing.T) {
+	c, file, cleanup := newTestController(t, testConfig, 10)
+	defer cleanup()
+	bob := &user.DefaultInfo{Name: "bob"}
+	workload := c.levels["workload"]
+
+	touch := func(config string, offset time.Duration) {
+		writeConfig(t, filepath.Dir(file), config)
+		modTime := time.Now().Add(offset)
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		c.syncConfiguration()
+	}
+
+	// An invalid configuration keeps the current one.
+	touch("priorityLevels: [", time.Minute)
+	if schema, _ := c.classify(accountsRequest, bob); schema.Name != "tenants" {
+		t.Errorf("expected the current configuration to be kept, got schema %q", schema.Name)
+	}
+
+	reloaded := testConfig + `
+- name: bob
+  priorityLevel: workload
+  matchingPrecedence: 10
+  rules:
+  - users: ["bob"]
+`
+	touch(reloaded, 2*time.Minute)
+	schema, level := c.classify(accountsRequest, bob)
+	if schema.Name != "bob" {
+		t.Errorf("expected the reloaded configuration, got schema %q", schema.Name)
+	}
+	if level != workload {
+		t.Errorf("expected the priority level to be kept across reloads")
+	}
+}
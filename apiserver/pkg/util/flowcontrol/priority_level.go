@@ -0,0 +1,155 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// priorityLevel executes up to concurrencyLimit requests at once. Further requests wait in
+// queues, a flow always hashing to the same queue, and the queues are served in turn so that
+// a busy flow only delays the flows sharing its queue.
+type priorityLevel struct {
+	name string
+
+	lock             sync.Mutex
+	exempt           bool
+	concurrencyLimit int
+	queueLengthLimit int
+	queueWait        time.Duration
+	queues           [][]*request
+	// next is the queue to dispatch from first.
+	next      int
+	waiting   int
+	executing int
+}
+
+// request is a request waiting in a queue. dispatched is closed once it may execute.
+type request struct {
+	flow       string
+	dispatched chan struct{}
+}
+
+func (l *priorityLevel) configure(cfg PriorityLevelConfiguration, concurrencyLimit int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.exempt = cfg.Exempt
+	l.concurrencyLimit = concurrencyLimit
+	if l.concurrencyLimit < 1 {
+		l.concurrencyLimit = 1
+	}
+	l.queueLengthLimit = cfg.QueueLengthLimit
+	l.queueWait = time.Duration(cfg.QueueWaitSeconds) * time.Second
+	if len(l.queues) != cfg.Queues {
+		old := l.queues
+		l.queues = make([][]*request, cfg.Queues)
+		l.next = 0
+		for _, queue := range old {
+			for _, r := range queue {
+				i := queueIndex(r.flow, len(l.queues))
+				l.queues[i] = append(l.queues[i], r)
+			}
+		}
+	}
+	// A raised limit lets waiting requests through right away.
+	l.dispatchLocked()
+}
+
+// wait returns once the request may execute, or with an error if it is rejected. A nil
+// return must be followed by a call to finish.
+func (l *priorityLevel) wait(ctx context.Context, flow string) error {
+	l.lock.Lock()
+	if l.exempt || (l.waiting == 0 && l.executing < l.concurrencyLimit) {
+		l.executing++
+		l.lock.Unlock()
+		return nil
+	}
+	i := queueIndex(flow, len(l.queues))
+	if len(l.queues[i]) >= l.queueLengthLimit {
+		l.lock.Unlock()
+		return fmt.Errorf("priority level %q: queue is full", l.name)
+	}
+	r := &request{flow: flow, dispatched: make(chan struct{})}
+	l.queues[i] = append(l.queues[i], r)
+	l.waiting++
+	timer := time.NewTimer(l.queueWait)
+	defer timer.Stop()
+	l.lock.Unlock()
+
+	var reason string
+	select {
+	case <-r.dispatched:
+		return nil
+	case <-timer.C:
+		reason = "timed out waiting in queue"
+	case <-ctx.Done():
+		reason = ctx.Err().Error()
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.dequeueLocked(r) {
+		// Dispatched while giving up.
+		return nil
+	}
+	return fmt.Errorf("priority level %q: %s", l.name, reason)
+}
+
+// finish releases the seat of an executing request and dispatches the next waiting one.
+func (l *priorityLevel) finish() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.executing--
+	l.dispatchLocked()
+}
+
+func (l *priorityLevel) dispatchLocked() {
+	for l.waiting > 0 && (l.exempt || l.executing < l.concurrencyLimit) {
+		for len(l.queues[l.next]) == 0 {
+			l.next = (l.next + 1) % len(l.queues)
+		}
+		r := l.queues[l.next][0]
+		l.queues[l.next] = l.queues[l.next][1:]
+		l.next = (l.next + 1) % len(l.queues)
+		l.waiting--
+		l.executing++
+		close(r.dispatched)
+	}
+}
+
+// dequeueLocked removes r from its queue, returning false if it was already dispatched.
+func (l *priorityLevel) dequeueLocked(r *request) bool {
+	i := queueIndex(r.flow, len(l.queues))
+	for j, queued := range l.queues[i] {
+		if queued == r {
+			l.queues[i] = append(l.queues[i][:j], l.queues[i][j+1:]...)
+			l.waiting--
+			return true
+		}
+	}
+	return false
+}
+
+func queueIndex(flow string, queues int) int {
+	h := fnv.New32a()
+	h.Write([]byte(flow))
+	return int(h.Sum32() % uint32(queues))
+}
@@ -0,0 +1,86 @@
+/* Copyright (c) 2016-2017 - CloudPerceptions, LLC. All rights reserved.
+  
+   Licensed under the Apache License, Version 2.0 (the "License"); you may
+   not use this file except in compliance with the License. You may obtain
+   a copy of the License at
+  
+        http://www.apache.org/licenses/LICENSE-2.0
+  
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+   WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+   License for the specific language governing permissions and limitations
+   under the License.
+*/
+
+// Package flowcontrol classifies API requests into flows and priority levels, and shares the
+// server's concurrency between them, so that one noisy client cannot starve the others.
+package flowcontrol
+
+// FlowDistinguisherMethod tells how requests matched by a FlowSchema are split into flows.
+type FlowDistinguisherMethod string
+
+const (
+	// FlowDistinguisherMethodByUser puts the requests of each user in their own flow.
+	FlowDistinguisherMethodByUser FlowDistinguisherMethod = "ByUser"
+	// FlowDistinguisherMethodByTenant puts the requests of each tenant in their own flow. The
+	// tenant is taken from the TenantExtraKey of the user's extra info, or is the user's name
+	// when the authenticator did not provide one.
+	FlowDistinguisherMethodByTenant FlowDistinguisherMethod = "ByTenant"
+	// FlowDistinguisherMethodNone puts all the requests matched by the schema in a single flow.
+	FlowDistinguisherMethodNone FlowDistinguisherMethod = ""
+)
+
+// TenantExtraKey is the key of the user's extra info holding the tenant a user belongs to.
+const TenantExtraKey = "cloudops.io/tenant"
+
+// CatchAll is the name of the priority level, and of the flow schema, serving the requests no
+// configured flow schema matches. Both are added when the configuration does not define them.
+const CatchAll = "catch-all"
+
+// Configuration is the flow control configuration, as read from the configuration file.
+type Configuration struct {
+	PriorityLevels []PriorityLevelConfiguration `json:"priorityLevels"`
+	FlowSchemas    []FlowSchema                 `json:"flowSchemas"`
+}
+
+// PriorityLevelConfiguration describes a priority level, the share of the server's concurrency
+// it gets and how the requests waiting for it are queued.
+type PriorityLevelConfiguration struct {
+	Name string `json:"name"`
+	// Exempt requests are neither limited nor queued.
+	Exempt bool `json:"exempt,omitempty"`
+	// ConcurrencyShares is the level's share of the server's concurrency limit, relative to the
+	// shares of the other non-exempt levels.
+	ConcurrencyShares int `json:"concurrencyShares,omitempty"`
+	// Queues is the number of queues the waiting requests of the level's flows are hashed into.
+	// Queues are served in turn, so that flows get an equal share of the level. Defaults to 64.
+	Queues int `json:"queues,omitempty"`
+	// QueueLengthLimit is the number of requests a queue holds before further requests are
+	// rejected. Defaults to 50.
+	QueueLengthLimit int `json:"queueLengthLimit,omitempty"`
+	// QueueWaitSeconds is how long a request waits in a queue before it is rejected. Defaults
+	// to 15.
+	QueueWaitSeconds int `json:"queueWaitSeconds,omitempty"`
+}
+
+// FlowSchema maps the requests matching any of its rules to a priority level. The schema with
+// the lowest MatchingPrecedence matching a request wins.
+type FlowSchema struct {
+	Name                string                  `json:"name"`
+	PriorityLevel       string                  `json:"priorityLevel"`
+	MatchingPrecedence  int                     `json:"matchingPrecedence"`
+	DistinguisherMethod FlowDistinguisherMethod `json:"distinguisherMethod,omitempty"`
+	Rules               []PolicyRule            `json:"rules"`
+}
+
+// PolicyRule matches requests by subject and resource. An empty list, or one holding "*",
+// matches anything. The subject matches when the user is named in Users or is a member of one
+// of the Groups.
+type PolicyRule struct {
+	Users     []string `json:"users,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	Verbs     []string `json:"verbs,omitempty"`
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
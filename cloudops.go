@@ -24,7 +24,7 @@ import (
 	"github.com/rantuttl/cloudops/apiserver/pkg/util/logs"
 	"github.com/rantuttl/cloudops/apiserver/pkg/util/flag"
 	"github.com/rantuttl/cloudops/cmd/app"
-	"github.com/rantuttl/cloudops/apimachinery/pkg/util/wait"
+	genericapiserver "github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
 	runoptions "github.com/rantuttl/cloudops/cmd/app/options"
 
 	"github.com/spf13/pflag"
@@ -48,7 +48,8 @@ func main() {
 
 	flag.PrintAndExitIfRequested()
 
-	if err := app.Run(s, wait.NeverStop); err != nil {
+	// The server shuts down gracefully on SIGTERM or SIGINT.
+	if err := app.Run(s, genericapiserver.SetupSignalHandler()); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
@@ -37,6 +37,8 @@ type ServerRunOptions struct {
 	InsecureServing         *genericopts.InsecureServingOptions
 	Authentication          *serveropts.BuiltInAuthenticationOptions
 	Authorization		*serveropts.BuiltInAuthorizationOptions
+	Audit			*genericopts.AuditOptions
+	AccessLog		*genericopts.AccessLogOptions
 
 	EnableLogsHandler         bool
 	EventTTL                  time.Duration
@@ -52,6 +54,8 @@ func NewServerRunOptions() *ServerRunOptions {
 		InsecureServing: genericopts.NewInsecureServingOptions(),
 		Authentication:  serveropts.NewBuiltInAuthenticationOptions().WithAll(),
 		Authorization:	serveropts.NewBuiltInAuthorizationOptions(),
+		Audit:		genericopts.NewAuditOptions(),
+		AccessLog:	genericopts.NewAccessLogOptions(),
 		EnableLogsHandler: true,
 		EventTTL:          1 * time.Hour,
 	}
@@ -71,6 +75,8 @@ func (s *ServerRunOptions) AddFlags(fs *pflag.FlagSet) {
 	s.Authentication.AddDeprecatedFlags(fs)
 	s.Authorization.AddFlags(fs)
 	s.Authorization.AddDeprecatedFlags(fs)
+	s.Audit.AddFlags(fs)
+	s.AccessLog.AddFlags(fs)
 
 	// Note: the weird ""+ in below lines seems to be the only way to get gofmt to
 	// arrange these text blocks sensibly. Grrr.
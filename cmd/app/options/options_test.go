@@ -15,9 +15,17 @@
 package options
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/spf13/pflag"
+
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server"
+	"github.com/rantuttl/cloudops/apiserver/pkg/genericserver/server/httplog"
 )
 
 func TestAddFlagsFlag(t *testing.T) {
@@ -38,3 +46,51 @@ func TestAddFlagsFlag(t *testing.T) {
 		t.Errorf("Expected s.Backend.BackendConfig.ServerList to have one entry")
 	}
 }
+
+func TestValidateMaxInFlight(t *testing.T) {
+	for _, flag := range []string{"--max-requests-inflight", "--max-mutating-requests-inflight"} {
+		f := pflag.NewFlagSet("validatetest", pflag.ContinueOnError)
+		s := NewServerRunOptions()
+		s.AddFlags(f)
+		if err := f.Parse([]string{"--backend-servers=http://localhost:3333", flag + "=-1"}); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, err := range s.Validate() {
+			found = found || strings.Contains(err.Error(), flag)
+		}
+		if !found {
+			t.Errorf("expected %s=-1 to be rejected", flag)
+		}
+	}
+}
+
+func TestAccessLogDefaults(t *testing.T) {
+	// Only the format is set, the verbosity and glog's --v are left at their defaults.
+	f := pflag.NewFlagSet("accesslogtest", pflag.ContinueOnError)
+	s := NewServerRunOptions()
+	s.AddFlags(f)
+	if err := f.Parse([]string{"--backend-servers=http://localhost:3333", "--access-log-format=json"}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.AccessLog.Validate(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	c := &server.Config{}
+	if err := s.AccessLog.ApplyTo(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := &bytes.Buffer{}
+	c.AccessLogConfig.Out = out
+
+	handler := httplog.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), nil, c.AccessLogConfig)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/core/v1/accounts", nil))
+
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", out.String(), err)
+	}
+	if record["status"] != float64(http.StatusOK) {
+		t.Errorf("expected the status 200 to be logged, got %v", record["status"])
+	}
+}
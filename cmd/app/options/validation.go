@@ -18,6 +18,9 @@ package options
 // Validate checks ServerRunOptions and return a slice of found errors.
 func (options *ServerRunOptions) Validate() []error {
 	var errors []error
+	if errs := options.GenericServerRunOptions.Validate(); len(errs) > 0 {
+		errors = append(errors, errs...)
+	}
 	if errs := options.Backend.Validate(); len(errs) > 0 {
 		errors = append(errors, errs...)
 	}
@@ -30,5 +33,11 @@ func (options *ServerRunOptions) Validate() []error {
 	if errs := options.InsecureServing.Validate("insecure-port"); len(errs) > 0 {
 		errors = append(errors, errs...)
 	}
+	if errs := options.Audit.Validate(); len(errs) > 0 {
+		errors = append(errors, errs...)
+	}
+	if errs := options.AccessLog.Validate(); len(errs) > 0 {
+		errors = append(errors, errs...)
+	}
 	return errors
 }
@@ -62,7 +62,9 @@ func CreateServerChain(runOptions *options.ServerRunOptions, stopCh <-chan struc
 
 	if insecureServingOptions != nil {
 		insecureHandlerChain := genericapiserver.BuildInsecureHandlerChain(s.UnprotectedHandler(), config.GenericConfig)
-		if err := genericapiserver.NonBlockingRun(insecureServingOptions, insecureHandlerChain, stopCh); err != nil {
+		// The insecure server shares the handler chain, it stops accepting connections along with
+		// the secure one on shutdown.
+		if err := genericapiserver.NonBlockingRun(insecureServingOptions, insecureHandlerChain, config.GenericConfig.ShutdownTimeout, s.ListenersStopCh()); err != nil {
 			return nil, err
 		}
 	}
@@ -130,8 +132,15 @@ func BuildGenericConfig(s *options.ServerRunOptions) (*genericapiserver.Config,
 	if err := s.Authentication.ApplyTo(config); err != nil {
 		return nil, nil, err
 	}
+	if err := s.Audit.ApplyTo(config); err != nil {
+		return nil, nil, err
+	}
+	if err := s.AccessLog.ApplyTo(config); err != nil {
+		return nil, nil, err
+	}
 
-	config.Authenticator, _, err = BuildAuthenticator(s)
+	var securityDefinitions *spec.SecurityDefinitions
+	config.Authenticator, securityDefinitions, err = BuildAuthenticator(s)
 	if err != nil {
 		return nil, nil, fmt.Errorf("invalid authentication config: %v", err)
 	}
@@ -146,6 +155,10 @@ func BuildGenericConfig(s *options.ServerRunOptions) (*genericapiserver.Config,
 	v := version.Get()
 	config.Version = &v
 
+	config.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(api.Scheme)
+	config.OpenAPIConfig.Info.Version = v.GitVersion
+	config.OpenAPIConfig.SecurityDefinitions = securityDefinitions
+
 	return config, insecureServingOptions, nil
 }
 
@@ -161,7 +174,10 @@ func defaultOptions(s *options.ServerRunOptions) error {
 
 func BuildAuthenticator(s *options.ServerRunOptions) (authenticator.Request, *spec.SecurityDefinitions, error) {
 	// apply any server run options to an AuthenticationConfig, then return the Authenticator that implements Request interface
-	authenticatorConfig := s.Authentication.ToAuthenticationConfig()
+	authenticatorConfig, err := s.Authentication.ToAuthenticationConfig()
+	if err != nil {
+		return nil, nil, err
+	}
 	return authenticatorConfig.New()
 }
 
@@ -173,5 +189,5 @@ func BuildAuthorizer(s *options.ServerRunOptions) (authorizer.Authorizer, error)
 }
 
 func BuildStorageFactory(s *options.ServerRunOptions) (*serverstorage.DefaultStorageFactory, error) {
-	return nil, nil
+	return serverstorage.NewDefaultStorageFactory(s.Backend.BackendConfig, master.DefaultAPIResourceConfigSource()), nil
 }
@@ -0,0 +1,77 @@
+#!/bin/bash
+
+# Copyright (c) 2016-2017 CloudPerceptions, LLC. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Generates the generated.pb.go files of the API types from their generated.proto files, with
+# protoc and protoc-gen-gogo. The tree must be in its GOPATH location, as in the build
+# container, and protoc (3.x) must be on the PATH.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+CLOUDOPS_ROOT=$(cd "$(dirname "${BASH_SOURCE}")/.." && pwd)
+GOPATH_SRC=$(cd "${CLOUDOPS_ROOT}/../../.." && pwd)
+GOGO_ROOT="${GOPATH_SRC}/github.com/gogo/protobuf"
+# The gogo/protobuf release the checked-in files are generated with.
+GOGO_VERSION=v1.3.2
+
+# The packages with a generated.proto, dependencies first.
+PACKAGES=(
+  apimachinery/pkg/runtime
+  apimachinery/pkg/apigroups/meta/v1
+  apiserver/pkg/api/core/v1
+)
+
+if [[ "${CLOUDOPS_ROOT}" != "${GOPATH_SRC}/github.com/rantuttl/cloudops" ]]; then
+  echo "The tree must be at \$GOPATH/src/github.com/rantuttl/cloudops, it is at ${CLOUDOPS_ROOT}" >&2
+  exit 1
+fi
+if ! which protoc >/dev/null 2>&1; then
+  echo "protoc not found, install protoc 3.x and add it to the PATH" >&2
+  exit 1
+fi
+
+# protoc-gen-gogo, and gogo.proto for the options of the messages
+if [[ ! -d "${GOGO_ROOT}" ]]; then
+  git clone -q --branch "${GOGO_VERSION}" https://github.com/gogo/protobuf "${GOGO_ROOT}"
+fi
+if [[ "$(git -C "${GOGO_ROOT}" describe --tags 2>/dev/null)" != "${GOGO_VERSION}" ]]; then
+  echo "Warning: ${GOGO_ROOT} is not at ${GOGO_VERSION}, the output may differ from the checked-in files" >&2
+fi
+BIN=$(mktemp -d)
+trap 'rm -rf "${BIN}"' EXIT
+GO111MODULE=off GOPATH=$(cd "${GOPATH_SRC}/.." && pwd) go build -o "${BIN}/protoc-gen-gogo" github.com/gogo/protobuf/protoc-gen-gogo
+
+for pkg in "${PACKAGES[@]}"; do
+  echo "Generating ${pkg}/generated.pb.go"
+  protoc \
+    -I "${GOPATH_SRC}" \
+    -I "${GOGO_ROOT}/protobuf" \
+    --plugin=protoc-gen-gogo="${BIN}/protoc-gen-gogo" \
+    --gogo_out="${GOPATH_SRC}" \
+    "github.com/rantuttl/cloudops/${pkg}/generated.proto"
+  out="${CLOUDOPS_ROOT}/${pkg}/generated.pb.go"
+  # With typedecl_all=false, protoc-gen-gogo still imports the packages of the fields of the
+  # types it does not declare; drop the imports the file does not use.
+  for alias in $(sed -n 's/^\t\([a-z0-9_]*\) ".*"$/\1/p' "${out}"); do
+    if [[ "${alias}" != "_" ]] && ! sed -e 's/"[^"]*"//g' -e 's|//.*||' "${out}" | grep "[^a-zA-Z0-9_]${alias}\." >/dev/null; then
+      sed -i "/^\t${alias} \".*\"$/d" "${out}"
+    fi
+  done
+  # protoc-gen-gogo writes no license header
+  { cat "${CLOUDOPS_ROOT}/hack/boilerplate.go.txt"; echo; cat "${out}"; } > "${BIN}/generated.pb.go"
+  mv "${BIN}/generated.pb.go" "${out}"
+done